@@ -13,12 +13,16 @@ import (
 	"context"
 	"encoding/json"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/sirupsen/logrus"
 
@@ -27,6 +31,7 @@ import (
 	ops "mcp-ambari/internal/operations"
 	"mcp-ambari/internal/operations/actionable"
 	"mcp-ambari/internal/operations/readonly"
+	"mcp-ambari/internal/policy"
 	"mcp-ambari/internal/resources"
 	"mcp-ambari/internal/transport"
 	"mcp-ambari/internal/prompts"
@@ -43,24 +48,147 @@ func main() {
 
 	logger.Info("Starting Tusker Ambari MCP Server (Go)")
 
-	// --- Ambari Client ---
-	timeout, _ := time.ParseDuration(envOr("AMBARI_TIMEOUT", "30s"))
-	ambariClient := client.NewAmbariClient(client.Config{
-		BaseURL:  envOr("AMBARI_BASE_URL", "http://localhost:8080/api/v1"),
-		Username: envOr("AMBARI_USERNAME", "admin"),
-		Password: envOr("AMBARI_PASSWORD", "admin"),
-		Timeout:  timeout,
-		Retries:  3,
-	}, logger)
+	// --- Parse CLI flags ---
+	var flagTransport string
+	var flagHost string
+	var flagPort string
+	flag.StringVar(&flagTransport, "transport", envOr("MCP_TRANSPORT", "stdio"), "Transport mode (stdio, http, https, https-mtls)")
+	flag.StringVar(&flagHost, "host", envOr("MCP_HOST", "127.0.0.1"), "Host address for HTTP transport")
+	flag.StringVar(&flagPort, "port", envOr("MCP_PORT", "8090"), "Port for HTTP transport")
+	flag.Parse()
+	transportMode := transport.Mode(strings.ToLower(flagTransport))
+
+	// --- Ambari Clients (one per named instance) ---
+	var instancesCfg client.InstancesConfig
+	if path := os.Getenv("AMBARI_INSTANCES_FILE"); path != "" {
+		instancesCfg, err = client.LoadInstances(path)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to load Ambari instances")
+		}
+	} else {
+		retries, err := strconv.Atoi(envOr("AMBARI_RETRIES", strconv.Itoa(client.DefaultRetries)))
+		if err != nil || retries < 0 {
+			logger.WithField("value", os.Getenv("AMBARI_RETRIES")).Fatal("AMBARI_RETRIES must be a non-negative integer")
+		}
+		breakerThreshold, err := strconv.Atoi(envOr("AMBARI_BREAKER_THRESHOLD", strconv.Itoa(client.DefaultBreakerThreshold)))
+		if err != nil || breakerThreshold < 0 {
+			logger.WithField("value", os.Getenv("AMBARI_BREAKER_THRESHOLD")).Fatal("AMBARI_BREAKER_THRESHOLD must be a non-negative integer")
+		}
+		maxConcurrent, err := strconv.Atoi(envOr("AMBARI_MAX_CONCURRENT", strconv.Itoa(client.DefaultMaxConcurrent)))
+		if err != nil || maxConcurrent < 1 {
+			logger.WithField("value", os.Getenv("AMBARI_MAX_CONCURRENT")).Fatal("AMBARI_MAX_CONCURRENT must be a positive integer")
+		}
+		instancesCfg.Instances = []client.InstanceConfig{{
+			Name:     client.DefaultInstance,
+			BaseURL:  envOr("AMBARI_BASE_URL", "http://localhost:8080/api/v1"),
+			Username: envOr("AMBARI_USERNAME", "admin"),
+			Password: envOr("AMBARI_PASSWORD", "admin"),
+			Timeout:  envOr("AMBARI_TIMEOUT", "30s"),
+			Retries:  &retries,
+			// A threshold of 0 disables the breaker
+			Breaker: client.BreakerConfig{
+				Disabled:         breakerThreshold == 0,
+				FailureThreshold: breakerThreshold,
+				Cooldown:         envOr("AMBARI_BREAKER_COOLDOWN", "30s"),
+			},
+			MaxConcurrent: maxConcurrent,
+			Auth: client.AuthConfig{
+				Mode:         envOr("AMBARI_AUTH_MODE", client.AuthBasic),
+				SessionReuse: envOr("AMBARI_SESSION_REUSE", "false") == "true",
+				Kerberos: client.KerberosConfig{
+					Krb5Conf:  envOr("KRB5_CONFIG", "/etc/krb5.conf"),
+					Keytab:    envOr("AMBARI_KEYTAB", ""),
+					Principal: envOr("AMBARI_PRINCIPAL", ""),
+					SPN:       envOr("AMBARI_SPN", ""),
+				},
+			},
+			TLS: client.TLSConfig{
+				CAFile:             envOr("AMBARI_TLS_CA_FILE", ""),
+				CertFile:           envOr("AMBARI_TLS_CERT_FILE", ""),
+				KeyFile:            envOr("AMBARI_TLS_KEY_FILE", ""),
+				ServerName:         envOr("AMBARI_TLS_SERVER_NAME", ""),
+				MinVersion:         envOr("AMBARI_TLS_MIN_VERSION", "1.2"),
+				InsecureSkipVerify: envOr("AMBARI_TLS_INSECURE_SKIP_VERIFY", "false") == "true",
+				PinnedSHA256:       splitList(envOr("AMBARI_TLS_PINNED_SHA256", "")),
+			},
+		}}
+	}
+	// The registry routes each request to the instance named by the call
+	instances, err := client.NewRegistry(instancesCfg, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to create Ambari clients")
+	}
+	var ambariClient client.AmbariClient = instances
+	logger.WithFields(logrus.Fields{
+		"instances": len(instances.Instances()), "default": instances.Default(),
+	}).Info("Ambari instances configured")
+
+	// --- Authentication Middleware ---
+	authCfg, err := auth.LoadConfig(envOr("AUTH_CONFIG_FILE", ""))
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load auth configuration")
+	}
+	if err := authCfg.Validate(string(transportMode)); err != nil {
+		logger.WithError(err).Fatal("Invalid auth configuration")
+	}
+	if authCfg.Authenticates() && authCfg.UsesProvider(string(transportMode), auth.ProviderMTLS) && transportMode != transport.ModeMTLS {
+		logger.WithField("transport", transportMode).Fatal("The mtls auth provider requires the mtls transport")
+	}
+	authProvider, err := auth.NewProvider(authCfg, string(transportMode), logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to create auth provider")
+	}
+	if authCfg.AmbariRBAC.Enabled && authProvider != nil {
+		ttl, _ := authCfg.AmbariRBAC.CacheDuration()
+		authProvider = auth.NewAmbariRBACProvider(authProvider, instances, ttl, logger)
+		logger.WithField("cache_ttl", ttl).Info("Deriving permissions from Ambari privileges")
+	}
+	sessionTimeout, err := time.ParseDuration(envOr("MCP_SESSION_TIMEOUT", "30m"))
+	if err != nil || sessionTimeout < 0 {
+		logger.WithField("value", os.Getenv("MCP_SESSION_TIMEOUT")).Fatal("MCP_SESSION_TIMEOUT must be a non-negative duration")
+	}
+	authMW := auth.NewMiddleware(authProvider, authCfg.Authenticates(), sessionTimeout, logger)
+	if transportMode != transport.ModeStdio && !authCfg.Authenticates() {
+		logger.Warn("Serving HTTP WITHOUT authentication (AUTH_ALLOW_UNAUTHENTICATED=true); every caller is treated as admin")
+	}
+	logger.WithFields(logrus.Fields{
+		"enabled": authCfg.Authenticates(), "provider": authCfg.Provider, "group_mappings": len(authCfg.GroupMappings),
+	}).Info("Authentication configured")
+
+	// --- Caller identity for tool calls ---
+	// HTTP transports carry the identity established by authMW; stdio has no
+	// per-request credentials and runs as a configurable local identity.
+	stdioCtx, err := auth.NewStaticAuthContext(
+		envOr("MCP_STDIO_USER", "stdio-user"),
+		splitList(envOr("MCP_STDIO_GROUPS", "ambari-admins")),
+		envOr("MCP_STDIO_ROLE", "ADMIN"),
+		"stdio",
+	)
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid stdio identity")
+	}
+	identity := &identityResolver{stdio: transportMode == transport.ModeStdio, stdioCtx: stdioCtx, authEnabled: authMW.Enabled()}
 
 	// --- Operation Registry (Registry/Factory pattern) ---
 	registry := ops.NewRegistry(logger)
 
-	// Register READ-ONLY operations (safe, GET-only, lower permissions) — 24 tools
+	// Cross-cluster views query at most this many clusters at once
+	aggregateParallelism, err := strconv.Atoi(envOr("AGGREGATE_PARALLELISM", "8"))
+	if err != nil || aggregateParallelism < 1 {
+		logger.WithField("value", os.Getenv("AGGREGATE_PARALLELISM")).Fatal("AGGREGATE_PARALLELISM must be a positive integer")
+	}
+
+	// Register READ-ONLY operations (safe, GET-only, lower permissions) — 29 tools
 	readOnlyOps := []ops.Operation{
 		// Clusters
 		readonly.NewGetClusters(ambariClient, logger),
+		// Instances and cross-cluster views
+		readonly.NewGetInstances(instances, logger),
+		readonly.NewGetConnectionStatus(instances, logger),
+		readonly.NewAggregateAlerts(instances, aggregateParallelism, logger),
+		readonly.NewAggregateServices(instances, aggregateParallelism, logger),
 		readonly.NewGetCluster(ambariClient, logger),
+		readonly.NewGetClusterHealth(ambariClient, logger),
 		// Services
 		readonly.NewGetServices(ambariClient, logger),
 		readonly.NewGetService(ambariClient, logger),
@@ -94,7 +222,7 @@ func main() {
 		}
 	}
 
-	// Register ACTIONABLE operations (state-changing, higher permissions) — 27 tools
+	// Register ACTIONABLE operations (state-changing, higher permissions) — 28 tools
 	// Disabled by default for security - enable with ENABLE_ACTIONABLE_TOOLS=true
 	enableActionable := strings.ToLower(envOr("ENABLE_ACTIONABLE_TOOLS", "false")) == "true"
 	
@@ -145,13 +273,55 @@ func main() {
 		logger.Info("Actionable tools disabled via ENABLE_ACTIONABLE_TOOLS=false")
 	}
 
+	// Policy rules, and the tools to list and approve calls they hold; these
+	// are registered whenever a policy is loaded, since rules can hold
+	// read-only calls too
+	var policies *policy.Engine
+	if path := os.Getenv("POLICY_FILE"); path != "" {
+		policies, err = policy.NewEngine(path, logger)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to load policy")
+		}
+		for _, op := range []ops.Operation{
+			readonly.NewGetApprovals(policies, logger),
+			actionable.NewApproveCall(policies, logger),
+		} {
+			if err := registry.Register(op); err != nil {
+				logger.WithError(err).Fatal("Failed to register policy operation")
+			}
+		}
+	}
+
 	total, ro, act := registry.Count()
 	logger.WithFields(logrus.Fields{
 		"total": total, "readonly": ro, "actionable": act,
 	}).Info("Operations registered")
 
 	// --- Operation Executor (Template Method pattern) ---
-	executor := ops.NewExecutor(ambariClient, logger)
+	// Per-user Ambari credentials only apply to transports with per-request identities
+	var credentials *auth.CredentialResolver
+	if transportMode != transport.ModeStdio {
+		credentials, err = auth.NewCredentialResolver(authCfg.AmbariCredentials, logger)
+		if err != nil {
+			logger.WithError(err).Fatal("Invalid Ambari credentials configuration")
+		}
+	}
+	logger.WithField("mode", credentials.Mode()).Info("Ambari credentials configured")
+	// Every tool's input schema is published and enforced, and typed tools
+	// publish an output schema too; a broken schema is a bug, so refuse to start
+	var instanceNames []string
+	for _, inst := range instances.Instances() {
+		instanceNames = append(instanceNames, inst.Name)
+	}
+	schemas, err := registry.CheckSchemas(instanceNames...)
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid tool input schema")
+	}
+	outputSchemas, err := registry.OutputSchemas()
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid tool output schema")
+	}
+	executor := ops.NewExecutor(ambariClient, policies, credentials, schemas, logger)
 
 	// --- MCP Server using Go SDK ---
 	implementation := &mcp.Implementation{
@@ -162,13 +332,19 @@ func main() {
 
 	// Register each operation as an MCP tool via the SDK
 	for _, op := range registry.All() {
-		registerMCPTool(mcpServer, op, executor, logger)
+		registerMCPTool(mcpServer, op, schemas[op.Name()].Schema(), outputSchemas[op.Name()], executor, identity, logger)
 	}
 
 	// --- MCP Resources (all read-only, accessed by URI) ---
-	resRegistry := resources.NewRegistry(ambariClient, logger)
+	// Reads are checked and run as the caller like tool calls
+	resRegistry := resources.NewRegistry(ambariClient, executor, logger)
 	for _, resDef := range resRegistry.Definitions() {
-		registerMCPResource(mcpServer, resDef, resRegistry, logger)
+		registerMCPResource(mcpServer, resDef, resRegistry, identity, logger)
+		// Every resource is also readable from a named instance
+		instanceDef := resDef
+		instanceDef.URI = resources.InstanceURI(resDef.URI)
+		instanceDef.Name = resDef.Name + " (named instance)"
+		registerMCPResource(mcpServer, instanceDef, resRegistry, identity, logger)
 	}
 
 	// --- MCP Prompts (reusable templates for common workflows) ---
@@ -181,15 +357,6 @@ func main() {
 		"tools": total, "resources": resRegistry.Count(), "prompts": promptRegistry.Count(),
 	}).Info("MCP server fully initialized")
 
-	// --- Parse CLI flags ---
-	var flagTransport string
-	var flagHost string
-	var flagPort string
-	flag.StringVar(&flagTransport, "transport", envOr("MCP_TRANSPORT", "stdio"), "Transport mode (stdio, http, https, https-mtls)")
-	flag.StringVar(&flagHost, "host", envOr("MCP_HOST", "127.0.0.1"), "Host address for HTTP transport")
-	flag.StringVar(&flagPort, "port", envOr("MCP_PORT", "8090"), "Port for HTTP transport")
-	flag.Parse()
-
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -202,19 +369,36 @@ func main() {
 		cancel()
 	}()
 
-	// --- Authentication Middleware ---
-	// Create default LDAP provider for development (disabled)
-	ldapProvider := auth.NewLDAPProvider("x-remote-", defaultGroupMappings(), []string{"cluster:admin", "service:admin"}, logger)
-	authMW := auth.NewMiddleware(ldapProvider, false, logger) // Disabled auth for development
+	// Reload rotatable credentials (e.g. API keys) and policy rules on SIGHUP
+	reloadCredentials, hasCredentials := authProvider.(auth.Reloader)
+	if hasCredentials || policies != nil {
+		hupCh := make(chan os.Signal, 1)
+		signal.Notify(hupCh, syscall.SIGHUP)
+		go func() {
+			for range hupCh {
+				if hasCredentials {
+					if err := reloadCredentials.Reload(); err != nil {
+						logger.WithError(err).Error("Credential reload failed; keeping previous credentials")
+					}
+				}
+				if policies != nil {
+					if err := policies.Reload(); err != nil {
+						logger.WithError(err).Error("Policy reload failed; keeping previous rules")
+					}
+				}
+			}
+		}()
+	}
 
 	// --- Transport Configuration ---
 	transportCfg := transport.Config{
-		Mode:       transport.Mode(strings.ToLower(flagTransport)),
-		Host:       flagHost,
-		Port:       flagPort,
-		SSLCert:    envOr("TLS_CERT_FILE", ""),
-		SSLKey:     envOr("TLS_KEY_FILE", ""),
-		SSLCACerts: envOr("TLS_CA_FILE", ""),
+		Mode:           transportMode,
+		Host:           flagHost,
+		Port:           flagPort,
+		SSLCert:        envOr("TLS_CERT_FILE", ""),
+		SSLKey:         envOr("TLS_KEY_FILE", ""),
+		SSLCACerts:     envOr("TLS_CA_FILE", ""),
+		SessionTimeout: sessionTimeout,
 	}
 
 	// Create transport using factory
@@ -235,47 +419,116 @@ func main() {
 	}
 }
 
-// registerMCPTool bridges our Operation interface to the SDK's mcp.Server using the proper API
-func registerMCPTool(server *mcp.Server, op ops.Operation, executor *ops.Executor, logger *logrus.Logger) {
+// registerMCPTool bridges our Operation interface to the SDK's mcp.Server. The
+// tool is added with Server.AddTool, which publishes the schema without
+// validating arguments against it: the executor checks and normalizes them and
+// reports bad ones as INVALID_ARGUMENT tool errors instead of JSON-RPC errors.
+//
+// Tools with an output schema return their typed result as structuredContent
+// and its text rendering as content; the others return the OperationResult
+// as both. Error results of typed tools carry text only, since the error
+// object does not match their output schema.
+func registerMCPTool(server *mcp.Server, op ops.Operation, schema, outputSchema *jsonschema.Schema, executor *ops.Executor, identity *identityResolver, logger *logrus.Logger) {
 	def := op.Definition()
 
 	// Create MCP tool definition
 	tool := &mcp.Tool{
 		Name:        def.Name,
 		Description: def.Description,
+		InputSchema: schema,
+	}
+	if outputSchema != nil {
+		tool.OutputSchema = outputSchema
 	}
 
 	// Create the tool handler function that matches the SDK's expected signature
-	handler := func(ctx context.Context, req *mcp.CallToolRequest, input map[string]interface{}) (*mcp.CallToolResult, map[string]interface{}, error) {
-		// Create default auth context for stdio
-		authCtx := &auth.AuthContext{
-			Username: "stdio-user", Groups: []string{"ambari-admins"},
-			Permissions: auth.PermissionGroups["ADMIN"],
-			IsValidated: true, Source: "stdio",
+	handler := func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		input := map[string]interface{}{}
+		if len(req.Params.Arguments) > 0 {
+			if err := json.Unmarshal(req.Params.Arguments, &input); err != nil {
+				return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: fmt.Sprintf("arguments of %s: %v", op.Name(), err)}
+			}
+		}
+
+		// Resolve the caller identity established by the transport. A call
+		// without one is a protocol error, not a tool failure.
+		authCtx, err := identity.resolve(ctx, req.Extra)
+		if err != nil {
+			logger.WithFields(logrus.Fields{"tool": op.Name(), "error": err}).Warn("Rejected unauthenticated tool call")
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidRequest, Message: err.Error()}
 		}
 
-		// Execute the operation through our executor
+		// Execute the operation through our executor; failures are returned as
+		// tool results the model can read and act on
 		result, err := executor.Run(ctx, op, input, authCtx)
 		if err != nil {
+			te := ops.AsToolError(op.Name(), err)
 			logger.WithFields(logrus.Fields{
-				"tool": op.Name(), "type": op.Type(), "error": err,
+				"tool": op.Name(), "type": op.Type(), "code": te.Code, "error": err,
 			}).Error("Operation failed")
-			return nil, nil, err
+			failed := &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{&mcp.TextContent{Text: te.Text()}},
+			}
+			if outputSchema == nil {
+				failed.StructuredContent = map[string]interface{}{"error": te}
+			}
+			return failed, nil
+		}
+
+		if outputSchema != nil {
+			return &mcp.CallToolResult{
+				Meta: mcp.Meta{
+					"tool": result.Tool, "operation_type": result.OperationType,
+					"execution_ms": result.ExecutionMs, "timestamp": result.Timestamp,
+				},
+				Content:           []mcp.Content{&mcp.TextContent{Text: result.Text}},
+				StructuredContent: result.Structured,
+			}, nil
 		}
 
-		return nil, map[string]interface{}{"result": result}, nil
+		out := map[string]interface{}{"result": result}
+		text, err := json.Marshal(out)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling result of %s: %w", op.Name(), err)
+		}
+		return &mcp.CallToolResult{
+			Content:           []mcp.Content{&mcp.TextContent{Text: string(text)}},
+			StructuredContent: out,
+		}, nil
 	}
 
 	// Register the tool with the SDK
-	mcp.AddTool(server, tool, handler)
+	server.AddTool(tool, handler)
 
 	logger.WithFields(logrus.Fields{
 		"tool": def.Name, "type": op.Type(), "category": op.Category(),
 	}).Debug("MCP tool registered")
 }
 
+// identityResolver decides which AuthContext a tool call or resource read
+// executes as
+type identityResolver struct {
+	stdio       bool
+	stdioCtx    *auth.AuthContext
+	authEnabled bool
+}
+
+func (r *identityResolver) resolve(ctx context.Context, extra *mcp.RequestExtra) (*auth.AuthContext, error) {
+	if authCtx, ok := auth.FromRequest(ctx, extra); ok {
+		return authCtx, nil
+	}
+	if r.stdio {
+		return r.stdioCtx, nil
+	}
+	if r.authEnabled {
+		return nil, fmt.Errorf("authentication required")
+	}
+	return nil, fmt.Errorf("no identity attached to request")
+}
+
 // registerMCPResource bridges our resource registry to the SDK's mcp.Server using the proper API
-func registerMCPResource(server *mcp.Server, resDef resources.ResourceDefinition, resReg *resources.Registry, logger *logrus.Logger) {
+func registerMCPResource(server *mcp.Server, resDef resources.ResourceDefinition, resReg *resources.Registry, identity *identityResolver, logger *logrus.Logger) {
 	// Create MCP resource definition
 	resource := &mcp.Resource{
 		URI:         resDef.URI,
@@ -286,8 +539,14 @@ func registerMCPResource(server *mcp.Server, resDef resources.ResourceDefinition
 
 	// Create resource handler
 	handler := mcp.ResourceHandler(func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		authCtx, err := identity.resolve(ctx, req.Extra)
+		if err != nil {
+			logger.WithFields(logrus.Fields{"uri": req.Params.URI, "error": err}).Warn("Rejected unauthenticated resource read")
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidRequest, Message: err.Error()}
+		}
+
 		// Use our resource registry to resolve the resource
-		result, err := resReg.Read(ctx, req.Params.URI)
+		result, err := resReg.Read(ctx, req.Params.URI, authCtx)
 		if err != nil {
 			logger.WithFields(logrus.Fields{
 				"uri":   req.Params.URI,
@@ -322,8 +581,17 @@ func registerMCPResource(server *mcp.Server, resDef resources.ResourceDefinition
 		}, nil
 	})
 
-	// Register the resource with the SDK
-	server.AddResource(resource, handler)
+	// Register the resource with the SDK; parameterized URIs are templates
+	if strings.Contains(resDef.URI, "{") {
+		server.AddResourceTemplate(&mcp.ResourceTemplate{
+			URITemplate: resDef.URI,
+			Name:        resDef.Name,
+			Description: resDef.Description,
+			MIMEType:    resDef.MimeType,
+		}, handler)
+	} else {
+		server.AddResource(resource, handler)
+	}
 
 	logger.WithFields(logrus.Fields{
 		"uri":  resDef.URI,
@@ -389,20 +657,12 @@ func envOr(key, fallback string) string {
 	return fallback
 }
 
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
 		}
 	}
-	return false
-}
-
-func defaultGroupMappings() map[string][]string {
-	return map[string][]string{
-		"ambari-admins":    {"cluster:admin", "service:admin", "alert:admin", "config:modify", "host:manage"},
-		"hadoop-operators": {"cluster:operate", "service:operate", "service:restart", "alert:manage"},
-		"data-engineers":   {"cluster:view", "service:view", "service:operate", "config:view"},
-		"bigdata-viewers":  {"cluster:view", "service:view", "alert:view", "config:view", "host:view"},
-	}
+	return out
 }
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"mcp-ambari/internal/client"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AmbariRBACConfig enables deriving permissions from the caller's Ambari privileges
+type AmbariRBACConfig struct {
+	Enabled  bool   `json:"enabled"`
+	CacheTTL string `json:"cache_ttl"`
+}
+
+// CacheDuration returns the privilege cache TTL, five minutes by default
+func (c AmbariRBACConfig) CacheDuration() (time.Duration, error) {
+	if c.CacheTTL == "" {
+		return 5 * time.Minute, nil
+	}
+	ttl, err := time.ParseDuration(c.CacheTTL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ambari_rbac cache_ttl %q: %w", c.CacheTTL, err)
+	}
+	return ttl, nil
+}
+
+// AmbariRolePermissions maps Ambari permission names (roles) to the MCP
+// permissions they grant. Cluster roles are granted on their cluster only,
+// and every role only in the instance it was granted in.
+var AmbariRolePermissions = map[string][]Permission{
+	"AMBARI.ADMINISTRATOR": AllPermissions,
+	"CLUSTER.ADMINISTRATOR": {
+		ClusterView, ClusterAdmin, ServiceView, ServiceOperate, ServiceRestart, ServiceAdmin,
+		HostView, HostManage, AlertView, AlertManage, AlertAdmin, ConfigView, ConfigModify,
+	},
+	"CLUSTER.OPERATOR": {
+		ClusterView, ServiceView, ServiceOperate, ServiceRestart, HostView, HostManage,
+		AlertView, AlertManage, ConfigView, ConfigModify,
+	},
+	"SERVICE.ADMINISTRATOR": {
+		ClusterView, ServiceView, ServiceOperate, ServiceRestart, ServiceAdmin,
+		HostView, AlertView, AlertManage, ConfigView, ConfigModify,
+	},
+	"SERVICE.OPERATOR": {
+		ClusterView, ServiceView, ServiceOperate, ServiceRestart, HostView, AlertView, ConfigView,
+	},
+	"CLUSTER.USER": {
+		ClusterView, ServiceView, HostView, AlertView, ConfigView,
+	},
+}
+
+// AmbariRBACProvider decorates another AuthProvider: once the inner provider
+// has identified the user, their permissions are replaced by those derived
+// from the user's privileges in each Ambari instance, so Ambari stays the
+// single source of truth for roles.
+type AmbariRBACProvider struct {
+	inner     AuthProvider
+	instances *client.Registry
+	ttl       time.Duration
+	mu        sync.Mutex
+	cache     map[rbacKey]rbacEntry
+	logger    *logrus.Logger
+}
+
+// rbacKey identifies a user's privileges in one instance; the same username
+// can belong to different people, with different roles, in different instances
+type rbacKey struct {
+	instance string
+	username string
+}
+
+type rbacEntry struct {
+	permissions []Permission
+	expires     time.Time
+}
+
+// NewAmbariRBACProvider wraps inner. Privileges are looked up in every
+// instance and cached per instance and user for ttl.
+func NewAmbariRBACProvider(inner AuthProvider, instances *client.Registry, ttl time.Duration, logger *logrus.Logger) *AmbariRBACProvider {
+	return &AmbariRBACProvider{
+		inner:     inner,
+		instances: instances,
+		ttl:       ttl,
+		cache:     make(map[rbacKey]rbacEntry),
+		logger:    logger,
+	}
+}
+
+func (p *AmbariRBACProvider) Name() string {
+	return p.inner.Name()
+}
+
+// Authenticate grants the union of the user's permissions in all instances,
+// each scoped to its instance. Instances whose privileges cannot be read grant
+// nothing; the user is rejected only when none can be read.
+func (p *AmbariRBACProvider) Authenticate(ctx context.Context, headers map[string]string) (*AuthContext, error) {
+	authCtx, err := p.inner.Authenticate(ctx, headers)
+	if err != nil {
+		return nil, err
+	}
+	var perms []Permission
+	var errs []error
+	for _, inst := range p.instances.Instances() {
+		granted, err := p.permissions(ctx, inst, authCtx.Username)
+		if err != nil {
+			p.logger.WithFields(logrus.Fields{"user": authCtx.Username, "instance": inst.Name, "error": err}).Warn("Ambari privileges unavailable")
+			errs = append(errs, fmt.Errorf("%s: %w", inst.Name, err))
+			continue
+		}
+		perms = append(perms, granted...)
+	}
+	if len(errs) == len(p.instances.Instances()) {
+		return nil, fmt.Errorf("look up Ambari privileges for %s: %w", authCtx.Username, errors.Join(errs...))
+	}
+	authCtx = authCtx.Clone()
+	authCtx.Permissions = perms
+	return authCtx, nil
+}
+
+// permissions returns the cached permissions of a user in an instance,
+// fetching them from Ambari when missing or expired. Failed lookups are not
+// cached.
+func (p *AmbariRBACProvider) permissions(ctx context.Context, inst *client.Instance, username string) ([]Permission, error) {
+	key := rbacKey{instance: inst.Name, username: username}
+	p.mu.Lock()
+	entry, ok := p.cache[key]
+	p.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.permissions, nil
+	}
+
+	resp, err := inst.Client.Get(ctx, fmt.Sprintf("/users/%s/privileges", url.PathEscape(username)), map[string]string{
+		"fields": "PrivilegeInfo/*",
+	})
+	if err != nil {
+		return nil, err
+	}
+	perms := privilegesToPermissions(inst.Name, resp)
+
+	now := time.Now()
+	p.mu.Lock()
+	for k, e := range p.cache {
+		if !now.Before(e.expires) {
+			delete(p.cache, k)
+		}
+	}
+	p.cache[key] = rbacEntry{permissions: perms, expires: now.Add(p.ttl)}
+	p.mu.Unlock()
+
+	p.logger.WithFields(logrus.Fields{"user": username, "instance": inst.Name, "permissions": len(perms)}).Debug("Ambari privileges loaded")
+	return perms, nil
+}
+
+// privilegesToPermissions converts an Ambari privileges response of an
+// instance into grants. AMBARI-level roles cover the whole instance; cluster
+// roles are scoped to their cluster in it.
+func privilegesToPermissions(instance string, resp map[string]interface{}) []Permission {
+	seen := make(map[Permission]bool)
+	var perms []Permission
+	items, _ := resp["items"].([]interface{})
+	for _, item := range items {
+		m, _ := item.(map[string]interface{})
+		info, _ := m["PrivilegeInfo"].(map[string]interface{})
+		role, _ := info["permission_name"].(string)
+		cluster, _ := info["cluster_name"].(string)
+		for _, perm := range AmbariRolePermissions[role] {
+			grant := Permission(fmt.Sprintf("%s@%s:", perm, instance))
+			if info["type"] == "CLUSTER" {
+				if cluster == "" {
+					continue
+				}
+				grant += Permission(cluster)
+			}
+			if !seen[grant] {
+				seen[grant] = true
+				perms = append(perms, grant)
+			}
+		}
+	}
+	return perms
+}
+
+// Reload drops cached privileges and reloads the inner provider if it supports it
+func (p *AmbariRBACProvider) Reload() error {
+	p.mu.Lock()
+	p.cache = make(map[rbacKey]rbacEntry)
+	p.mu.Unlock()
+	if reloader, ok := p.inner.(Reloader); ok {
+		return reloader.Reload()
+	}
+	return nil
+}
+
+func (p *AmbariRBACProvider) Challenge(err error) string {
+	if challenger, ok := p.inner.(Challenger); ok {
+		return challenger.Challenge(err)
+	}
+	return ""
+}
+
+func (p *AmbariRBACProvider) MetadataHandlers() map[string]http.Handler {
+	if publisher, ok := p.inner.(MetadataPublisher); ok {
+		return publisher.MetadataHandlers()
+	}
+	return nil
+}
+
+func (p *AmbariRBACProvider) Sanitize(ctx context.Context, header http.Header) {
+	if sanitizer, ok := p.inner.(HeaderSanitizer); ok {
+		sanitizer.Sanitize(ctx, header)
+	}
+}
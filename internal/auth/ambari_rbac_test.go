@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-ambari/internal/client"
+)
+
+// privilegesServer serves /users/{user}/privileges from roles, a map from
+// user to "ROLE" or "ROLE@cluster" entries, and counts the lookups
+type privilegesServer struct {
+	mu      sync.Mutex
+	roles   map[string][]string
+	lookups int
+}
+
+func (s *privilegesServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups++
+	user := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/users/"), "/privileges")
+	roles, ok := s.roles[user]
+	if !ok {
+		http.Error(w, `{"status":404,"message":"user not found"}`, http.StatusNotFound)
+		return
+	}
+	var items []string
+	for _, role := range roles {
+		name, cluster, scoped := strings.Cut(role, "@")
+		typ := "AMBARI"
+		if scoped {
+			typ = "CLUSTER"
+		}
+		items = append(items, fmt.Sprintf(`{"PrivilegeInfo":{"permission_name":%q,"type":%q,"cluster_name":%q}}`, name, typ, cluster))
+	}
+	fmt.Fprintf(w, `{"items":[%s]}`, strings.Join(items, ","))
+}
+
+func (s *privilegesServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lookups
+}
+
+func testRBAC(t *testing.T, ttl time.Duration, east, west *privilegesServer) *AmbariRBACProvider {
+	t.Helper()
+	var cfg client.InstancesConfig
+	for name, srv := range map[string]*privilegesServer{"east": east, "west": west} {
+		ts := httptest.NewServer(srv)
+		t.Cleanup(ts.Close)
+		retries := 0
+		cfg.Instances = append(cfg.Instances, client.InstanceConfig{Name: name, BaseURL: ts.URL, Username: "svc", Password: "pw", Retries: &retries})
+	}
+	sort.Slice(cfg.Instances, func(i, j int) bool { return cfg.Instances[i].Name < cfg.Instances[j].Name })
+	instances, err := client.NewRegistry(cfg, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewAmbariRBACProvider(&staticProvider{name: "static"}, instances, ttl, testLogger())
+}
+
+func authenticateAs(p *AmbariRBACProvider, username string) (*AuthContext, error) {
+	p.inner.(*staticProvider).authCtx = &AuthContext{Username: username}
+	return p.Authenticate(context.Background(), nil)
+}
+
+func TestAmbariRBACGrants(t *testing.T) {
+	east := &privilegesServer{roles: map[string][]string{
+		"alice": {"CLUSTER.USER@c1"},
+		"bob":   {"AMBARI.ADMINISTRATOR"},
+	}}
+	west := &privilegesServer{roles: map[string][]string{
+		"alice": {"CLUSTER.OPERATOR@c1"},
+	}}
+	p := testRBAC(t, time.Minute, east, west)
+
+	tests := []struct {
+		user    string
+		perm    Permission
+		target  Scope
+		want    bool
+		wantErr bool
+	}{
+		{user: "alice", perm: ServiceView, target: Scope{Instance: "east", Cluster: "c1"}, want: true},
+		{user: "alice", perm: ServiceRestart, target: Scope{Instance: "east", Cluster: "c1"}, want: false},
+		{user: "alice", perm: ServiceRestart, target: Scope{Instance: "west", Cluster: "c1"}, want: true},
+		{user: "alice", perm: ServiceView, target: Scope{Instance: "east", Cluster: "c2"}, want: false},
+		{user: "alice", perm: ClusterView, target: Scope{Instance: "east"}, want: false},
+		{user: "bob", perm: ClusterAdmin, target: Scope{Instance: "east"}, want: true},
+		{user: "bob", perm: ServiceAdmin, target: Scope{Instance: "east", Cluster: "c9"}, want: true},
+		{user: "bob", perm: ClusterView, target: Scope{Instance: "west", Cluster: "c1"}, want: false},
+		{user: "mallory", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s on %s", tt.user, tt.perm, tt.target), func(t *testing.T) {
+			authCtx, err := authenticateAs(p, tt.user)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Authenticate() = %v, want error", authCtx.Permissions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if got := authCtx.HasPermissionOn(tt.perm, tt.target); got != tt.want {
+				t.Errorf("HasPermissionOn() = %v, want %v (grants %v)", got, tt.want, authCtx.Permissions)
+			}
+		})
+	}
+}
+
+func TestAmbariRBACCache(t *testing.T) {
+	east := &privilegesServer{roles: map[string][]string{"alice": {"CLUSTER.USER@c1"}, "bob": {"CLUSTER.USER@c1"}}}
+	west := &privilegesServer{roles: map[string][]string{"alice": {"AMBARI.ADMINISTRATOR"}}}
+
+	t.Run("cached per instance and user", func(t *testing.T) {
+		p := testRBAC(t, time.Minute, east, west)
+		for i := 0; i < 3; i++ {
+			authCtx, err := authenticateAs(p, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if authCtx.HasPermissionOn(ClusterAdmin, Scope{Instance: "east"}) {
+				t.Fatalf("west privileges leaked into east: %v", authCtx.Permissions)
+			}
+		}
+		if east.count() != 1 || west.count() != 1 {
+			t.Errorf("lookups east=%d west=%d, want 1 each", east.count(), west.count())
+		}
+		if len(p.cache) != 2 {
+			t.Errorf("cache has %d entries, want 2", len(p.cache))
+		}
+	})
+
+	t.Run("expired entries evicted", func(t *testing.T) {
+		p := testRBAC(t, time.Millisecond, east, west)
+		if _, err := authenticateAs(p, "alice"); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		if _, err := authenticateAs(p, "bob"); err != nil {
+			t.Fatal(err)
+		}
+		for key := range p.cache {
+			if key.username != "bob" {
+				t.Errorf("expired entry %v kept", key)
+			}
+		}
+	})
+}
@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// APIKeyConfig configures the static API key provider
+type APIKeyConfig struct {
+	KeysFile string `json:"keys_file"`
+}
+
+// APIKeyEntry is one key in the keys file. Presented keys have the form
+// "<name>.<secret>"; Hash is a bcrypt or argon2id (PHC format) hash of the
+// whole presented key.
+type APIKeyEntry struct {
+	Name        string     `json:"name"`
+	Hash        string     `json:"hash"`
+	Groups      []string   `json:"groups"`
+	Permissions []string   `json:"permissions"`
+	Clusters    []string   `json:"clusters,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// apiKeyVerifyTTL is how long a successfully verified key is accepted without
+// hashing it again; bcrypt and argon2id are deliberately slow, and automation
+// presents the same key on every request
+const apiKeyVerifyTTL = 5 * time.Minute
+
+// APIKeyProvider implements AuthProvider for static API keys used by automation.
+// Keys are read from a file and can be rotated at runtime with Reload.
+type APIKeyProvider struct {
+	path string
+	mu   sync.RWMutex
+	keys map[string]APIKeyEntry
+	// verified maps the SHA-256 of keys that matched their hash to when
+	// that verification expires
+	verified           map[[sha256.Size]byte]time.Time
+	groupMappings      map[string][]string
+	defaultPermissions []Permission
+	logger             *logrus.Logger
+}
+
+// NewAPIKeyProvider creates an API key provider and loads the keys file
+func NewAPIKeyProvider(cfg APIKeyConfig, groupMappings map[string][]string, defaultPerms []string, logger *logrus.Logger) (*APIKeyProvider, error) {
+	if cfg.KeysFile == "" {
+		return nil, fmt.Errorf("apikey provider requires keys_file")
+	}
+	p := &APIKeyProvider{
+		path:               cfg.KeysFile,
+		groupMappings:      groupMappings,
+		defaultPermissions: toPermissions(defaultPerms),
+		logger:             logger,
+	}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *APIKeyProvider) Name() string {
+	return "APIKey"
+}
+
+// Reload re-reads the keys file and forgets verified keys. On error the
+// previously loaded keys stay active.
+func (p *APIKeyProvider) Reload() error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("read API keys %s: %w", p.path, err)
+	}
+	var file struct {
+		Keys []APIKeyEntry `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("parse API keys %s: %w", p.path, err)
+	}
+
+	keys := make(map[string]APIKeyEntry, len(file.Keys))
+	for _, k := range file.Keys {
+		if k.Name == "" || strings.Contains(k.Name, ".") {
+			return fmt.Errorf("API key name %q must be non-empty and must not contain '.'", k.Name)
+		}
+		if _, dup := keys[k.Name]; dup {
+			return fmt.Errorf("duplicate API key name %q", k.Name)
+		}
+		if !strings.HasPrefix(k.Hash, "$2") && !strings.HasPrefix(k.Hash, "$argon2id$") {
+			return fmt.Errorf("API key %q: hash must be bcrypt or argon2id", k.Name)
+		}
+		if _, err := ParsePermissions(k.Permissions); err != nil {
+			return fmt.Errorf("API key %q: %w", k.Name, err)
+		}
+		keys[k.Name] = k
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.verified = make(map[[sha256.Size]byte]time.Time)
+	p.mu.Unlock()
+
+	p.logger.WithFields(logrus.Fields{"file": p.path, "keys": len(keys)}).Info("API keys loaded")
+	return nil
+}
+
+func (p *APIKeyProvider) Authenticate(ctx context.Context, headers map[string]string) (*AuthContext, error) {
+	presented := headers["x-api-key"]
+	if presented == "" {
+		// Bearer values are only ours when they are not JWTs and have the key format
+		fields := strings.Fields(headers["authorization"])
+		if len(fields) == 2 && strings.EqualFold(fields[0], "bearer") && !looksLikeJWT(fields[1]) && strings.Contains(fields[1], ".") {
+			presented = fields[1]
+		}
+	}
+	if presented == "" {
+		return nil, fmt.Errorf("API key not found in headers: %w", ErrNoCredentials)
+	}
+
+	name, _, ok := strings.Cut(presented, ".")
+	if !ok {
+		return nil, fmt.Errorf("malformed API key")
+	}
+	sum := sha256.Sum256([]byte(presented))
+	p.mu.RLock()
+	entry, found := p.keys[name]
+	verifiedUntil, cached := p.verified[sum]
+	p.mu.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("invalid API key")
+	}
+	if !cached || !time.Now().Before(verifiedUntil) {
+		if !verifyKeyHash(entry.Hash, presented) {
+			return nil, fmt.Errorf("invalid API key")
+		}
+		p.remember(name, entry.Hash, sum)
+	}
+	if entry.ExpiresAt != nil && time.Now().After(*entry.ExpiresAt) {
+		return nil, fmt.Errorf("API key %s expired at %s", name, entry.ExpiresAt.Format(time.RFC3339))
+	}
+
+	// Explicit per-key permissions are combined with those of the key's groups
+	permSet := make(map[Permission]bool)
+	for _, perm := range entry.Permissions {
+		permSet[Permission(perm)] = true
+	}
+	var defaults []Permission
+	if len(entry.Permissions) == 0 {
+		defaults = p.defaultPermissions
+	}
+	for _, perm := range resolvePermissions(entry.Groups, p.groupMappings, defaults) {
+		permSet[perm] = true
+	}
+	permissions := make([]Permission, 0, len(permSet))
+	for perm := range permSet {
+		permissions = append(permissions, perm)
+	}
+
+	return &AuthContext{
+		Username:    "apikey:" + name,
+		Groups:      entry.Groups,
+		Permissions: permissions,
+		Clusters:    entry.Clusters,
+		IsValidated: true,
+		Source:      "APIKey",
+	}, nil
+}
+
+// remember records a successful verification against hash, dropping expired
+// ones. Nothing is recorded when a Reload replaced the key meanwhile.
+func (p *APIKeyProvider) remember(name, hash string, sum [sha256.Size]byte) {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.keys[name].Hash != hash {
+		return
+	}
+	for k, until := range p.verified {
+		if !now.Before(until) {
+			delete(p.verified, k)
+		}
+	}
+	p.verified[sum] = now.Add(apiKeyVerifyTTL)
+}
+
+// verifyKeyHash compares a presented key against a bcrypt or argon2id hash
+func verifyKeyHash(hash, key string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		return verifyArgon2id(hash, key)
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(key)) == nil
+}
+
+// verifyArgon2id checks a PHC string: $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+func verifyArgon2id(hash, key string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+	var memory, iterations uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false
+	}
+	got := argon2.IDKey([]byte(key), salt, iterations, memory, threads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func bcryptHash(t *testing.T, key string) string {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(key), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(hash)
+}
+
+func argon2idHash(key string) string {
+	salt := []byte("0123456789abcdef")
+	sum := argon2.IDKey([]byte(key), salt, 1, 1024, 1, 32)
+	return fmt.Sprintf("$argon2id$v=%d$m=1024,t=1,p=1$%s$%s", argon2.Version,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(sum))
+}
+
+func writeKeys(t *testing.T, path string, keys ...APIKeyEntry) {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testAPIKeyProvider(t *testing.T) (*APIKeyProvider, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	writeKeys(t, path,
+		APIKeyEntry{Name: "ci-bot", Hash: bcryptHash(t, "ci-bot.s3cret"), Groups: []string{"ops"}, Clusters: []string{"prod"}, ExpiresAt: &future},
+		APIKeyEntry{Name: "health", Hash: argon2idHash("health.s3cret"), Permissions: []string{string(AlertView)}},
+		APIKeyEntry{Name: "old", Hash: bcryptHash(t, "old.s3cret"), ExpiresAt: &past},
+	)
+	p, err := NewAPIKeyProvider(APIKeyConfig{KeysFile: path}, map[string][]string{"ops": {string(ServiceRestart)}}, []string{string(ClusterView)}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, path
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		wantUser     string
+		wantPerms    []Permission
+		wantClusters []string
+		wantErr      string
+		wantNoCreds  bool
+	}{
+		{name: "bcrypt", headers: map[string]string{"x-api-key": "ci-bot.s3cret"},
+			wantUser: "apikey:ci-bot", wantPerms: []Permission{ServiceRestart}, wantClusters: []string{"prod"}},
+		{name: "argon2id", headers: map[string]string{"x-api-key": "health.s3cret"},
+			wantUser: "apikey:health", wantPerms: []Permission{AlertView}},
+		{name: "bearer", headers: map[string]string{"authorization": "Bearer health.s3cret"},
+			wantUser: "apikey:health", wantPerms: []Permission{AlertView}},
+		{name: "wrong secret", headers: map[string]string{"x-api-key": "ci-bot.guess"}, wantErr: "invalid API key"},
+		{name: "wrong argon2id secret", headers: map[string]string{"x-api-key": "health.guess"}, wantErr: "invalid API key"},
+		{name: "unknown key", headers: map[string]string{"x-api-key": "intruder.s3cret"}, wantErr: "invalid API key"},
+		{name: "expired", headers: map[string]string{"x-api-key": "old.s3cret"}, wantErr: "expired"},
+		{name: "malformed", headers: map[string]string{"x-api-key": "s3cret"}, wantErr: "malformed"},
+		{name: "no key", headers: map[string]string{}, wantNoCreds: true},
+		{name: "bearer without key format", headers: map[string]string{"authorization": "Bearer opaque"}, wantNoCreds: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := testAPIKeyProvider(t)
+			authCtx, err := p.Authenticate(context.Background(), tt.headers)
+			if tt.wantNoCreds {
+				if !errors.Is(err, ErrNoCredentials) {
+					t.Fatalf("Authenticate() error = %v, want ErrNoCredentials", err)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Authenticate() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if errors.Is(err, ErrNoCredentials) {
+					t.Errorf("Authenticate() error = %v passes the request on to other providers", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if authCtx.Username != tt.wantUser || !authCtx.IsValidated {
+				t.Errorf("Authenticate() = %s (validated %v), want %s", authCtx.Username, authCtx.IsValidated, tt.wantUser)
+			}
+			if fmt.Sprint(authCtx.Permissions) != fmt.Sprint(tt.wantPerms) || fmt.Sprint(authCtx.Clusters) != fmt.Sprint(tt.wantClusters) {
+				t.Errorf("Authenticate() = %v on %v, want %v on %v", authCtx.Permissions, authCtx.Clusters, tt.wantPerms, tt.wantClusters)
+			}
+		})
+	}
+}
+
+func TestAPIKeyVerificationCache(t *testing.T) {
+	p, path := testAPIKeyProvider(t)
+	key := map[string]string{"x-api-key": "ci-bot.s3cret"}
+	if _, err := p.Authenticate(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.verified) != 1 {
+		t.Fatalf("verified = %d keys, want 1", len(p.verified))
+	}
+	if _, err := p.Authenticate(context.Background(), map[string]string{"x-api-key": "ci-bot.guess"}); err == nil {
+		t.Fatal("wrong secret accepted after the right one was cached")
+	}
+
+	// A cached key is still refused once it expires
+	p.mu.Lock()
+	entry := p.keys["ci-bot"]
+	past := time.Now().Add(-time.Minute)
+	entry.ExpiresAt = &past
+	p.keys["ci-bot"] = entry
+	p.mu.Unlock()
+	if _, err := p.Authenticate(context.Background(), key); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("Authenticate() error = %v, want expired", err)
+	}
+
+	// Rotating the key forgets the old secret at once
+	writeKeys(t, path, APIKeyEntry{Name: "ci-bot", Hash: bcryptHash(t, "ci-bot.n3w")})
+	if err := p.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Authenticate(context.Background(), key); err == nil || !strings.Contains(err.Error(), "invalid API key") {
+		t.Fatalf("old secret after rotation: error = %v, want invalid API key", err)
+	}
+	if _, err := p.Authenticate(context.Background(), map[string]string{"x-api-key": "ci-bot.n3w"}); err != nil {
+		t.Fatalf("new secret after rotation: %v", err)
+	}
+}
+
+func TestAPIKeyReload(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "not JSON", content: "keys:", wantErr: "parse API keys"},
+		{name: "duplicate name", content: `{"keys":[{"name":"a","hash":"$2a$04$x"},{"name":"a","hash":"$2a$04$y"}]}`, wantErr: "duplicate API key name"},
+		{name: "dot in name", content: `{"keys":[{"name":"a.b","hash":"$2a$04$x"}]}`, wantErr: "must not contain '.'"},
+		{name: "plain text hash", content: `{"keys":[{"name":"a","hash":"s3cret"}]}`, wantErr: "bcrypt or argon2id"},
+		{name: "unknown permission", content: `{"keys":[{"name":"a","hash":"$2a$04$x","permissions":["cluster:fly"]}]}`, wantErr: `API key "a"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, path := testAPIKeyProvider(t)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Reload(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Reload() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if _, err := p.Authenticate(context.Background(), map[string]string{"x-api-key": "health.s3cret"}); err != nil {
+				t.Errorf("previous keys dropped after a failed reload: %v", err)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		p, path := testAPIKeyProvider(t)
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Reload(); err == nil || !strings.Contains(err.Error(), "read API keys") {
+			t.Fatalf("Reload() error = %v, want read API keys", err)
+		}
+		if _, err := p.Authenticate(context.Background(), map[string]string{"x-api-key": "ci-bot.s3cret"}); err != nil {
+			t.Errorf("previous keys dropped after a failed reload: %v", err)
+		}
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -38,6 +39,34 @@ const (
 	ConfigModify Permission = "config:modify"
 )
 
+// AllPermissions lists every permission known to the server
+var AllPermissions = []Permission{
+	ClusterView, ClusterAdmin, ServiceView, ServiceOperate, ServiceRestart, ServiceAdmin,
+	HostView, HostManage, AlertView, AlertManage, AlertAdmin, ConfigView, ConfigModify,
+}
+
+// ParsePermissions converts permission strings, rejecting unknown values.
+// Values may carry a scope (see ParseGrant) and are kept verbatim.
+func ParsePermissions(values []string) ([]Permission, error) {
+	perms := make([]Permission, 0, len(values))
+	for _, v := range values {
+		if _, _, err := ParseGrant(v); err != nil {
+			return nil, err
+		}
+		perms = append(perms, Permission(strings.TrimSpace(v)))
+	}
+	return perms, nil
+}
+
+func isKnownPermission(perm Permission) bool {
+	for _, p := range AllPermissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
 // PermissionGroups maps group names to their permissions
 var PermissionGroups = map[string][]Permission{
 	"ADMIN": {
@@ -61,6 +90,33 @@ type AuthContext struct {
 	IsValidated bool         `json:"is_validated"`
 	Source      string       `json:"source"`
 	Headers     map[string]string `json:"headers,omitempty"`
+	// Clusters restricts the identity to these clusters; empty means all clusters
+	Clusters []string `json:"clusters,omitempty"`
+	// Authorization is the caller's Authorization header, kept for Ambari
+	// credential passthrough and never serialized
+	Authorization string `json:"-"`
+}
+
+// Clone returns a copy of the context that shares no slices or maps with a
+func (a *AuthContext) Clone() *AuthContext {
+	c := *a
+	c.Groups = append([]string(nil), a.Groups...)
+	c.Permissions = append([]Permission(nil), a.Permissions...)
+	c.Clusters = append([]string(nil), a.Clusters...)
+	if a.Headers != nil {
+		c.Headers = make(map[string]string, len(a.Headers))
+		for k, v := range a.Headers {
+			c.Headers[k] = v
+		}
+	}
+	return &c
+}
+
+// AllowsCluster reports whether the identity may act on the named cluster.
+// An identity restricted to clusters is not allowed an empty cluster, i.e.
+// calls that act outside any cluster.
+func (a *AuthContext) AllowsCluster(cluster string) bool {
+	return len(a.Clusters) == 0 || contains(a.Clusters, cluster)
 }
 
 // HasPermission checks if the user has a specific permission
@@ -83,12 +139,46 @@ func (a *AuthContext) HasAllPermissions(perms ...Permission) bool {
 	return true
 }
 
+// NewStaticAuthContext builds a fixed identity for transports that carry no
+// per-request credentials (stdio). role must be a key of PermissionGroups.
+func NewStaticAuthContext(username string, groups []string, role, source string) (*AuthContext, error) {
+	perms, ok := PermissionGroups[strings.ToUpper(role)]
+	if !ok {
+		return nil, fmt.Errorf("unknown permission group: %s", role)
+	}
+	return &AuthContext{
+		Username:    username,
+		Groups:      groups,
+		Permissions: append([]Permission{}, perms...),
+		IsValidated: true,
+		Source:      source,
+	}, nil
+}
+
 // AuthProvider is the Strategy interface for different authentication methods
 type AuthProvider interface {
 	Authenticate(ctx context.Context, headers map[string]string) (*AuthContext, error)
 	Name() string
 }
 
+// Challenger is implemented by providers that tell clients how to authenticate
+// through the WWW-Authenticate header of a 401 response (RFC 6750)
+type Challenger interface {
+	Challenge(err error) string
+}
+
+// MetadataPublisher is implemented by providers that serve unauthenticated
+// discovery documents, keyed by URL path
+type MetadataPublisher interface {
+	MetadataHandlers() map[string]http.Handler
+}
+
+// Reloader is implemented by providers whose credentials can be re-read at
+// runtime (e.g. on SIGHUP) without restarting the server
+type Reloader interface {
+	Reload() error
+}
+
 // contextKey is used for storing auth context in request context
 type contextKey string
 
@@ -106,27 +196,28 @@ func GetAuthContext(ctx context.Context) (*AuthContext, bool) {
 }
 
 // LDAPProvider implements AuthProvider for LDAP authentication via headers
+// set by a trusted reverse proxy
 type LDAPProvider struct {
 	headerPrefix       string
+	trust              *proxyTrust
 	groupMappings      map[string][]string
 	defaultPermissions []Permission
 	logger             *logrus.Logger
 }
 
 // NewLDAPProvider creates a new LDAP authentication provider
-func NewLDAPProvider(headerPrefix string, groupMappings map[string][]string, defaultPerms []string, logger *logrus.Logger) *LDAPProvider {
-	// Convert string permissions to Permission type
-	perms := make([]Permission, len(defaultPerms))
-	for i, p := range defaultPerms {
-		perms[i] = Permission(p)
+func NewLDAPProvider(cfg LDAPConfig, groupMappings map[string][]string, defaultPerms []string, logger *logrus.Logger) (*LDAPProvider, error) {
+	trust, err := newProxyTrust(cfg)
+	if err != nil {
+		return nil, err
 	}
-	
 	return &LDAPProvider{
-		headerPrefix:       headerPrefix,
+		headerPrefix:       cfg.HeaderPrefix,
+		trust:              trust,
 		groupMappings:      groupMappings,
-		defaultPermissions: perms,
+		defaultPermissions: toPermissions(defaultPerms),
 		logger:             logger,
-	}
+	}, nil
 }
 
 func (p *LDAPProvider) Name() string {
@@ -140,7 +231,10 @@ func (p *LDAPProvider) Authenticate(ctx context.Context, headers map[string]stri
 		username = headers[p.headerPrefix+"username"]
 	}
 	if username == "" {
-		return nil, fmt.Errorf("username not found in headers")
+		return nil, fmt.Errorf("username not found in headers: %w", ErrNoCredentials)
+	}
+	if err := p.trust.verify(ctx, func(name string) string { return headers[name] }); err != nil {
+		return nil, err
 	}
 
 	// Extract groups from headers
@@ -153,10 +247,52 @@ func (p *LDAPProvider) Authenticate(ctx context.Context, headers map[string]stri
 		}
 	}
 
-	// Map groups to permissions
+	return &AuthContext{
+		Username:    username,
+		Groups:      groups,
+		Permissions: resolvePermissions(groups, p.groupMappings, p.defaultPermissions),
+		IsValidated: true,
+		Source:      "LDAP",
+		Headers:     headers,
+	}, nil
+}
+
+// Sanitize strips identity headers that did not come from a trusted proxy
+func (p *LDAPProvider) Sanitize(ctx context.Context, header http.Header) {
+	get := func(name string) string { return header.Get(name) }
+	if get(p.headerPrefix+"name") == "" && get(p.headerPrefix+"username") == "" && get(p.headerPrefix+"groups") == "" {
+		return
+	}
+	if err := p.trust.verify(ctx, get); err != nil {
+		p.logger.WithError(err).Warn("Stripping untrusted identity headers")
+		p.trust.strip(header)
+	}
+}
+
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
+// toPermissions converts string permissions to Permission type
+func toPermissions(values []string) []Permission {
+	perms := make([]Permission, len(values))
+	for i, v := range values {
+		perms[i] = Permission(v)
+	}
+	return perms
+}
+
+// resolvePermissions maps groups to permissions, falling back to the defaults
+// when none of the groups has a mapping
+func resolvePermissions(groups []string, groupMappings map[string][]string, defaults []Permission) []Permission {
 	permSet := make(map[Permission]bool)
 	for _, group := range groups {
-		if mappedPerms, exists := p.groupMappings[group]; exists {
+		if mappedPerms, exists := groupMappings[group]; exists {
 			for _, perm := range mappedPerms {
 				permSet[Permission(perm)] = true
 			}
@@ -165,7 +301,7 @@ func (p *LDAPProvider) Authenticate(ctx context.Context, headers map[string]stri
 
 	// Add default permissions if no group mappings found
 	if len(permSet) == 0 {
-		for _, perm := range p.defaultPermissions {
+		for _, perm := range defaults {
 			permSet[perm] = true
 		}
 	}
@@ -175,31 +311,31 @@ func (p *LDAPProvider) Authenticate(ctx context.Context, headers map[string]stri
 	for perm := range permSet {
 		permissions = append(permissions, perm)
 	}
-
-	return &AuthContext{
-		Username:    username,
-		Groups:      groups,
-		Permissions: permissions,
-		IsValidated: true,
-		Source:      "LDAP",
-		Headers:     headers,
-	}, nil
+	return permissions
 }
 
 // Middleware provides HTTP middleware for authentication
 type Middleware struct {
 	provider AuthProvider
 	enabled  bool
+	metadata map[string]http.Handler
+	sessions *sessionBinder
 	logger   *logrus.Logger
 }
 
-// NewMiddleware creates a new authentication middleware
-func NewMiddleware(provider AuthProvider, enabled bool, logger *logrus.Logger) *Middleware {
-	return &Middleware{
+// NewMiddleware creates a new authentication middleware. sessionTimeout is
+// the idle timeout of MCP sessions, after which their owners are forgotten.
+func NewMiddleware(provider AuthProvider, enabled bool, sessionTimeout time.Duration, logger *logrus.Logger) *Middleware {
+	m := &Middleware{
 		provider: provider,
 		enabled:  enabled,
+		sessions: newSessionBinder(sessionTimeout, logger),
 		logger:   logger,
 	}
+	if publisher, ok := provider.(MetadataPublisher); ok && enabled {
+		m.metadata = publisher.MetadataHandlers()
+	}
+	return m
 }
 
 // Handler wraps an HTTP handler with authentication middleware
@@ -207,7 +343,22 @@ func (m *Middleware) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
+		// Discovery documents are public
+		if h, ok := m.metadata[r.URL.Path]; ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+
 		if m.enabled {
+			authnCtx := withPeerAddr(ctx, r.RemoteAddr)
+			if r.TLS != nil {
+				authnCtx = withTLSState(authnCtx, r.TLS)
+			}
+			if sanitizer, ok := m.provider.(HeaderSanitizer); ok {
+				r = r.Clone(ctx)
+				sanitizer.Sanitize(authnCtx, r.Header)
+			}
+
 			// Extract headers for authentication
 			headers := make(map[string]string)
 			for name, values := range r.Header {
@@ -217,13 +368,23 @@ func (m *Middleware) Handler(next http.Handler) http.Handler {
 			}
 
 			// Authenticate the request
-			authCtx, err := m.provider.Authenticate(ctx, headers)
+			authCtx, err := m.provider.Authenticate(authnCtx, headers)
 			if err != nil {
 				m.logger.WithError(err).Warn("Authentication failed")
+				if challenger, ok := m.provider.(Challenger); ok {
+					if challenge := challenger.Challenge(err); challenge != "" {
+						w.Header().Set("WWW-Authenticate", challenge)
+					}
+				}
 				http.Error(w, "Authentication failed", http.StatusUnauthorized)
 				return
 			}
 
+			// Providers may return shared contexts (cached or static
+			// identities), so request-specific fields go on a copy
+			authCtx = authCtx.Clone()
+			authCtx.Authorization = r.Header.Get("Authorization")
+
 			// Add auth context to request context
 			ctx = WithAuthContext(ctx, authCtx)
 			r = r.WithContext(ctx)
@@ -236,16 +397,23 @@ func (m *Middleware) Handler(next http.Handler) http.Handler {
 		} else {
 			// Create default auth context for disabled auth
 			defaultCtx := &AuthContext{
-				Username:    "default-user",
-				Groups:      []string{"ambari-admins"},
-				Permissions: PermissionGroups["ADMIN"],
-				IsValidated: false,
-				Source:      "disabled",
+				Username:      "default-user",
+				Groups:        []string{"ambari-admins"},
+				Permissions:   PermissionGroups["ADMIN"],
+				IsValidated:   false,
+				Source:        "disabled",
+				Authorization: r.Header.Get("Authorization"),
 			}
 			ctx = WithAuthContext(ctx, defaultCtx)
 			r = r.WithContext(ctx)
 		}
 
-		next.ServeHTTP(w, r)
+		authCtx, _ := GetAuthContext(ctx)
+		m.sessions.serve(w, r, authCtx, next)
 	})
+}
+
+// Enabled reports whether requests are authenticated by the provider
+func (m *Middleware) Enabled() bool {
+	return m.enabled
 }
\ No newline at end of file
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// staticProvider returns the same AuthContext for every request, like a
+// provider that caches identities
+type staticProvider struct {
+	name    string
+	authCtx *AuthContext
+	err     error
+}
+
+func (p *staticProvider) Name() string { return p.name }
+func (p *staticProvider) Authenticate(context.Context, map[string]string) (*AuthContext, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.authCtx, nil
+}
+
+func TestMiddlewareCopiesProviderContext(t *testing.T) {
+	shared := &AuthContext{Username: "alice", Permissions: []Permission{ClusterView}, Source: "static"}
+	chain := NewChainProvider([]AuthProvider{&staticProvider{name: "first", authCtx: shared}}, testLogger())
+	m := NewMiddleware(chain, true, 0, testLogger())
+
+	var seen []*AuthContext
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authCtx, _ := GetAuthContext(r.Context())
+		seen = append(seen, authCtx)
+	}))
+	for _, authorization := range []string{"Basic YWxpY2U6b25l", "Basic YWxpY2U6dHdv"} {
+		r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		r.Header.Set("Authorization", authorization)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	if shared.Authorization != "" || shared.Source != "static" {
+		t.Errorf("provider context was modified: Authorization=%q Source=%q", shared.Authorization, shared.Source)
+	}
+	if len(seen) != 2 || seen[0] == seen[1] {
+		t.Fatalf("requests did not get their own contexts: %v", seen)
+	}
+	if seen[0].Authorization != "Basic YWxpY2U6b25l" || seen[1].Authorization != "Basic YWxpY2U6dHdv" {
+		t.Errorf("Authorization = %q, %q", seen[0].Authorization, seen[1].Authorization)
+	}
+	if seen[0].Source != "first" {
+		t.Errorf("Source = %q, want first", seen[0].Source)
+	}
+}
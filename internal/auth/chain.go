@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNoCredentials is returned (wrapped) by a provider when the request carries
+// none of the credentials it understands, as opposed to carrying invalid ones.
+var ErrNoCredentials = errors.New("no credentials")
+
+// ChainProvider tries providers in order (Chain of Responsibility). A provider
+// reporting ErrNoCredentials passes the request on; any other failure stops the
+// chain so invalid credentials are never accepted by a later, weaker provider.
+type ChainProvider struct {
+	providers []AuthProvider
+	logger    *logrus.Logger
+}
+
+// NewChainProvider creates a provider chain in the given order
+func NewChainProvider(providers []AuthProvider, logger *logrus.Logger) *ChainProvider {
+	return &ChainProvider{providers: providers, logger: logger}
+}
+
+func (c *ChainProvider) Name() string {
+	names := make([]string, len(c.providers))
+	for i, p := range c.providers {
+		names[i] = p.Name()
+	}
+	return "Chain(" + strings.Join(names, ",") + ")"
+}
+
+func (c *ChainProvider) Authenticate(ctx context.Context, headers map[string]string) (*AuthContext, error) {
+	for _, p := range c.providers {
+		authCtx, err := p.Authenticate(ctx, headers)
+		if err == nil {
+			authCtx = authCtx.Clone()
+			authCtx.Source = p.Name()
+			return authCtx, nil
+		}
+		if !errors.Is(err, ErrNoCredentials) {
+			return nil, &chainError{provider: p, err: err}
+		}
+		c.logger.WithFields(logrus.Fields{"provider": p.Name(), "reason": err}).Debug("Provider skipped")
+	}
+	return nil, fmt.Errorf("no provider in %s found credentials: %w", c.Name(), ErrNoCredentials)
+}
+
+// Challenge returns the failing provider's challenge for invalid credentials,
+// otherwise every member's challenge so clients learn all accepted schemes
+func (c *ChainProvider) Challenge(err error) string {
+	var chainErr *chainError
+	if errors.As(err, &chainErr) {
+		if challenger, ok := chainErr.provider.(Challenger); ok {
+			return challenger.Challenge(chainErr.err)
+		}
+		return ""
+	}
+	var challenges []string
+	for _, p := range c.providers {
+		if challenger, ok := p.(Challenger); ok {
+			challenges = append(challenges, challenger.Challenge(err))
+		}
+	}
+	return strings.Join(challenges, ", ")
+}
+
+// Sanitize lets every member strip the headers it does not trust
+func (c *ChainProvider) Sanitize(ctx context.Context, header http.Header) {
+	for _, p := range c.providers {
+		if sanitizer, ok := p.(HeaderSanitizer); ok {
+			sanitizer.Sanitize(ctx, header)
+		}
+	}
+}
+
+// MetadataHandlers merges the discovery documents of all members
+func (c *ChainProvider) MetadataHandlers() map[string]http.Handler {
+	handlers := make(map[string]http.Handler)
+	for _, p := range c.providers {
+		if publisher, ok := p.(MetadataPublisher); ok {
+			for path, h := range publisher.MetadataHandlers() {
+				handlers[path] = h
+			}
+		}
+	}
+	return handlers
+}
+
+// Reload reloads every member that supports it
+func (c *ChainProvider) Reload() error {
+	var errs []error
+	for _, p := range c.providers {
+		if reloader, ok := p.(Reloader); ok {
+			if err := reloader.Reload(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// chainError records which provider rejected the credentials
+type chainError struct {
+	provider AuthProvider
+	err      error
+}
+
+func (e *chainError) Error() string { return fmt.Sprintf("%s: %v", e.provider.Name(), e.err) }
+func (e *chainError) Unwrap() error { return e.err }
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// chainMember is a chain provider stub that counts its calls and challenges
+// with its name
+type chainMember struct {
+	staticProvider
+	calls int
+}
+
+func (p *chainMember) Authenticate(ctx context.Context, headers map[string]string) (*AuthContext, error) {
+	p.calls++
+	return p.staticProvider.Authenticate(ctx, headers)
+}
+
+func (p *chainMember) Challenge(err error) string {
+	if err != nil && !errors.Is(err, ErrNoCredentials) {
+		return p.name + ` error="invalid"`
+	}
+	return p.name
+}
+
+func TestChainProvider(t *testing.T) {
+	noCreds := fmt.Errorf("no header: %w", ErrNoCredentials)
+	invalid := errors.New("bad signature")
+	alice := &AuthContext{Username: "alice", Source: "member"}
+
+	tests := []struct {
+		name          string
+		results       []error // per member; nil authenticates as alice
+		wantSource    string
+		wantErr       error
+		wantCalls     []int
+		wantChallenge string
+	}{
+		{
+			name:       "first member authenticates",
+			results:    []error{nil, nil},
+			wantSource: "m0",
+			wantCalls:  []int{1, 0},
+		},
+		{
+			name:       "member without credentials passes on",
+			results:    []error{noCreds, nil},
+			wantSource: "m1",
+			wantCalls:  []int{1, 1},
+		},
+		{
+			name:          "invalid credentials stop the chain",
+			results:       []error{invalid, nil},
+			wantErr:       invalid,
+			wantCalls:     []int{1, 0},
+			wantChallenge: `m0 error="invalid"`,
+		},
+		{
+			name:          "invalid credentials after a skipped member",
+			results:       []error{noCreds, invalid, nil},
+			wantErr:       invalid,
+			wantCalls:     []int{1, 1, 0},
+			wantChallenge: `m1 error="invalid"`,
+		},
+		{
+			name:          "no member finds credentials",
+			results:       []error{noCreds, noCreds},
+			wantErr:       ErrNoCredentials,
+			wantCalls:     []int{1, 1},
+			wantChallenge: "m0, m1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := make([]*chainMember, len(tt.results))
+			providers := make([]AuthProvider, len(tt.results))
+			for i, err := range tt.results {
+				members[i] = &chainMember{staticProvider: staticProvider{name: fmt.Sprintf("m%d", i), authCtx: alice, err: err}}
+				providers[i] = members[i]
+			}
+			chain := NewChainProvider(providers, testLogger())
+
+			authCtx, err := chain.Authenticate(context.Background(), map[string]string{})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+				}
+				if got := chain.Challenge(err); got != tt.wantChallenge {
+					t.Errorf("Challenge() = %q, want %q", got, tt.wantChallenge)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Authenticate() error = %v", err)
+				}
+				if authCtx.Username != "alice" || authCtx.Source != tt.wantSource {
+					t.Errorf("Authenticate() = %s from %s, want alice from %s", authCtx.Username, authCtx.Source, tt.wantSource)
+				}
+				if alice.Source != "member" {
+					t.Errorf("member's context was modified: Source = %q", alice.Source)
+				}
+			}
+			for i, m := range members {
+				if m.calls != tt.wantCalls[i] {
+					t.Errorf("member %d called %d times, want %d", i, m.calls, tt.wantCalls[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChainOrderPerTransport(t *testing.T) {
+	chain := map[string][]ProviderType{
+		"default": {ProviderToken, ProviderAPIKey},
+		"stdio":   {ProviderAPIKey},
+		"sse":     {},
+	}
+	tests := []struct {
+		transport string
+		want      []ProviderType
+		wantErr   string
+	}{
+		{transport: "http", want: []ProviderType{ProviderToken, ProviderAPIKey}},
+		{transport: "stdio", want: []ProviderType{ProviderAPIKey}},
+		{transport: "sse", want: []ProviderType{}, wantErr: `no providers for transport "sse"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.transport, func(t *testing.T) {
+			cfg := &Config{Enabled: true, Provider: ProviderChain, Chain: chain}
+			if got := cfg.ProvidersFor(tt.transport); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ProvidersFor() = %v, want %v", got, tt.want)
+			}
+			err := cfg.Validate(tt.transport)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChainMembers(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []ProviderType
+		wantErr string
+	}{
+		{name: "supported members", members: []ProviderType{ProviderMTLS, ProviderToken, ProviderAPIKey}},
+		{name: "nested chain", members: []ProviderType{ProviderChain}, wantErr: "unsupported member"},
+		{name: "none", members: []ProviderType{ProviderAPIKey, ProviderNone}, wantErr: "unsupported member"},
+		{name: "ldap-header without proxy trust", members: []ProviderType{ProviderLDAPHeader}, wantErr: "header prefix"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Enabled: true, Provider: ProviderChain, Chain: map[string][]ProviderType{"default": tt.members}}
+			err := cfg.Validate("http")
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ProviderType names an authentication provider selectable from configuration
+type ProviderType string
+
+const (
+	ProviderLDAPHeader ProviderType = "ldap-header"
+	ProviderMTLS       ProviderType = "mtls"
+	ProviderToken      ProviderType = "token"
+	ProviderAPIKey     ProviderType = "apikey"
+	ProviderChain      ProviderType = "chain"
+	ProviderNone       ProviderType = "none"
+)
+
+// Config selects and configures the authentication provider.
+// It is loaded from an optional JSON file (AUTH_CONFIG_FILE) and then
+// overridden by environment variables.
+type Config struct {
+	Enabled              bool                `json:"enabled"`
+	Provider             ProviderType        `json:"provider"`
+	AllowUnauthenticated bool                `json:"allow_unauthenticated"`
+	DefaultPermissions   []string            `json:"default_permissions"`
+	GroupMappingsFile    string              `json:"group_mappings_file"`
+	GroupMappings        map[string][]string `json:"group_mappings,omitempty"`
+	LDAP                 LDAPConfig          `json:"ldap"`
+	MTLS                 MTLSConfig          `json:"mtls"`
+	Token                TokenConfig         `json:"token"`
+	APIKey               APIKeyConfig        `json:"apikey"`
+	AmbariRBAC           AmbariRBACConfig    `json:"ambari_rbac"`
+	// AmbariCredentials selects whose credentials tool calls use towards Ambari
+	AmbariCredentials AmbariCredentialsConfig `json:"ambari_credentials"`
+	// Chain orders providers for the chain provider per transport mode
+	// ("stdio", "http", "ssl", "mtls"); the "default" entry applies otherwise.
+	Chain map[string][]ProviderType `json:"chain,omitempty"`
+}
+
+// LDAPConfig configures the LDAP header provider. Identity headers are only
+// accepted from a trusted proxy: a peer in TrustedProxies, a peer presenting a
+// verified client certificate whose CN is in TrustedProxyCNs, or a request
+// signed with the shared secret in HMACSecretFile.
+type LDAPConfig struct {
+	HeaderPrefix    string   `json:"header_prefix"`
+	TrustedProxies  []string `json:"trusted_proxies"`
+	TrustedProxyCNs []string `json:"trusted_proxy_cns,omitempty"`
+	HMACSecretFile  string   `json:"hmac_secret_file,omitempty"`
+}
+
+// DefaultConfig returns the configuration used when nothing is set
+func DefaultConfig() *Config {
+	return &Config{
+		Enabled:            false,
+		Provider:           ProviderLDAPHeader,
+		DefaultPermissions: []string{string(ClusterView), string(ServiceView)},
+		LDAP: LDAPConfig{
+			HeaderPrefix:   "x-remote-",
+			TrustedProxies: []string{"127.0.0.1/32", "::1/128"},
+		},
+	}
+}
+
+// LoadConfig reads the optional config file at path, applies environment
+// overrides and loads group mappings from GroupMappingsFile.
+func LoadConfig(path string) (*Config, error) {
+	cfg := DefaultConfig()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read auth config %s: %w", path, err)
+		}
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parse auth config %s: %w", path, err)
+		}
+	}
+	if err := cfg.applyEnv(os.Getenv); err != nil {
+		return nil, err
+	}
+	if cfg.GroupMappingsFile != "" {
+		mappings, err := LoadGroupMappings(cfg.GroupMappingsFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.GroupMappings = mappings
+	}
+	return cfg, nil
+}
+
+func (c *Config) applyEnv(getenv func(string) string) error {
+	if v := getenv("AUTH_ENABLED"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid AUTH_ENABLED %q: %w", v, err)
+		}
+		c.Enabled = b
+	}
+	if v := getenv("AUTH_PROVIDER"); v != "" {
+		c.Provider = ProviderType(strings.ToLower(v))
+	}
+	if v := getenv("AUTH_ALLOW_UNAUTHENTICATED"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid AUTH_ALLOW_UNAUTHENTICATED %q: %w", v, err)
+		}
+		c.AllowUnauthenticated = b
+	}
+	if v := getenv("DEFAULT_PERMISSIONS"); v != "" {
+		c.DefaultPermissions = splitCSV(v)
+	}
+	if v := getenv("AUTH_GROUP_MAPPINGS_FILE"); v != "" {
+		c.GroupMappingsFile = v
+	}
+	if v := getenv("LDAP_HEADER_PREFIX"); v != "" {
+		c.LDAP.HeaderPrefix = strings.ToLower(v)
+	}
+	if v := getenv("LDAP_TRUSTED_PROXIES"); v != "" {
+		c.LDAP.TrustedProxies = splitCSV(v)
+	}
+	if v := getenv("LDAP_TRUSTED_PROXY_CNS"); v != "" {
+		c.LDAP.TrustedProxyCNs = splitCSV(v)
+	}
+	if v := getenv("LDAP_HMAC_SECRET_FILE"); v != "" {
+		c.LDAP.HMACSecretFile = v
+	}
+	if v := getenv("MTLS_USERNAME_FROM"); v != "" {
+		c.MTLS.UsernameFrom = strings.ToLower(v)
+	}
+	if v := getenv("MTLS_GROUPS_FROM"); v != "" {
+		c.MTLS.GroupsFrom = strings.ToLower(v)
+	}
+	if v := getenv("MTLS_IDENTITY_MAPPINGS_FILE"); v != "" {
+		c.MTLS.IdentityMappingsFile = v
+	}
+	if v := getenv("AUTH_CHAIN"); v != "" {
+		if c.Chain == nil {
+			c.Chain = map[string][]ProviderType{}
+		}
+		var order []ProviderType
+		for _, name := range splitCSV(strings.ToLower(v)) {
+			order = append(order, ProviderType(name))
+		}
+		c.Chain["default"] = order
+	}
+	if v := getenv("AUTH_AMBARI_RBAC"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid AUTH_AMBARI_RBAC %q: %w", v, err)
+		}
+		c.AmbariRBAC.Enabled = b
+	}
+	if v := getenv("AUTH_AMBARI_RBAC_CACHE_TTL"); v != "" {
+		c.AmbariRBAC.CacheTTL = v
+	}
+	if v := getenv("AMBARI_CREDENTIAL_MODE"); v != "" {
+		c.AmbariCredentials.Mode = strings.ToLower(v)
+	}
+	if v := getenv("AMBARI_TOKEN_COOKIE"); v != "" {
+		c.AmbariCredentials.TokenCookie = v
+	}
+	if v := getenv("AMBARI_GROUP_ACCOUNTS_FILE"); v != "" {
+		c.AmbariCredentials.GroupAccountsFile = v
+	}
+	if v := getenv("API_KEYS_FILE"); v != "" {
+		c.APIKey.KeysFile = v
+	}
+	if v := getenv("JWT_JWKS_FILE"); v != "" {
+		c.Token.JWKSFile = v
+	}
+	if v := getenv("JWT_ISSUER"); v != "" {
+		c.Token.Issuer = v
+	}
+	if v := getenv("JWT_AUDIENCE"); v != "" {
+		c.Token.Audience = v
+	}
+	if v := getenv("OAUTH_RESOURCE"); v != "" {
+		c.Token.Resource = v
+	}
+	if v := getenv("OAUTH_AUTHORIZATION_SERVERS"); v != "" {
+		c.Token.AuthorizationServers = splitCSV(v)
+	}
+	return nil
+}
+
+// Validate checks the configuration for the given transport mode. Serving any
+// transport other than stdio without authentication must be explicitly allowed.
+func (c *Config) Validate(transportMode string) error {
+	httpExposed := transportMode != "stdio"
+	switch c.Provider {
+	case ProviderLDAPHeader, ProviderMTLS, ProviderToken, ProviderAPIKey, ProviderNone:
+	case ProviderChain:
+		order := c.ProvidersFor(transportMode)
+		if c.Enabled && len(order) == 0 {
+			return fmt.Errorf("chain provider has no providers for transport %q", transportMode)
+		}
+		for _, t := range order {
+			switch t {
+			case ProviderLDAPHeader, ProviderMTLS, ProviderToken, ProviderAPIKey:
+			default:
+				return fmt.Errorf("chain: unsupported member provider %q", t)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown auth provider %q (supported: ldap-header, mtls, token, apikey, chain, none)", c.Provider)
+	}
+	if httpExposed && !c.Authenticates() && !c.AllowUnauthenticated {
+		return fmt.Errorf("refusing to serve HTTP without authentication; set AUTH_ENABLED=true with a provider, or AUTH_ALLOW_UNAUTHENTICATED=true to override")
+	}
+	if _, err := ParsePermissions(c.DefaultPermissions); err != nil {
+		return fmt.Errorf("default permissions: %w", err)
+	}
+	for group, perms := range c.GroupMappings {
+		if _, err := ParsePermissions(perms); err != nil {
+			return fmt.Errorf("group mapping %s: %w", group, err)
+		}
+	}
+	switch c.AmbariCredentials.Mode {
+	case "", CredentialsService, CredentialsPassthrough, CredentialsToken, CredentialsGroup:
+	default:
+		return fmt.Errorf("unknown ambari credentials mode %q (supported: service, passthrough, token, group)", c.AmbariCredentials.Mode)
+	}
+	if _, err := c.AmbariRBAC.CacheDuration(); err != nil {
+		return err
+	}
+	if c.UsesProvider(transportMode, ProviderLDAPHeader) {
+		if c.LDAP.HeaderPrefix == "" {
+			return fmt.Errorf("ldap-header provider requires a header prefix")
+		}
+		if len(c.LDAP.TrustedProxies) == 0 && len(c.LDAP.TrustedProxyCNs) == 0 && c.LDAP.HMACSecretFile == "" {
+			return fmt.Errorf("ldap-header provider requires trusted_proxies, trusted_proxy_cns or hmac_secret_file")
+		}
+	}
+	return nil
+}
+
+// Authenticates reports whether requests will actually be authenticated
+func (c *Config) Authenticates() bool {
+	return c.Enabled && c.Provider != ProviderNone
+}
+
+// ProvidersFor returns the chain order for a transport mode
+func (c *Config) ProvidersFor(transportMode string) []ProviderType {
+	if order, ok := c.Chain[transportMode]; ok {
+		return order
+	}
+	return c.Chain["default"]
+}
+
+// UsesProvider reports whether t authenticates requests on the transport,
+// directly or as a chain member
+func (c *Config) UsesProvider(transportMode string, t ProviderType) bool {
+	if c.Provider == ProviderChain {
+		for _, member := range c.ProvidersFor(transportMode) {
+			if member == t {
+				return true
+			}
+		}
+		return false
+	}
+	return c.Provider == t
+}
+
+// NewProvider creates the AuthProvider selected by the configuration for the
+// transport mode (Factory pattern). It returns nil for ProviderNone.
+func NewProvider(cfg *Config, transportMode string, logger *logrus.Logger) (AuthProvider, error) {
+	if cfg.Provider != ProviderChain {
+		return newProvider(cfg.Provider, cfg, logger)
+	}
+	var members []AuthProvider
+	for _, t := range cfg.ProvidersFor(transportMode) {
+		p, err := newProvider(t, cfg, logger)
+		if err != nil {
+			return nil, fmt.Errorf("chain member %s: %w", t, err)
+		}
+		members = append(members, p)
+	}
+	return NewChainProvider(members, logger), nil
+}
+
+func newProvider(t ProviderType, cfg *Config, logger *logrus.Logger) (AuthProvider, error) {
+	switch t {
+	case ProviderLDAPHeader:
+		return NewLDAPProvider(cfg.LDAP, cfg.GroupMappings, cfg.DefaultPermissions, logger)
+	case ProviderMTLS:
+		return NewMTLSProvider(cfg.MTLS, cfg.GroupMappings, cfg.DefaultPermissions, logger)
+	case ProviderAPIKey:
+		return NewAPIKeyProvider(cfg.APIKey, cfg.GroupMappings, cfg.DefaultPermissions, logger)
+	case ProviderNone:
+		return nil, nil
+	case ProviderToken:
+		return NewJWTProvider(cfg.Token, cfg.GroupMappings, cfg.DefaultPermissions, logger)
+	default:
+		return nil, fmt.Errorf("unknown auth provider %q", t)
+	}
+}
+
+// LoadGroupMappings reads a JSON object mapping group names to permission lists, e.g.
+//
+//	{"ambari-admins": ["cluster:admin", "service:admin"], "bigdata-viewers": ["cluster:view"]}
+func LoadGroupMappings(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read group mappings %s: %w", path, err)
+	}
+	var mappings map[string][]string
+	if err := json.Unmarshal(data, &mappings); err != nil {
+		return nil, fmt.Errorf("parse group mappings %s: %w", path, err)
+	}
+	return mappings, nil
+}
+
+func splitCSV(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
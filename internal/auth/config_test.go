@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(c *Config) bool
+		wantErr string
+	}{
+		{name: "nothing set", env: map[string]string{}, check: func(c *Config) bool { return reflect.DeepEqual(c, DefaultConfig()) }},
+		{name: "enabled", env: map[string]string{"AUTH_ENABLED": "true"}, check: func(c *Config) bool { return c.Enabled }},
+		{name: "invalid enabled", env: map[string]string{"AUTH_ENABLED": "yes please"}, wantErr: "invalid AUTH_ENABLED"},
+		{name: "provider lowercased", env: map[string]string{"AUTH_PROVIDER": "APIKey"}, check: func(c *Config) bool { return c.Provider == ProviderAPIKey }},
+		{name: "allow unauthenticated", env: map[string]string{"AUTH_ALLOW_UNAUTHENTICATED": "1"}, check: func(c *Config) bool { return c.AllowUnauthenticated }},
+		{name: "invalid allow unauthenticated", env: map[string]string{"AUTH_ALLOW_UNAUTHENTICATED": "sure"}, wantErr: "invalid AUTH_ALLOW_UNAUTHENTICATED"},
+		{name: "default permissions", env: map[string]string{"DEFAULT_PERMISSIONS": " cluster:view, ,alert:view "}, check: func(c *Config) bool {
+			return reflect.DeepEqual(c.DefaultPermissions, []string{"cluster:view", "alert:view"})
+		}},
+		{name: "trusted proxies", env: map[string]string{"LDAP_TRUSTED_PROXIES": "10.0.0.0/8,fd00::/8", "LDAP_HEADER_PREFIX": "X-Auth-"}, check: func(c *Config) bool {
+			return reflect.DeepEqual(c.LDAP.TrustedProxies, []string{"10.0.0.0/8", "fd00::/8"}) && c.LDAP.HeaderPrefix == "x-auth-"
+		}},
+		{name: "chain", env: map[string]string{"AUTH_CHAIN": "Token, apikey"}, check: func(c *Config) bool {
+			return reflect.DeepEqual(c.Chain["default"], []ProviderType{ProviderToken, ProviderAPIKey})
+		}},
+		{name: "ambari rbac", env: map[string]string{"AUTH_AMBARI_RBAC": "true", "AUTH_AMBARI_RBAC_CACHE_TTL": "1m"}, check: func(c *Config) bool {
+			return c.AmbariRBAC.Enabled && c.AmbariRBAC.CacheTTL == "1m"
+		}},
+		{name: "invalid ambari rbac", env: map[string]string{"AUTH_AMBARI_RBAC": "on"}, wantErr: "invalid AUTH_AMBARI_RBAC"},
+		{name: "credential mode lowercased", env: map[string]string{"AMBARI_CREDENTIAL_MODE": "Passthrough"}, check: func(c *Config) bool {
+			return c.AmbariCredentials.Mode == CredentialsPassthrough
+		}},
+		{name: "token", env: map[string]string{"JWT_ISSUER": "https://idp", "JWT_AUDIENCE": "mcp", "OAUTH_AUTHORIZATION_SERVERS": "https://idp,https://idp2"}, check: func(c *Config) bool {
+			return c.Token.Issuer == "https://idp" && c.Token.Audience == "mcp" && len(c.Token.AuthorizationServers) == 2
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			err := c.applyEnv(func(name string) string { return tt.env[name] })
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyEnv() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyEnv() error = %v", err)
+			}
+			if !tt.check(c) {
+				t.Errorf("applyEnv() = %+v", c)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	mappings := write("groups.json", `{"ops": ["service:restart"]}`)
+	file := write("auth.json", fmt.Sprintf(`{"enabled": true, "provider": "apikey", "apikey": {"keys_file": "/etc/keys.json"}, "group_mappings_file": %q}`, mappings))
+
+	tests := []struct {
+		name    string
+		path    string
+		env     map[string]string
+		check   func(c *Config) bool
+		wantErr string
+	}{
+		{name: "no file", check: func(c *Config) bool { return reflect.DeepEqual(c, DefaultConfig()) }},
+		{name: "file", path: file, check: func(c *Config) bool {
+			return c.Enabled && c.Provider == ProviderAPIKey && c.APIKey.KeysFile == "/etc/keys.json" &&
+				reflect.DeepEqual(c.GroupMappings, map[string][]string{"ops": {"service:restart"}}) &&
+				c.LDAP.HeaderPrefix == "x-remote-"
+		}},
+		{name: "environment overrides file", path: file, env: map[string]string{"AUTH_PROVIDER": "token", "API_KEYS_FILE": "/run/keys.json"}, check: func(c *Config) bool {
+			return c.Enabled && c.Provider == ProviderToken && c.APIKey.KeysFile == "/run/keys.json"
+		}},
+		{name: "missing file", path: filepath.Join(dir, "missing.json"), wantErr: "read auth config"},
+		{name: "invalid file", path: write("invalid.json", `{"enabled": "yes"}`), wantErr: "parse auth config"},
+		{name: "invalid environment", env: map[string]string{"AUTH_ENABLED": "maybe"}, wantErr: "invalid AUTH_ENABLED"},
+		{name: "missing group mappings", env: map[string]string{"AUTH_GROUP_MAPPINGS_FILE": filepath.Join(dir, "none.json")}, wantErr: "read group mappings"},
+		{name: "invalid group mappings", env: map[string]string{"AUTH_GROUP_MAPPINGS_FILE": write("bad-groups.json", `["ops"]`)}, wantErr: "parse group mappings"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"AUTH_ENABLED", "AUTH_PROVIDER", "API_KEYS_FILE", "AUTH_GROUP_MAPPINGS_FILE"} {
+				t.Setenv(name, tt.env[name])
+			}
+			c, err := LoadConfig(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if !tt.check(c) {
+				t.Errorf("LoadConfig() = %+v", c)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	with := func(change func(c *Config)) *Config {
+		c := DefaultConfig()
+		change(c)
+		return c
+	}
+	enabled := func(p ProviderType) func(c *Config) {
+		return func(c *Config) { c.Enabled, c.Provider = true, p }
+	}
+	tests := []struct {
+		name      string
+		cfg       *Config
+		transport string
+		wantErr   string
+	}{
+		{name: "stdio without auth", cfg: DefaultConfig(), transport: "stdio"},
+		{name: "HTTP without auth", cfg: DefaultConfig(), transport: "http", wantErr: "refusing to serve HTTP without authentication"},
+		{name: "SSL without auth", cfg: DefaultConfig(), transport: "ssl", wantErr: "refusing to serve HTTP without authentication"},
+		{name: "HTTP with provider none", cfg: with(enabled(ProviderNone)), transport: "http", wantErr: "refusing to serve HTTP without authentication"},
+		{name: "HTTP without auth allowed", cfg: with(func(c *Config) { c.AllowUnauthenticated = true }), transport: "http"},
+		{name: "HTTP with auth", cfg: with(enabled(ProviderAPIKey)), transport: "http"},
+		{name: "unknown provider", cfg: with(enabled("kerberos")), transport: "http", wantErr: `unknown auth provider "kerberos"`},
+		{name: "invalid default permission", cfg: with(func(c *Config) { c.DefaultPermissions = []string{"cluster:fly"} }), transport: "stdio", wantErr: "default permissions"},
+		{name: "invalid group mapping", cfg: with(func(c *Config) { c.GroupMappings = map[string][]string{"ops": {"everything"}} }), transport: "stdio", wantErr: "group mapping ops"},
+		{name: "unknown credentials mode", cfg: with(func(c *Config) { c.AmbariCredentials.Mode = "kerberos" }), transport: "stdio", wantErr: "unknown ambari credentials mode"},
+		{name: "invalid rbac cache TTL", cfg: with(func(c *Config) { c.AmbariRBAC.CacheTTL = "soon" }), transport: "stdio", wantErr: "cache_ttl"},
+		{name: "ldap-header without prefix", cfg: with(func(c *Config) { enabled(ProviderLDAPHeader)(c); c.LDAP.HeaderPrefix = "" }), transport: "http", wantErr: "requires a header prefix"},
+		{name: "ldap-header without proxy trust", cfg: with(func(c *Config) { enabled(ProviderLDAPHeader)(c); c.LDAP.TrustedProxies = nil }), transport: "http", wantErr: "requires trusted_proxies"},
+		{name: "ldap-header trusting a signature", cfg: with(func(c *Config) {
+			enabled(ProviderLDAPHeader)(c)
+			c.LDAP.TrustedProxies, c.LDAP.HMACSecretFile = nil, "/etc/hmac"
+		}), transport: "http"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate(tt.transport)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
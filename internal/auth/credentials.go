@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"mcp-ambari/internal/client"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Modes for the credentials used towards Ambari
+const (
+	// CredentialsService uses the shared AMBARI_USERNAME/AMBARI_PASSWORD account
+	CredentialsService = "service"
+	// CredentialsPassthrough forwards the caller's HTTP basic credentials
+	CredentialsPassthrough = "passthrough"
+	// CredentialsToken forwards the caller's bearer token as the Ambari JWT cookie
+	CredentialsToken = "token"
+	// CredentialsGroup uses a service account mapped from the caller's groups
+	CredentialsGroup = "group"
+)
+
+// AmbariCredentialsConfig selects whose credentials Ambari requests are made with
+type AmbariCredentialsConfig struct {
+	Mode              string `json:"mode"`
+	TokenCookie       string `json:"token_cookie"`
+	GroupAccountsFile string `json:"group_accounts_file"`
+}
+
+// GroupAccount is an Ambari account used for members of Group
+type GroupAccount struct {
+	Group        string `json:"group"`
+	Username     string `json:"username"`
+	PasswordFile string `json:"password_file"`
+	password     string
+}
+
+// CredentialResolver derives the Ambari credentials of a tool call from the
+// caller's AuthContext, so Ambari's audit log and RBAC see the real operator.
+// A nil resolver keeps the service account for every call.
+type CredentialResolver struct {
+	mode        string
+	tokenCookie string
+	accounts    []GroupAccount
+	logger      *logrus.Logger
+}
+
+// NewCredentialResolver creates a resolver. It returns nil for the service mode.
+func NewCredentialResolver(cfg AmbariCredentialsConfig, logger *logrus.Logger) (*CredentialResolver, error) {
+	r := &CredentialResolver{mode: cfg.Mode, tokenCookie: cfg.TokenCookie, logger: logger}
+	if r.tokenCookie == "" {
+		r.tokenCookie = "hadoop-jwt"
+	}
+	switch cfg.Mode {
+	case "", CredentialsService:
+		return nil, nil
+	case CredentialsPassthrough, CredentialsToken:
+	case CredentialsGroup:
+		if cfg.GroupAccountsFile == "" {
+			return nil, fmt.Errorf("ambari credentials mode group requires group_accounts_file")
+		}
+		accounts, err := loadGroupAccounts(cfg.GroupAccountsFile)
+		if err != nil {
+			return nil, err
+		}
+		r.accounts = accounts
+	default:
+		return nil, fmt.Errorf("unknown ambari credentials mode %q (supported: service, passthrough, token, group)", cfg.Mode)
+	}
+	return r, nil
+}
+
+// loadGroupAccounts reads an ordered JSON list of group accounts; the first
+// entry matching one of the caller's groups is used
+func loadGroupAccounts(path string) ([]GroupAccount, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read group accounts %s: %w", path, err)
+	}
+	var accounts []GroupAccount
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, fmt.Errorf("parse group accounts %s: %w", path, err)
+	}
+	for i := range accounts {
+		a := &accounts[i]
+		if a.Group == "" || a.Username == "" || a.PasswordFile == "" {
+			return nil, fmt.Errorf("group account %d: group, username and password_file are required", i+1)
+		}
+		secret, err := os.ReadFile(a.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("read password for group account %s: %w", a.Group, err)
+		}
+		a.password = strings.TrimSpace(string(secret))
+	}
+	return accounts, nil
+}
+
+// Mode returns the configured credential mode
+func (r *CredentialResolver) Mode() string {
+	if r == nil {
+		return CredentialsService
+	}
+	return r.mode
+}
+
+// Resolve returns the credentials for a call, or nil to use the service account
+func (r *CredentialResolver) Resolve(authCtx *AuthContext) (*client.Credentials, error) {
+	if r == nil {
+		return nil, nil
+	}
+	scheme, value, _ := strings.Cut(authCtx.Authorization, " ")
+	switch r.mode {
+	case CredentialsPassthrough:
+		if !strings.EqualFold(scheme, "basic") {
+			return nil, fmt.Errorf("ambari credential passthrough requires HTTP basic credentials from %s", authCtx.Username)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("malformed basic credentials from %s", authCtx.Username)
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed basic credentials from %s", authCtx.Username)
+		}
+		if username != authCtx.Username {
+			return nil, fmt.Errorf("basic credentials of %s do not belong to the authenticated user %s", username, authCtx.Username)
+		}
+		return &client.Credentials{Username: username, Password: password}, nil
+	case CredentialsToken:
+		if !strings.EqualFold(scheme, "bearer") || value == "" {
+			return nil, fmt.Errorf("ambari token passthrough requires a bearer token from %s", authCtx.Username)
+		}
+		return &client.Credentials{Token: strings.TrimSpace(value), TokenCookie: r.tokenCookie}, nil
+	case CredentialsGroup:
+		for _, a := range r.accounts {
+			if contains(authCtx.Groups, a.Group) {
+				return &client.Credentials{Username: a.Username, Password: a.password}, nil
+			}
+		}
+		return nil, fmt.Errorf("no Ambari account is mapped to any group of %s", authCtx.Username)
+	}
+	return nil, nil
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestCredentialResolverPassthrough(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	r, err := NewCredentialResolver(AmbariCredentialsConfig{Mode: CredentialsPassthrough}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	basic := func(userpass string) string {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(userpass))
+	}
+
+	tests := []struct {
+		name          string
+		username      string
+		authorization string
+		wantUser      string
+		wantErr       bool
+	}{
+		{name: "own credentials", username: "alice", authorization: basic("alice:pw"), wantUser: "alice"},
+		{name: "password with colon", username: "alice", authorization: basic("alice:p:w"), wantUser: "alice"},
+		{name: "another user's credentials", username: "alice", authorization: basic("admin:admin"), wantErr: true},
+		{name: "bearer token", username: "alice", authorization: "Bearer abc", wantErr: true},
+		{name: "no colon", username: "alice", authorization: basic("alice"), wantErr: true},
+		{name: "not base64", username: "alice", authorization: "Basic %%%", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds, err := r.Resolve(&AuthContext{Username: tt.username, Authorization: tt.authorization})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Resolve() = %+v, want error", creds)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if creds == nil || creds.Username != tt.wantUser {
+				t.Errorf("Resolve() = %+v, want username %s", creds, tt.wantUser)
+			}
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	sdkauth "github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/oauthex"
+	"github.com/sirupsen/logrus"
+)
+
+// ProtectedResourceMetadataPath is where OAuth protected resource metadata (RFC 9728) is served
+const ProtectedResourceMetadataPath = "/.well-known/oauth-protected-resource"
+
+// jwtLeeway tolerates clock skew between the authorization server and this server
+const jwtLeeway = 60 * time.Second
+
+var errMissingBearer = fmt.Errorf("bearer JWT not found in headers: %w", ErrNoCredentials)
+
+// TokenConfig configures the JWT bearer token provider
+type TokenConfig struct {
+	JWKSFile             string              `json:"jwks_file"`
+	Issuer               string              `json:"issuer"`
+	Audience             string              `json:"audience"`
+	UsernameClaim        string              `json:"username_claim"`
+	GroupsClaim          string              `json:"groups_claim"`
+	ScopeMappings        map[string][]string `json:"scope_mappings"`
+	Resource             string              `json:"resource"`
+	AuthorizationServers []string            `json:"authorization_servers"`
+}
+
+// JWTProvider implements AuthProvider for OAuth 2.1 bearer JWTs (MCP authorization spec).
+// Tokens are verified against a local JWKS file; scopes and groups map to permissions.
+type JWTProvider struct {
+	keys               map[string]crypto.PublicKey
+	issuer             string
+	audience           string
+	usernameClaim      string
+	groupsClaim        string
+	scopeMappings      map[string][]string
+	groupMappings      map[string][]string
+	defaultPermissions []Permission
+	metadata           *oauthex.ProtectedResourceMetadata
+	metadataURL        string
+	logger             *logrus.Logger
+}
+
+// NewJWTProvider creates a bearer token provider
+func NewJWTProvider(cfg TokenConfig, groupMappings map[string][]string, defaultPerms []string, logger *logrus.Logger) (*JWTProvider, error) {
+	if cfg.JWKSFile == "" || cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, fmt.Errorf("token provider requires jwks_file, issuer and audience")
+	}
+	keys, err := loadJWKS(cfg.JWKSFile)
+	if err != nil {
+		return nil, err
+	}
+	for scope, perms := range cfg.ScopeMappings {
+		if _, err := ParsePermissions(perms); err != nil {
+			return nil, fmt.Errorf("scope mapping %s: %w", scope, err)
+		}
+	}
+
+	p := &JWTProvider{
+		keys:               keys,
+		issuer:             cfg.Issuer,
+		audience:           cfg.Audience,
+		usernameClaim:      cfg.UsernameClaim,
+		groupsClaim:        cfg.GroupsClaim,
+		scopeMappings:      cfg.ScopeMappings,
+		groupMappings:      groupMappings,
+		defaultPermissions: toPermissions(defaultPerms),
+		logger:             logger,
+	}
+	if p.usernameClaim == "" {
+		p.usernameClaim = "sub"
+	}
+	if p.groupsClaim == "" {
+		p.groupsClaim = "groups"
+	}
+
+	resource := cfg.Resource
+	if resource == "" {
+		resource = cfg.Audience
+	}
+	scopes := make([]string, 0, len(cfg.ScopeMappings))
+	for scope := range cfg.ScopeMappings {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	p.metadata = &oauthex.ProtectedResourceMetadata{
+		Resource:               resource,
+		AuthorizationServers:   cfg.AuthorizationServers,
+		ScopesSupported:        scopes,
+		BearerMethodsSupported: []string{"header"},
+		ResourceName:           "Ambari MCP Server",
+	}
+	if u, err := url.Parse(resource); err == nil && u.Scheme != "" && u.Host != "" {
+		p.metadataURL = u.Scheme + "://" + u.Host + ProtectedResourceMetadataPath
+	}
+	return p, nil
+}
+
+func (p *JWTProvider) Name() string {
+	return "JWT"
+}
+
+func (p *JWTProvider) Authenticate(ctx context.Context, headers map[string]string) (*AuthContext, error) {
+	fields := strings.Fields(headers["authorization"])
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "bearer") || !looksLikeJWT(fields[1]) {
+		return nil, errMissingBearer
+	}
+
+	claims, err := p.verify(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid bearer token: %w", err)
+	}
+
+	username, _ := claims[p.usernameClaim].(string)
+	if username == "" {
+		return nil, fmt.Errorf("invalid bearer token: missing %s claim", p.usernameClaim)
+	}
+	groups := claimStrings(claims[p.groupsClaim])
+	scopes := tokenScopes(claims)
+
+	// Scopes grant permissions directly (when named like one) or via scope mappings;
+	// groups map through the shared group mappings.
+	permSet := make(map[Permission]bool)
+	for _, scope := range scopes {
+		if perms, err := ParsePermissions([]string{scope}); err == nil {
+			permSet[perms[0]] = true
+		}
+		for _, perm := range p.scopeMappings[scope] {
+			permSet[Permission(perm)] = true
+		}
+	}
+	for _, perm := range resolvePermissions(groups, p.groupMappings, nil) {
+		permSet[perm] = true
+	}
+	if len(permSet) == 0 {
+		for _, perm := range p.defaultPermissions {
+			permSet[perm] = true
+		}
+	}
+	permissions := make([]Permission, 0, len(permSet))
+	for perm := range permSet {
+		permissions = append(permissions, perm)
+	}
+
+	return &AuthContext{
+		Username:    username,
+		Groups:      groups,
+		Permissions: permissions,
+		IsValidated: true,
+		Source:      "JWT",
+	}, nil
+}
+
+// Challenge builds the WWW-Authenticate value for a failed authentication (RFC 6750 §3)
+func (p *JWTProvider) Challenge(err error) string {
+	params := []string{}
+	if p.metadataURL != "" {
+		params = append(params, fmt.Sprintf(`resource_metadata="%s"`, p.metadataURL))
+	}
+	if err != nil && !errors.Is(err, ErrNoCredentials) {
+		params = append(params, `error="invalid_token"`, fmt.Sprintf(`error_description="%s"`, strings.ReplaceAll(err.Error(), `"`, "'")))
+	}
+	if len(params) == 0 {
+		return "Bearer"
+	}
+	return "Bearer " + strings.Join(params, ", ")
+}
+
+// MetadataHandlers serves the OAuth protected resource metadata document so MCP
+// clients can discover the authorization server
+func (p *JWTProvider) MetadataHandlers() map[string]http.Handler {
+	return map[string]http.Handler{
+		ProtectedResourceMetadataPath: sdkauth.ProtectedResourceMetadataHandler(p.metadata),
+	}
+}
+
+func (p *JWTProvider) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("decode header: %w", err)
+	}
+	key, err := p.key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	switch header.Alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("key %q is not an RSA key", header.Kid)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+			return nil, fmt.Errorf("signature verification failed")
+		}
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return nil, fmt.Errorf("key %q is not a P-256 key or signature malformed", header.Kid)
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return nil, fmt.Errorf("signature verification failed")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", header.Alg)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("decode claims: %w", err)
+	}
+
+	now := time.Now()
+	if iss, _ := claims["iss"].(string); iss != p.issuer {
+		return nil, fmt.Errorf("unexpected issuer %q", iss)
+	}
+	if !contains(claimStrings(claims["aud"]), p.audience) {
+		return nil, fmt.Errorf("token not issued for audience %q", p.audience)
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("missing exp claim")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(jwtLeeway)) {
+		return nil, fmt.Errorf("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtLeeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, fmt.Errorf("token not yet valid")
+	}
+	return claims, nil
+}
+
+func (p *JWTProvider) key(kid string) (crypto.PublicKey, error) {
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// loadJWKS reads RSA and P-256 public keys from a JWKS file, keyed by kid
+func loadJWKS(path string) (map[string]crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read JWKS %s: %w", path, err)
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse JWKS %s: %w", path, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil {
+				return nil, fmt.Errorf("JWKS key %q: invalid RSA parameters", k.Kid)
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			if k.Crv != "P-256" {
+				return nil, fmt.Errorf("JWKS key %q: unsupported curve %q", k.Kid, k.Crv)
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if errX != nil || errY != nil {
+				return nil, fmt.Errorf("JWKS key %q: invalid EC parameters", k.Kid)
+			}
+			pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+			if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+				return nil, fmt.Errorf("JWKS key %q: point not on curve", k.Kid)
+			}
+			keys[k.Kid] = pub
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS %s contains no usable signing keys", path)
+	}
+	return keys, nil
+}
+
+// looksLikeJWT distinguishes a compact JWS from other bearer credentials (e.g. API keys)
+func looksLikeJWT(token string) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	return decodeSegment(parts[0], &header) == nil && header.Alg != ""
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// tokenScopes reads the space-delimited "scope" claim, or the "scp" array some issuers use
+func tokenScopes(claims map[string]interface{}) []string {
+	if s, ok := claims["scope"].(string); ok {
+		return strings.Fields(s)
+	}
+	return claimStrings(claims["scp"])
+}
+
+// claimStrings normalizes a claim that may be a string or an array of strings
+func claimStrings(v interface{}) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case []interface{}:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testKeys holds the signing keys behind the JWKS a test provider trusts
+type testKeys struct {
+	rsa  *rsa.PrivateKey
+	ec   *ecdsa.PrivateKey
+	jwks string
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	set := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": b64(rsaKey.N.Bytes()), "e": "AQAB"},
+	}}
+	data, _ := json.Marshal(set)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return &testKeys{rsa: rsaKey, ec: ecKey, jwks: path}
+}
+
+// sign builds a compact JWS; alg selects the key, kid is sent as given
+func (k *testKeys) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch alg {
+	case "RS256":
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestJWTVerify(t *testing.T) {
+	keys := newTestKeys(t)
+	p, err := NewJWTProvider(TokenConfig{
+		JWKSFile: keys.jwks,
+		Issuer:   "https://idp.example.com",
+		Audience: "https://mcp.example.com",
+	}, nil, []string{string(ClusterView)}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Unix()
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss": "https://idp.example.com",
+			"aud": "https://mcp.example.com",
+			"sub": "alice",
+			"exp": now + 300,
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+	valid := keys.sign(t, "RS256", "rsa", claims(nil))
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{name: "RS256", token: valid},
+		{name: "ES256", token: keys.sign(t, "ES256", "ec", claims(nil))},
+		{name: "audience in list", token: keys.sign(t, "RS256", "rsa", claims(map[string]interface{}{"aud": []string{"other", "https://mcp.example.com"}}))},
+		{name: "expired within leeway", token: keys.sign(t, "RS256", "rsa", claims(map[string]interface{}{"exp": now - 30}))},
+		{name: "not before within leeway", token: keys.sign(t, "RS256", "rsa", claims(map[string]interface{}{"nbf": now + 30}))},
+		{name: "expired", token: keys.sign(t, "RS256", "rsa", claims(map[string]interface{}{"exp": now - 120})), wantErr: "token expired"},
+		{name: "not yet valid", token: keys.sign(t, "RS256", "rsa", claims(map[string]interface{}{"nbf": now + 120})), wantErr: "not yet valid"},
+		{name: "missing exp", token: keys.sign(t, "RS256", "rsa", claims(map[string]interface{}{"exp": nil})), wantErr: "missing exp"},
+		{name: "other issuer", token: keys.sign(t, "RS256", "rsa", claims(map[string]interface{}{"iss": "https://evil.example.com"})), wantErr: "unexpected issuer"},
+		{name: "other audience", token: keys.sign(t, "RS256", "rsa", claims(map[string]interface{}{"aud": "https://other.example.com"})), wantErr: "audience"},
+		{name: "unknown kid", token: keys.sign(t, "RS256", "missing", claims(nil)), wantErr: "unknown signing key"},
+		{name: "encryption key", token: keys.sign(t, "RS256", "enc", claims(nil)), wantErr: "unknown signing key"},
+		{name: "no kid with several keys", token: keys.sign(t, "RS256", "", claims(nil)), wantErr: "unknown signing key"},
+		{name: "algorithm of another key type", token: keys.sign(t, "RS256", "ec", claims(nil)), wantErr: "not an RSA key"},
+		{name: "unsigned", token: keys.sign(t, "none", "rsa", claims(nil)), wantErr: "unsupported signing algorithm"},
+		{name: "HMAC", token: keys.sign(t, "HS256", "rsa", claims(nil)), wantErr: "unsupported signing algorithm"},
+		{name: "tampered claims", token: parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"https://idp.example.com","aud":"https://mcp.example.com","sub":"admin","exp":9999999999}`)) + "." + parts[2], wantErr: "signature verification failed"},
+		{name: "signature of another token", token: parts[0] + "." + parts[1] + "." + strings.Split(keys.sign(t, "RS256", "rsa", claims(map[string]interface{}{"sub": "bob"})), ".")[2], wantErr: "signature verification failed"},
+		{name: "missing username", token: keys.sign(t, "RS256", "rsa", claims(map[string]interface{}{"sub": nil})), wantErr: "missing sub claim"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authCtx, err := p.Authenticate(context.Background(), map[string]string{"authorization": "Bearer " + tt.token})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Authenticate() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if errors.Is(err, ErrNoCredentials) {
+					t.Errorf("Authenticate() error = %v passes the request on to other providers", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if authCtx.Username != "alice" || !authCtx.IsValidated {
+				t.Errorf("Authenticate() = %+v, want validated alice", authCtx)
+			}
+		})
+	}
+}
+
+func TestJWTNotABearerJWT(t *testing.T) {
+	keys := newTestKeys(t)
+	p, err := NewJWTProvider(TokenConfig{JWKSFile: keys.jwks, Issuer: "iss", Audience: "aud"}, nil, nil, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, authorization := range []string{"", "Basic YWxpY2U6cHc=", "Bearer amk_0123456789", "Bearer a.b.c", "Bearer"} {
+		if _, err := p.Authenticate(context.Background(), map[string]string{"authorization": authorization}); !errors.Is(err, ErrNoCredentials) {
+			t.Errorf("Authenticate(%q) error = %v, want ErrNoCredentials", authorization, err)
+		}
+	}
+}
+
+func TestJWTPermissions(t *testing.T) {
+	keys := newTestKeys(t)
+	p, err := NewJWTProvider(TokenConfig{
+		JWKSFile:      keys.jwks,
+		Issuer:        "iss",
+		Audience:      "aud",
+		UsernameClaim: "preferred_username",
+		ScopeMappings: map[string][]string{"ambari.operate": {string(ServiceRestart), string(ServiceOperate)}},
+	}, map[string][]string{"ops": {string(AlertManage)}}, []string{string(ClusterView)}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		claims     map[string]interface{}
+		wantGroups []string
+		want       []Permission
+	}{
+		{name: "defaults", claims: map[string]interface{}{}, want: []Permission{ClusterView}},
+		{name: "scope named like a permission", claims: map[string]interface{}{"scope": "openid service:restart"}, want: []Permission{ServiceRestart}},
+		{name: "mapped scope", claims: map[string]interface{}{"scope": "ambari.operate"}, want: []Permission{ServiceRestart, ServiceOperate}},
+		{name: "scp array", claims: map[string]interface{}{"scp": []string{"ambari.operate"}}, want: []Permission{ServiceRestart, ServiceOperate}},
+		{name: "mapped group", claims: map[string]interface{}{"groups": []string{"ops", "other"}}, wantGroups: []string{"ops", "other"}, want: []Permission{AlertManage}},
+		{name: "single group string", claims: map[string]interface{}{"groups": "ops"}, wantGroups: []string{"ops"}, want: []Permission{AlertManage}},
+		{name: "unknown scope and group", claims: map[string]interface{}{"scope": "openid", "groups": []string{"other"}}, wantGroups: []string{"other"}, want: []Permission{ClusterView}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := map[string]interface{}{"iss": "iss", "aud": "aud", "preferred_username": "alice", "sub": "0b5e", "exp": time.Now().Unix() + 300}
+			for k, v := range tt.claims {
+				c[k] = v
+			}
+			authCtx, err := p.Authenticate(context.Background(), map[string]string{"authorization": "Bearer " + keys.sign(t, "ES256", "ec", c)})
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if authCtx.Username != "alice" {
+				t.Errorf("Username = %q, want alice", authCtx.Username)
+			}
+			sort.Slice(authCtx.Permissions, func(i, j int) bool { return authCtx.Permissions[i] < authCtx.Permissions[j] })
+			sort.Slice(tt.want, func(i, j int) bool { return tt.want[i] < tt.want[j] })
+			if strings.Join(toStrings(authCtx.Permissions), ",") != strings.Join(toStrings(tt.want), ",") {
+				t.Errorf("Permissions = %v, want %v", authCtx.Permissions, tt.want)
+			}
+			if strings.Join(authCtx.Groups, ",") != strings.Join(tt.wantGroups, ",") {
+				t.Errorf("Groups = %v, want %v", authCtx.Groups, tt.wantGroups)
+			}
+		})
+	}
+}
+
+func toStrings(perms []Permission) []string {
+	out := make([]string, len(perms))
+	for i, p := range perms {
+		out[i] = string(p)
+	}
+	return out
+}
+
+func TestJWTChallenge(t *testing.T) {
+	keys := newTestKeys(t)
+	tests := []struct {
+		name     string
+		resource string
+		err      error
+		want     string
+	}{
+		{name: "no credentials", resource: "https://mcp.example.com/mcp", err: errMissingBearer, want: `Bearer resource_metadata="https://mcp.example.com/.well-known/oauth-protected-resource"`},
+		{name: "invalid token", resource: "https://mcp.example.com/mcp", err: errors.New(`bad "kid"`), want: `Bearer resource_metadata="https://mcp.example.com/.well-known/oauth-protected-resource", error="invalid_token", error_description="bad 'kid'"`},
+		{name: "resource without URL", resource: "ambari-mcp", err: errMissingBearer, want: "Bearer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewJWTProvider(TokenConfig{JWKSFile: keys.jwks, Issuer: "iss", Audience: "aud", Resource: tt.resource}, nil, nil, testLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.Challenge(tt.err); got != tt.want {
+				t.Errorf("Challenge() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
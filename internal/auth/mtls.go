@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+const tlsStateKey contextKey = "tls_state"
+
+// withTLSState stores the connection's TLS state for providers that authenticate
+// from the transport rather than from headers
+func withTLSState(ctx context.Context, state *tls.ConnectionState) context.Context {
+	return context.WithValue(ctx, tlsStateKey, state)
+}
+
+// TLSStateFromContext returns the TLS state of the request being authenticated
+func TLSStateFromContext(ctx context.Context) (*tls.ConnectionState, bool) {
+	state, ok := ctx.Value(tlsStateKey).(*tls.ConnectionState)
+	return state, ok && state != nil
+}
+
+// Sources for the certificate username
+const (
+	CertUsernameFromCN       = "cn"
+	CertUsernameFromSANURI   = "san-uri"
+	CertUsernameFromSANEmail = "san-email"
+)
+
+// Sources for the certificate groups
+const (
+	CertGroupsFromOU   = "ou"
+	CertGroupsFromFile = "file"
+)
+
+// MTLSConfig configures the client certificate provider
+type MTLSConfig struct {
+	UsernameFrom         string `json:"username_from"`
+	GroupsFrom           string `json:"groups_from"`
+	IdentityMappingsFile string `json:"identity_mappings_file"`
+}
+
+// MTLSProvider implements AuthProvider from verified TLS client certificates
+type MTLSProvider struct {
+	usernameFrom       string
+	groupsFrom         string
+	identityGroups     map[string][]string
+	groupMappings      map[string][]string
+	defaultPermissions []Permission
+	logger             *logrus.Logger
+}
+
+// NewMTLSProvider creates a client certificate authentication provider.
+// Permissions are derived from groups exactly as the LDAP provider does.
+func NewMTLSProvider(cfg MTLSConfig, groupMappings map[string][]string, defaultPerms []string, logger *logrus.Logger) (*MTLSProvider, error) {
+	p := &MTLSProvider{
+		usernameFrom:       cfg.UsernameFrom,
+		groupsFrom:         cfg.GroupsFrom,
+		groupMappings:      groupMappings,
+		defaultPermissions: toPermissions(defaultPerms),
+		logger:             logger,
+	}
+	if p.usernameFrom == "" {
+		p.usernameFrom = CertUsernameFromCN
+	}
+	if p.groupsFrom == "" {
+		p.groupsFrom = CertGroupsFromOU
+	}
+
+	switch p.usernameFrom {
+	case CertUsernameFromCN, CertUsernameFromSANURI, CertUsernameFromSANEmail:
+	default:
+		return nil, fmt.Errorf("unsupported mTLS username source %q (supported: cn, san-uri, san-email)", p.usernameFrom)
+	}
+
+	switch p.groupsFrom {
+	case CertGroupsFromOU:
+	case CertGroupsFromFile:
+		if cfg.IdentityMappingsFile == "" {
+			return nil, fmt.Errorf("mTLS groups_from=file requires identity_mappings_file")
+		}
+		data, err := os.ReadFile(cfg.IdentityMappingsFile)
+		if err != nil {
+			return nil, fmt.Errorf("read identity mappings %s: %w", cfg.IdentityMappingsFile, err)
+		}
+		if err := json.Unmarshal(data, &p.identityGroups); err != nil {
+			return nil, fmt.Errorf("parse identity mappings %s: %w", cfg.IdentityMappingsFile, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported mTLS groups source %q (supported: ou, file)", p.groupsFrom)
+	}
+	return p, nil
+}
+
+func (p *MTLSProvider) Name() string {
+	return "mTLS"
+}
+
+func (p *MTLSProvider) Authenticate(ctx context.Context, headers map[string]string) (*AuthContext, error) {
+	state, ok := TLSStateFromContext(ctx)
+	if !ok || len(state.PeerCertificates) == 0 {
+		return nil, fmt.Errorf("no client certificate presented: %w", ErrNoCredentials)
+	}
+	if len(state.VerifiedChains) == 0 {
+		return nil, fmt.Errorf("client certificate not verified")
+	}
+	cert := state.PeerCertificates[0]
+
+	username, err := p.username(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []string
+	switch p.groupsFrom {
+	case CertGroupsFromOU:
+		groups = append(groups, cert.Subject.OrganizationalUnit...)
+	case CertGroupsFromFile:
+		groups = append(groups, p.identityGroups[username]...)
+	}
+
+	return &AuthContext{
+		Username:    username,
+		Groups:      groups,
+		Permissions: resolvePermissions(groups, p.groupMappings, p.defaultPermissions),
+		IsValidated: true,
+		Source:      "mTLS",
+	}, nil
+}
+
+func (p *MTLSProvider) username(cert *x509.Certificate) (string, error) {
+	switch p.usernameFrom {
+	case CertUsernameFromSANURI:
+		if len(cert.URIs) > 0 {
+			return cert.URIs[0].String(), nil
+		}
+		return "", fmt.Errorf("client certificate has no URI SAN")
+	case CertUsernameFromSANEmail:
+		if len(cert.EmailAddresses) > 0 {
+			return cert.EmailAddresses[0], nil
+		}
+		return "", fmt.Errorf("client certificate has no email SAN")
+	default:
+		if cert.Subject.CommonName != "" {
+			return cert.Subject.CommonName, nil
+		}
+		return "", fmt.Errorf("client certificate has no common name")
+	}
+}
@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCA issues client certificates for the mTLS tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// issue signs a client certificate from template
+func (ca *testCA) issue(t *testing.T, template *x509.Certificate) *x509.Certificate {
+	t.Helper()
+	return ca.issueKeyPair(t, template).Leaf
+}
+
+// issueKeyPair signs a client certificate from template and returns it with its key
+func (ca *testCA) issueKeyPair(t *testing.T, template *x509.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}
+}
+
+// presented returns the TLS state of a connection on which cert was
+// presented, with the chains a server trusting roots would have verified
+func presented(t *testing.T, cert *x509.Certificate, roots *testCA) *tls.ConnectionState {
+	t.Helper()
+	state := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	pool := x509.NewCertPool()
+	pool.AddCert(roots.cert)
+	chains, err := cert.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	if err == nil {
+		state.VerifiedChains = chains
+	}
+	return state
+}
+
+func TestMTLSAuthenticate(t *testing.T) {
+	ca := newTestCA(t, "Test CA")
+	rogue := newTestCA(t, "Rogue CA")
+	spiffe, _ := url.Parse("spiffe://example.com/ci-bot")
+
+	alice := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "alice", OrganizationalUnit: []string{"ops", "users"}}})
+	bot := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "ci"}, URIs: []*url.URL{spiffe}, EmailAddresses: []string{"ci@example.com"}})
+	anonymous := ca.issue(t, &x509.Certificate{Subject: pkix.Name{OrganizationalUnit: []string{"ops"}}})
+	forged := rogue.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "admin", OrganizationalUnit: []string{"ambari-admins"}}})
+
+	mappings := filepath.Join(t.TempDir(), "identities.json")
+	if err := os.WriteFile(mappings, []byte(`{"spiffe://example.com/ci-bot": ["ops"], "ci@example.com": ["users"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		cfg         MTLSConfig
+		state       *tls.ConnectionState
+		wantUser    string
+		wantGroups  string
+		wantPerms   string
+		wantErr     string
+		wantNoCreds bool
+	}{
+		{name: "CN and OU", state: presented(t, alice, ca), wantUser: "alice", wantGroups: "ops,users", wantPerms: "service:restart"},
+		{name: "URI SAN and groups file", cfg: MTLSConfig{UsernameFrom: CertUsernameFromSANURI, GroupsFrom: CertGroupsFromFile, IdentityMappingsFile: mappings},
+			state: presented(t, bot, ca), wantUser: "spiffe://example.com/ci-bot", wantGroups: "ops", wantPerms: "service:restart"},
+		{name: "email SAN", cfg: MTLSConfig{UsernameFrom: CertUsernameFromSANEmail, GroupsFrom: CertGroupsFromFile, IdentityMappingsFile: mappings},
+			state: presented(t, bot, ca), wantUser: "ci@example.com", wantGroups: "users", wantPerms: "cluster:view"},
+		{name: "no OU gets default permissions", state: presented(t, bot, ca), wantUser: "ci", wantGroups: "", wantPerms: "cluster:view"},
+		{name: "identity missing from groups file", cfg: MTLSConfig{GroupsFrom: CertGroupsFromFile, IdentityMappingsFile: mappings},
+			state: presented(t, alice, ca), wantUser: "alice", wantGroups: "", wantPerms: "cluster:view"},
+		{name: "no common name", state: presented(t, anonymous, ca), wantErr: "no common name"},
+		{name: "no URI SAN", cfg: MTLSConfig{UsernameFrom: CertUsernameFromSANURI}, state: presented(t, alice, ca), wantErr: "no URI SAN"},
+		{name: "no email SAN", cfg: MTLSConfig{UsernameFrom: CertUsernameFromSANEmail}, state: presented(t, alice, ca), wantErr: "no email SAN"},
+		{name: "unverified chain", state: presented(t, forged, ca), wantErr: "not verified"},
+		{name: "no certificate", state: &tls.ConnectionState{}, wantNoCreds: true},
+		{name: "no TLS", wantNoCreds: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewMTLSProvider(tt.cfg, map[string][]string{"ops": {string(ServiceRestart)}}, []string{string(ClusterView)}, testLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			if tt.state != nil {
+				ctx = withTLSState(ctx, tt.state)
+			}
+			authCtx, err := p.Authenticate(ctx, map[string]string{"x-remote-name": "admin"})
+			if tt.wantNoCreds {
+				if !errors.Is(err, ErrNoCredentials) {
+					t.Fatalf("Authenticate() error = %v, want ErrNoCredentials", err)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Authenticate() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if errors.Is(err, ErrNoCredentials) {
+					t.Errorf("Authenticate() error = %v passes the request on to other providers", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			var perms []string
+			for _, perm := range authCtx.Permissions {
+				perms = append(perms, string(perm))
+			}
+			if authCtx.Username != tt.wantUser || strings.Join(authCtx.Groups, ",") != tt.wantGroups || strings.Join(perms, ",") != tt.wantPerms {
+				t.Errorf("Authenticate() = %s in %v with %v, want %s in [%s] with [%s]",
+					authCtx.Username, authCtx.Groups, perms, tt.wantUser, tt.wantGroups, tt.wantPerms)
+			}
+			if !authCtx.IsValidated || authCtx.Source != "mTLS" {
+				t.Errorf("Authenticate() validated=%v source=%s", authCtx.IsValidated, authCtx.Source)
+			}
+		})
+	}
+}
+
+func TestMTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`["ops"]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		cfg     MTLSConfig
+		wantErr string
+	}{
+		{name: "unknown username source", cfg: MTLSConfig{UsernameFrom: "serial"}, wantErr: "unsupported mTLS username source"},
+		{name: "unknown groups source", cfg: MTLSConfig{GroupsFrom: "ldap"}, wantErr: "unsupported mTLS groups source"},
+		{name: "groups file not set", cfg: MTLSConfig{GroupsFrom: CertGroupsFromFile}, wantErr: "requires identity_mappings_file"},
+		{name: "groups file missing", cfg: MTLSConfig{GroupsFrom: CertGroupsFromFile, IdentityMappingsFile: filepath.Join(dir, "missing.json")}, wantErr: "read identity mappings"},
+		{name: "groups file invalid", cfg: MTLSConfig{GroupsFrom: CertGroupsFromFile, IdentityMappingsFile: invalid}, wantErr: "parse identity mappings"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMTLSProvider(tt.cfg, nil, nil, testLogger())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewMTLSProvider() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMTLSHandshake(t *testing.T) {
+	ca := newTestCA(t, "Test CA")
+	rogue := newTestCA(t, "Rogue CA")
+	trusted := ca.issueKeyPair(t, &x509.Certificate{Subject: pkix.Name{CommonName: "alice", OrganizationalUnit: []string{"ops"}}})
+	forged := rogue.issueKeyPair(t, &x509.Certificate{Subject: pkix.Name{CommonName: "alice", OrganizationalUnit: []string{"ops"}}})
+	p, err := NewMTLSProvider(MTLSConfig{}, map[string][]string{"ops": {string(ServiceRestart)}}, nil, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		clientAuth tls.ClientAuthType
+		cert       *tls.Certificate
+		want       string
+	}{
+		{name: "verified", clientAuth: tls.VerifyClientCertIfGiven, cert: &trusted, want: "alice"},
+		{name: "requested but not verified", clientAuth: tls.RequestClientCert, cert: &trusted, want: "client certificate not verified"},
+		{name: "other CA not verified", clientAuth: tls.RequestClientCert, cert: &forged, want: "client certificate not verified"},
+		{name: "none presented", clientAuth: tls.VerifyClientCertIfGiven, want: "no client certificate presented"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authCtx, err := p.Authenticate(withTLSState(r.Context(), r.TLS), nil)
+				if err != nil {
+					fmt.Fprint(w, err)
+					return
+				}
+				fmt.Fprint(w, authCtx.Username)
+			}))
+			pool := x509.NewCertPool()
+			pool.AddCert(ca.cert)
+			srv.TLS = &tls.Config{ClientAuth: tt.clientAuth, ClientCAs: pool}
+			srv.StartTLS()
+			defer srv.Close()
+
+			transport := srv.Client().Transport.(*http.Transport).Clone()
+			if tt.cert != nil {
+				// Present the certificate even when the server does not ask for its CA
+				cert := tt.cert
+				transport.TLSClientConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) { return cert, nil }
+			}
+			resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(body), tt.want) {
+				t.Errorf("Authenticate() = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const peerAddrKey contextKey = "peer_addr"
+
+// maxSignatureSkew bounds the age of a signed identity header set
+const maxSignatureSkew = 5 * time.Minute
+
+// withPeerAddr stores the address of the directly connected peer
+func withPeerAddr(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, peerAddrKey, addr)
+}
+
+// PeerAddrFromContext returns the remote address of the connection being authenticated
+func PeerAddrFromContext(ctx context.Context) (string, bool) {
+	addr, ok := ctx.Value(peerAddrKey).(string)
+	return addr, ok && addr != ""
+}
+
+// HeaderSanitizer is implemented by providers that accept identity headers only
+// from trusted peers. Sanitize removes those headers when the peer is not trusted
+// so they never reach the provider or downstream handlers.
+type HeaderSanitizer interface {
+	Sanitize(ctx context.Context, header http.Header)
+}
+
+// proxyTrust decides whether identity headers come from a trusted reverse proxy:
+// the peer address is in a trusted CIDR, the peer presented a verified client
+// certificate with a trusted CN, or the headers carry a valid HMAC signature.
+type proxyTrust struct {
+	networks []*net.IPNet
+	certCNs  []string
+	secret   []byte
+	prefix   string
+}
+
+func newProxyTrust(cfg LDAPConfig) (*proxyTrust, error) {
+	t := &proxyTrust{certCNs: cfg.TrustedProxyCNs, prefix: cfg.HeaderPrefix}
+	for _, cidr := range cfg.TrustedProxies {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", cidr, err)
+		}
+		t.networks = append(t.networks, network)
+	}
+	if cfg.HMACSecretFile != "" {
+		secret, err := os.ReadFile(cfg.HMACSecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("read HMAC secret %s: %w", cfg.HMACSecretFile, err)
+		}
+		t.secret = []byte(strings.TrimSpace(string(secret)))
+		if len(t.secret) == 0 {
+			return nil, fmt.Errorf("HMAC secret %s is empty", cfg.HMACSecretFile)
+		}
+	}
+	return t, nil
+}
+
+// verify returns nil when the identity headers may be trusted. get returns a
+// header value by its lowercased name.
+func (t *proxyTrust) verify(ctx context.Context, get func(string) string) error {
+	if addr, ok := PeerAddrFromContext(ctx); ok && t.trustedAddr(addr) {
+		return nil
+	}
+	if state, ok := TLSStateFromContext(ctx); ok && len(state.VerifiedChains) > 0 {
+		if contains(t.certCNs, state.PeerCertificates[0].Subject.CommonName) {
+			return nil
+		}
+	}
+	if len(t.secret) > 0 && get(t.prefix+"signature") != "" {
+		return t.verifySignature(get)
+	}
+	addr, _ := PeerAddrFromContext(ctx)
+	return fmt.Errorf("identity headers from untrusted peer %q", addr)
+}
+
+func (t *proxyTrust) trustedAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range t.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifySignature checks <prefix>signature, a hex HMAC-SHA256 over
+// "<timestamp>\n<name>\n<groups>" keyed with the shared secret, where
+// <prefix>timestamp is Unix seconds within maxSignatureSkew of now.
+func (t *proxyTrust) verifySignature(get func(string) string) error {
+	ts, err := strconv.ParseInt(get(t.prefix+"timestamp"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid or missing %stimestamp header", t.prefix)
+	}
+	skew := time.Since(time.Unix(ts, 0))
+	if skew > maxSignatureSkew || skew < -maxSignatureSkew {
+		return fmt.Errorf("identity header signature expired")
+	}
+	want, err := hex.DecodeString(get(t.prefix + "signature"))
+	if err != nil {
+		return fmt.Errorf("malformed identity header signature")
+	}
+	username := get(t.prefix + "name")
+	if username == "" {
+		username = get(t.prefix + "username")
+	}
+	mac := hmac.New(sha256.New, t.secret)
+	fmt.Fprintf(mac, "%d\n%s\n%s", ts, username, get(t.prefix+"groups"))
+	if !hmac.Equal(mac.Sum(nil), want) {
+		return fmt.Errorf("invalid identity header signature")
+	}
+	return nil
+}
+
+// strip removes every header carrying the identity prefix
+func (t *proxyTrust) strip(header http.Header) {
+	for name := range header {
+		if strings.HasPrefix(strings.ToLower(name), t.prefix) {
+			header.Del(name)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testLDAPProvider(t *testing.T) *LDAPProvider {
+	t.Helper()
+	secret := filepath.Join(t.TempDir(), "hmac")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewLDAPProvider(LDAPConfig{
+		HeaderPrefix:    "x-remote-",
+		TrustedProxies:  []string{"10.0.0.0/24", "fd00::/64"},
+		TrustedProxyCNs: []string{"proxy.example.com"},
+		HMACSecretFile:  secret,
+	}, map[string][]string{"ops": {string(ServiceRestart)}}, []string{string(ClusterView)}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// signIdentity signs the identity headers as a trusted proxy would
+func signIdentity(secret string, ts int64, username, groups string) map[string]string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d\n%s\n%s", ts, username, groups)
+	return map[string]string{
+		"x-remote-name":      username,
+		"x-remote-groups":    groups,
+		"x-remote-timestamp": strconv.FormatInt(ts, 10),
+		"x-remote-signature": hex.EncodeToString(mac.Sum(nil)),
+	}
+}
+
+func withProxyCert(ctx context.Context, cn string, verified bool) context.Context {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+	state := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	if verified {
+		state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+	return withTLSState(ctx, state)
+}
+
+func TestProxyTrust(t *testing.T) {
+	now := time.Now().Unix()
+	plain := map[string]string{"x-remote-name": "alice", "x-remote-groups": "ops, users"}
+	with := func(headers map[string]string, name, value string) map[string]string {
+		out := map[string]string{}
+		for k, v := range headers {
+			out[k] = v
+		}
+		out[name] = value
+		return out
+	}
+	signed := signIdentity("s3cret", now, "alice", "ops, users")
+	untrusted := withPeerAddr(context.Background(), "192.0.2.7:4711")
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		headers map[string]string
+		wantErr string
+	}{
+		{name: "trusted IPv4 proxy", ctx: withPeerAddr(context.Background(), "10.0.0.5:4711"), headers: plain},
+		{name: "trusted IPv6 proxy", ctx: withPeerAddr(context.Background(), "[fd00::1]:4711"), headers: plain},
+		{name: "address without port", ctx: withPeerAddr(context.Background(), "10.0.0.5"), headers: plain},
+		{name: "untrusted peer", ctx: untrusted, headers: plain, wantErr: "untrusted peer"},
+		{name: "neighbouring network", ctx: withPeerAddr(context.Background(), "10.0.1.5:4711"), headers: plain, wantErr: "untrusted peer"},
+		{name: "unknown peer", ctx: context.Background(), headers: plain, wantErr: "untrusted peer"},
+		{name: "trusted proxy certificate", ctx: withProxyCert(untrusted, "proxy.example.com", true), headers: plain},
+		{name: "unverified proxy certificate", ctx: withProxyCert(untrusted, "proxy.example.com", false), headers: plain, wantErr: "untrusted peer"},
+		{name: "other certificate", ctx: withProxyCert(untrusted, "client.example.com", true), headers: plain, wantErr: "untrusted peer"},
+		{name: "signed", ctx: untrusted, headers: signed},
+		{name: "signed within skew", ctx: untrusted, headers: signIdentity("s3cret", now-240, "alice", "ops, users")},
+		{name: "signature expired", ctx: untrusted, headers: signIdentity("s3cret", now-600, "alice", "ops, users"), wantErr: "signature expired"},
+		{name: "signature from the future", ctx: untrusted, headers: signIdentity("s3cret", now+600, "alice", "ops, users"), wantErr: "signature expired"},
+		{name: "other secret", ctx: untrusted, headers: signIdentity("guess", now, "alice", "ops, users"), wantErr: "invalid identity header signature"},
+		{name: "username changed", ctx: untrusted, headers: with(signed, "x-remote-name", "admin"), wantErr: "invalid identity header signature"},
+		{name: "groups changed", ctx: untrusted, headers: with(signed, "x-remote-groups", "ops, users, ambari-admins"), wantErr: "invalid identity header signature"},
+		{name: "timestamp changed", ctx: untrusted, headers: with(signed, "x-remote-timestamp", strconv.FormatInt(now-1, 10)), wantErr: "invalid identity header signature"},
+		{name: "timestamp missing", ctx: untrusted, headers: with(signed, "x-remote-timestamp", ""), wantErr: "timestamp"},
+		{name: "signature not hex", ctx: untrusted, headers: with(signed, "x-remote-signature", "zz"), wantErr: "malformed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testLDAPProvider(t)
+			authCtx, err := p.Authenticate(tt.ctx, tt.headers)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Authenticate() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if errors.Is(err, ErrNoCredentials) {
+					t.Errorf("Authenticate() error = %v passes the request on to other providers", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if authCtx.Username != "alice" || strings.Join(authCtx.Groups, ",") != "ops,users" {
+				t.Errorf("Authenticate() = %s in %v, want alice in [ops users]", authCtx.Username, authCtx.Groups)
+			}
+			if len(authCtx.Permissions) != 1 || authCtx.Permissions[0] != ServiceRestart {
+				t.Errorf("Permissions = %v, want [%s]", authCtx.Permissions, ServiceRestart)
+			}
+		})
+	}
+}
+
+func TestProxyTrustWithoutIdentity(t *testing.T) {
+	p := testLDAPProvider(t)
+	_, err := p.Authenticate(withPeerAddr(context.Background(), "192.0.2.7:4711"), map[string]string{"x-remote-groups": "ops"})
+	if !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("Authenticate() error = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestProxySanitize(t *testing.T) {
+	tests := []struct {
+		name      string
+		addr      string
+		header    http.Header
+		wantStrip bool
+	}{
+		{name: "trusted proxy", addr: "10.0.0.5:4711", header: http.Header{"X-Remote-Name": {"alice"}, "X-Remote-Groups": {"ops"}}},
+		{name: "untrusted peer", addr: "192.0.2.7:4711", header: http.Header{"X-Remote-Name": {"alice"}, "X-Remote-Groups": {"ops"}}, wantStrip: true},
+		{name: "groups only", addr: "192.0.2.7:4711", header: http.Header{"X-Remote-Groups": {"ambari-admins"}}, wantStrip: true},
+		{name: "forged signature", addr: "192.0.2.7:4711", header: http.Header{"X-Remote-Name": {"alice"}, "X-Remote-Timestamp": {"1"}, "X-Remote-Signature": {"00"}}, wantStrip: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testLDAPProvider(t)
+			tt.header.Set("Content-Type", "application/json")
+			p.Sanitize(withPeerAddr(context.Background(), tt.addr), tt.header)
+			for name := range tt.header {
+				if strings.HasPrefix(strings.ToLower(name), "x-remote-") && tt.wantStrip {
+					t.Errorf("header %s was not stripped", name)
+				}
+			}
+			if !tt.wantStrip && tt.header.Get("X-Remote-Name") == "" && tt.header.Get("X-Remote-Groups") == "" {
+				t.Error("headers from a trusted proxy were stripped")
+			}
+			if tt.header.Get("Content-Type") == "" {
+				t.Error("unrelated header was stripped")
+			}
+		})
+	}
+}
+
+func TestProxyTrustConfig(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		cfg     LDAPConfig
+		wantErr string
+	}{
+		{name: "invalid CIDR", cfg: LDAPConfig{HeaderPrefix: "x-remote-", TrustedProxies: []string{"10.0.0.1"}}, wantErr: "invalid trusted proxy"},
+		{name: "missing secret", cfg: LDAPConfig{HeaderPrefix: "x-remote-", HMACSecretFile: filepath.Join(dir, "missing")}, wantErr: "read HMAC secret"},
+		{name: "empty secret", cfg: LDAPConfig{HeaderPrefix: "x-remote-", HMACSecretFile: empty}, wantErr: "is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLDAPProvider(tt.cfg, nil, nil, testLogger())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewLDAPProvider() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope restricts a permission grant to an Ambari instance, a cluster and
+// optionally one service of it. A grant with neither Instance nor Cluster is
+// global; one with only Instance covers everything on that instance.
+type Scope struct {
+	Instance string
+	Cluster  string
+	Service  string
+}
+
+func (s Scope) String() string {
+	var out string
+	switch {
+	case s.Cluster == "" && s.Service == "":
+		out = "all clusters"
+	case s.Service == "":
+		out = "cluster=" + s.Cluster
+	default:
+		out = fmt.Sprintf("cluster=%s, service=%s", s.Cluster, s.Service)
+	}
+	if s.Instance != "" {
+		out = "instance=" + s.Instance + ", " + out
+	}
+	return out
+}
+
+// ParseGrant splits a grant of the form
+// <permission>[@[<instance>:]<cluster>[/<service>]], e.g.
+// "service:restart@prod-core/YARN" or "service:view@prod-east:analytics".
+// "*" as the cluster matches any cluster; "<permission>@<instance>:" grants
+// the permission on everything of one instance.
+func ParseGrant(value string) (Permission, Scope, error) {
+	perm, target, scoped := strings.Cut(strings.TrimSpace(value), "@")
+	if !isKnownPermission(Permission(perm)) {
+		return "", Scope{}, fmt.Errorf("unknown permission %q", value)
+	}
+	if !scoped {
+		return Permission(perm), Scope{}, nil
+	}
+	var scope Scope
+	if instance, rest, ok := strings.Cut(target, ":"); ok {
+		if instance == "" {
+			return "", Scope{}, fmt.Errorf("invalid scope in %q (expected <permission>@[<instance>:]<cluster>[/<service>])", value)
+		}
+		scope.Instance, target = instance, rest
+		if target == "" {
+			return Permission(perm), scope, nil
+		}
+	}
+	cluster, service, _ := strings.Cut(target, "/")
+	if cluster == "" || strings.Contains(service, "/") || strings.Contains(target, ":") {
+		return "", Scope{}, fmt.Errorf("invalid scope in %q (expected <permission>@[<instance>:]<cluster>[/<service>])", value)
+	}
+	scope.Cluster, scope.Service = cluster, service
+	return Permission(perm), scope, nil
+}
+
+// covers reports whether a grant with this scope applies to the target
+func (s Scope) covers(target Scope) bool {
+	if s.Instance != "" && s.Instance != target.Instance {
+		return false
+	}
+	if s.Cluster == "" {
+		return true
+	}
+	if target.Cluster == "" || (s.Cluster != "*" && s.Cluster != target.Cluster) {
+		return false
+	}
+	return s.Service == "" || strings.EqualFold(s.Service, target.Service)
+}
+
+// HasPermissionOn checks a permission against the instance, cluster and
+// service an operation targets. Global grants apply everywhere; scoped grants
+// only to their own instance, cluster and service.
+func (a *AuthContext) HasPermissionOn(perm Permission, target Scope) bool {
+	for _, granted := range a.Permissions {
+		p, scope, err := ParseGrant(string(granted))
+		if err == nil && p == perm && scope.covers(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermissionAnywhere reports whether the permission is granted on any
+// scope, for operations that check each of their targets themselves
+func (a *AuthContext) HasPermissionAnywhere(perm Permission) bool {
+	for _, granted := range a.Permissions {
+		p, _, err := ParseGrant(string(granted))
+		if err == nil && p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermissionOnInstance reports whether the permission is granted on some
+// scope of the instance: globally, on the instance or on one of its clusters
+func (a *AuthContext) HasPermissionOnInstance(perm Permission, instance string) bool {
+	for _, granted := range a.Permissions {
+		p, scope, err := ParseGrant(string(granted))
+		if err == nil && p == perm && (scope.Instance == "" || scope.Instance == instance) {
+			return true
+		}
+	}
+	return false
+}
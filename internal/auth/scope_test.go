@@ -0,0 +1,107 @@
+package auth
+
+import "testing"
+
+func TestParseGrant(t *testing.T) {
+	tests := []struct {
+		grant     string
+		wantPerm  Permission
+		wantScope Scope
+		wantErr   bool
+	}{
+		{grant: "cluster:view", wantPerm: ClusterView},
+		{grant: " host:view ", wantPerm: HostView},
+		{grant: "service:restart@prod-core", wantPerm: ServiceRestart, wantScope: Scope{Cluster: "prod-core"}},
+		{grant: "service:restart@prod-core/YARN", wantPerm: ServiceRestart, wantScope: Scope{Cluster: "prod-core", Service: "YARN"}},
+		{grant: "alert:view@*", wantPerm: AlertView, wantScope: Scope{Cluster: "*"}},
+		{grant: "service:view@prod-east:analytics", wantPerm: ServiceView, wantScope: Scope{Instance: "prod-east", Cluster: "analytics"}},
+		{grant: "service:view@prod-east:analytics/HDFS", wantPerm: ServiceView, wantScope: Scope{Instance: "prod-east", Cluster: "analytics", Service: "HDFS"}},
+		{grant: "cluster:admin@prod-east:", wantPerm: ClusterAdmin, wantScope: Scope{Instance: "prod-east"}},
+		{grant: "cluster:view@:c1", wantErr: true},
+		{grant: "cluster:view@a:b:c", wantErr: true},
+		{grant: "cluster:fly", wantErr: true},
+		{grant: "cluster:view@", wantErr: true},
+		{grant: "cluster:view@/HDFS", wantErr: true},
+		{grant: "service:view@c1/HDFS/extra", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.grant, func(t *testing.T) {
+			perm, scope, err := ParseGrant(tt.grant)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseGrant(%q) = %s, %v, want error", tt.grant, perm, scope)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseGrant(%q) error = %v", tt.grant, err)
+			}
+			if perm != tt.wantPerm || scope != tt.wantScope {
+				t.Errorf("ParseGrant(%q) = %s, %v, want %s, %v", tt.grant, perm, scope, tt.wantPerm, tt.wantScope)
+			}
+		})
+	}
+}
+
+func TestHasPermissionOn(t *testing.T) {
+	tests := []struct {
+		name   string
+		grants []Permission
+		perm   Permission
+		target Scope
+		want   bool
+	}{
+		{"global grant on cluster", []Permission{ServiceView}, ServiceView, Scope{Cluster: "c1"}, true},
+		{"global grant without target", []Permission{ServiceView}, ServiceView, Scope{}, true},
+		{"other permission", []Permission{ServiceView}, ServiceAdmin, Scope{Cluster: "c1"}, false},
+		{"cluster grant on its cluster", []Permission{"service:view@c1"}, ServiceView, Scope{Cluster: "c1"}, true},
+		{"cluster grant on its service", []Permission{"service:view@c1"}, ServiceView, Scope{Cluster: "c1", Service: "HDFS"}, true},
+		{"cluster grant on other cluster", []Permission{"service:view@c1"}, ServiceView, Scope{Cluster: "c2"}, false},
+		{"cluster grant without target", []Permission{"cluster:admin@analytics"}, ClusterAdmin, Scope{}, false},
+		{"wildcard grant on any cluster", []Permission{"service:view@*"}, ServiceView, Scope{Cluster: "c2"}, true},
+		{"wildcard grant without target", []Permission{"host:view@*"}, HostView, Scope{}, false},
+		{"service grant on its service", []Permission{"service:restart@c1/YARN"}, ServiceRestart, Scope{Cluster: "c1", Service: "yarn"}, true},
+		{"service grant on other service", []Permission{"service:restart@c1/YARN"}, ServiceRestart, Scope{Cluster: "c1", Service: "HDFS"}, false},
+		{"service grant on whole cluster", []Permission{"service:restart@c1/YARN"}, ServiceRestart, Scope{Cluster: "c1"}, false},
+		{"instance grant on its instance", []Permission{"cluster:admin@east:"}, ClusterAdmin, Scope{Instance: "east"}, true},
+		{"instance grant on its cluster", []Permission{"cluster:admin@east:"}, ClusterAdmin, Scope{Instance: "east", Cluster: "c1"}, true},
+		{"instance grant on other instance", []Permission{"cluster:admin@east:"}, ClusterAdmin, Scope{Instance: "west"}, false},
+		{"instance cluster grant on its cluster", []Permission{"service:view@east:c1"}, ServiceView, Scope{Instance: "east", Cluster: "c1"}, true},
+		{"instance cluster grant on same cluster elsewhere", []Permission{"service:view@east:c1"}, ServiceView, Scope{Instance: "west", Cluster: "c1"}, false},
+		{"instance cluster grant without target", []Permission{"service:view@east:c1"}, ServiceView, Scope{Instance: "east"}, false},
+		{"cluster grant on any instance", []Permission{"service:view@c1"}, ServiceView, Scope{Instance: "west", Cluster: "c1"}, true},
+		{"invalid grant ignored", []Permission{"service:view@"}, ServiceView, Scope{Cluster: "c1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &AuthContext{Permissions: tt.grants}
+			if got := a.HasPermissionOn(tt.perm, tt.target); got != tt.want {
+				t.Errorf("HasPermissionOn(%s, %v) = %v, want %v", tt.perm, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasPermissionOnInstance(t *testing.T) {
+	tests := []struct {
+		name     string
+		grants   []Permission
+		instance string
+		want     bool
+	}{
+		{"global grant", []Permission{AlertView}, "east", true},
+		{"cluster grant", []Permission{"alert:view@c1"}, "east", true},
+		{"instance grant", []Permission{"alert:view@east:"}, "east", true},
+		{"instance cluster grant", []Permission{"alert:view@east:c1"}, "east", true},
+		{"grant on other instance", []Permission{"alert:view@west:c1"}, "east", false},
+		{"other permission", []Permission{"service:view@east:"}, "east", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &AuthContext{Permissions: tt.grants}
+			if got := a.HasPermissionOnInstance(AlertView, tt.instance); got != tt.want {
+				t.Errorf("HasPermissionOnInstance(%s, %s) = %v, want %v", AlertView, tt.instance, got, tt.want)
+			}
+		})
+	}
+}
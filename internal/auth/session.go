@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+// IdentityHeader carries a handle to the caller's AuthContext from the
+// Middleware to tool and resource handlers.
+//
+// Handlers do not run on the HTTP request context, so values stored with
+// WithAuthContext never reach them; of the request they only see its headers,
+// as RequestExtra.Header. The Middleware therefore registers each request's
+// AuthContext under a random handle, valid while the request is served, and
+// overwrites this header with it.
+const IdentityHeader = "X-Mcp-Ambari-Identity"
+
+// sessionIDHeader is the streamable HTTP transport's session header
+const sessionIDHeader = "Mcp-Session-Id"
+
+// identities maps the handles of requests being served to their identity
+var identities sync.Map
+
+// identity is an AuthContext registered under a handle, with the session of
+// the request, if it has one yet
+type identity struct {
+	authCtx *AuthContext
+	session string
+}
+
+// sessionBinder attaches identities to requests and keeps each MCP session
+// to the user that created it, so a session ID cannot be used by another
+// caller. Sessions are forgotten when the client deletes them or, like in the
+// MCP handler, after timeout without requests; a zero timeout keeps them.
+type sessionBinder struct {
+	mu       sync.Mutex
+	sessions map[string]*boundSession
+	timeout  time.Duration
+	swept    time.Time
+	logger   *logrus.Logger
+}
+
+// boundSession is the owner of a session and its activity. The idle time is
+// counted from the end of the last request, so a session is never forgotten
+// before the MCP handler closes it.
+type boundSession struct {
+	owner    string
+	lastSeen time.Time
+	active   int
+}
+
+func newSessionBinder(timeout time.Duration, logger *logrus.Logger) *sessionBinder {
+	return &sessionBinder{sessions: make(map[string]*boundSession), timeout: timeout, logger: logger}
+}
+
+// serve passes r on to next with authCtx attached. Requests of a session
+// created by another user are refused.
+func (b *sessionBinder) serve(w http.ResponseWriter, r *http.Request, authCtx *AuthContext, next http.Handler) {
+	handle := newHandle()
+	sessionID := r.Header.Get(sessionIDHeader)
+	bound := sessionID
+	if sessionID == "" {
+		// A new session's ID is in the response headers
+		w = &sessionRecorder{ResponseWriter: w, bind: func(id string) {
+			if b.acquire(id, authCtx.Username) {
+				bound = id
+				identities.Store(handle, identity{authCtx: authCtx, session: id})
+			}
+		}}
+	} else if !b.acquire(sessionID, authCtx.Username) {
+		b.logger.WithFields(logrus.Fields{"user": authCtx.Username}).Warn("Rejected request for another user's session")
+		http.Error(w, "session belongs to another user", http.StatusForbidden)
+		return
+	}
+	defer func() {
+		if bound != "" {
+			b.release(bound)
+		}
+	}()
+
+	identities.Store(handle, identity{authCtx: authCtx, session: sessionID})
+	defer identities.Delete(handle)
+
+	r = r.Clone(r.Context())
+	r.Header.Set(IdentityHeader, handle)
+	next.ServeHTTP(w, r)
+
+	if r.Method == http.MethodDelete && sessionID != "" {
+		b.mu.Lock()
+		b.forget(sessionID)
+		b.mu.Unlock()
+		bound = ""
+	}
+}
+
+// acquire records username as the owner of session id unless it has one, and
+// reports whether username owns it. A request of the owner keeps the session
+// alive until the matching release.
+func (b *sessionBinder) acquire(id, username string) bool {
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sweep(now)
+	s, ok := b.sessions[id]
+	if !ok {
+		s = &boundSession{owner: username}
+		b.sessions[id] = s
+	} else if s.owner != username {
+		return false
+	}
+	s.active++
+	s.lastSeen = now
+	return true
+}
+
+// release ends a request of session id
+func (b *sessionBinder) release(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if s, ok := b.sessions[id]; ok {
+		s.active--
+		s.lastSeen = time.Now()
+	}
+}
+
+// sweep forgets sessions idle for timeout, at most once per minute (or per
+// timeout, when shorter). b.mu must be held.
+func (b *sessionBinder) sweep(now time.Time) {
+	if b.timeout <= 0 || now.Sub(b.swept) < min(b.timeout, time.Minute) {
+		return
+	}
+	b.swept = now
+	for id, s := range b.sessions {
+		if s.active == 0 && now.Sub(s.lastSeen) >= b.timeout {
+			b.forget(id)
+			b.logger.WithFields(logrus.Fields{"user": s.owner}).Debug("Forgot idle session")
+		}
+	}
+}
+
+// forget drops session id and any identity still registered for it. b.mu
+// must be held.
+func (b *sessionBinder) forget(id string) {
+	delete(b.sessions, id)
+	identities.Range(func(handle, v interface{}) bool {
+		if v.(identity).session == id {
+			identities.Delete(handle)
+		}
+		return true
+	})
+}
+
+// sessionRecorder calls bind with the session ID the response assigns, when
+// its headers are written
+type sessionRecorder struct {
+	http.ResponseWriter
+	bind     func(id string)
+	recorded bool
+}
+
+func (s *sessionRecorder) record() {
+	if s.recorded {
+		return
+	}
+	s.recorded = true
+	if id := s.Header().Get(sessionIDHeader); id != "" {
+		s.bind(id)
+	}
+}
+
+func (s *sessionRecorder) WriteHeader(code int) {
+	s.record()
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *sessionRecorder) Write(p []byte) (int, error) {
+	s.record()
+	return s.ResponseWriter.Write(p)
+}
+
+func (s *sessionRecorder) Flush() {
+	s.record()
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *sessionRecorder) Unwrap() http.ResponseWriter { return s.ResponseWriter }
+
+func newHandle() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("read random identity handle: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// FromRequest resolves the caller identity for an MCP request. HTTP transports
+// attach it with IdentityHeader; in-process callers may use WithAuthContext.
+func FromRequest(ctx context.Context, extra *mcp.RequestExtra) (*AuthContext, bool) {
+	if extra != nil && extra.Header != nil {
+		if id, ok := identities.Load(extra.Header.Get(IdentityHeader)); ok {
+			return id.(identity).authCtx, true
+		}
+	}
+	return GetAuthContext(ctx)
+}
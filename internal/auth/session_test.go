@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// headerProvider authenticates the user named by the X-User header
+type headerProvider struct{}
+
+func (headerProvider) Name() string { return "header" }
+func (headerProvider) Authenticate(_ context.Context, headers map[string]string) (*AuthContext, error) {
+	if headers["x-user"] == "" {
+		return nil, errors.New("no user")
+	}
+	return &AuthContext{Username: headers["x-user"]}, nil
+}
+
+// asUser adds X-User, and any extra headers, to every request
+type asUser struct {
+	user  string
+	extra map[string]string
+}
+
+func (u asUser) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("X-User", u.user)
+	for k, v := range u.extra {
+		r.Header.Set(k, v)
+	}
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// whoamiServer serves an MCP server whose whoami tool returns the identity
+// the call resolves to. handles receives the identity handle of each call.
+func whoamiServer(t *testing.T, handles chan<- string) *httptest.Server {
+	t.Helper()
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1"}, nil)
+	server.AddTool(&mcp.Tool{Name: "whoami", InputSchema: &jsonschema.Schema{Type: "object"}}, func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if handles != nil {
+			handles <- req.Extra.Header.Get(IdentityHeader)
+		}
+		authCtx, ok := FromRequest(ctx, req.Extra)
+		if !ok {
+			return nil, errors.New("no identity")
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: authCtx.Username}}}, nil
+	})
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil)
+	srv := httptest.NewServer(NewMiddleware(headerProvider{}, true, time.Minute, testLogger()).Handler(handler))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func connect(t *testing.T, url string, rt http.RoundTripper) *mcp.ClientSession {
+	t.Helper()
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1"}, nil)
+	cs, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{Endpoint: url, HTTPClient: &http.Client{Transport: rt}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs
+}
+
+func whoami(t *testing.T, cs *mcp.ClientSession) string {
+	t.Helper()
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "whoami"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("whoami failed: %v", res.Content)
+	}
+	return res.Content[0].(*mcp.TextContent).Text
+}
+
+func TestSessionIdentity(t *testing.T) {
+	handles := make(chan string, 10)
+	srv := whoamiServer(t, handles)
+
+	alice := connect(t, srv.URL, asUser{user: "alice"})
+	bob := connect(t, srv.URL, asUser{user: "bob"})
+	if got := whoami(t, alice); got != "alice" {
+		t.Errorf("alice's call ran as %q", got)
+	}
+	if got := whoami(t, bob); got != "bob" {
+		t.Errorf("bob's call ran as %q", got)
+	}
+
+	// Handles are only valid while their request is served
+	first := <-handles
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, ok := identities.Load(first); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("identity handle still valid after its request was served")
+		}
+	}
+	<-handles
+
+	// A client-supplied handle, even a formerly valid one, is replaced by
+	// the middleware's
+	forged := connect(t, srv.URL, asUser{user: "mallory", extra: map[string]string{IdentityHeader: first}})
+	if got := whoami(t, forged); got != "mallory" {
+		t.Errorf("call with a forged identity header ran as %q", got)
+	}
+	if h := <-handles; h == first {
+		t.Error("forged identity header reached the handler")
+	}
+}
+
+func TestSessionCannotBeReusedByAnotherUser(t *testing.T) {
+	srv := whoamiServer(t, nil)
+	alice := connect(t, srv.URL, asUser{user: "alice"})
+	whoami(t, alice)
+
+	call := `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"whoami","arguments":{}}}`
+	post := func(user string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(call))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		req.Header.Set("Mcp-Session-Id", alice.ID())
+		req.Header.Set("Mcp-Protocol-Version", "2025-06-18")
+		req.Header.Set("X-User", user)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	if resp := post("mallory"); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("mallory's request on alice's session: status %d, want 403", resp.StatusCode)
+	}
+	if resp := post("alice"); resp.StatusCode != http.StatusOK {
+		t.Errorf("alice's request on her session: status %d, want 200", resp.StatusCode)
+	}
+	if got := whoami(t, alice); got != "alice" {
+		t.Errorf("alice's call ran as %q after the rejected request", got)
+	}
+}
+
+func TestSessionBinderExpiry(t *testing.T) {
+	tests := []struct {
+		name       string
+		timeout    time.Duration
+		idle       time.Duration
+		active     bool
+		wantKept   bool
+		wantHandle bool
+	}{
+		{name: "idle session forgotten", timeout: time.Minute, idle: 2 * time.Minute},
+		{name: "recent session kept", timeout: time.Minute, idle: 30 * time.Second, wantKept: true, wantHandle: true},
+		{name: "session with a request in flight kept", timeout: time.Minute, idle: 2 * time.Minute, active: true, wantKept: true, wantHandle: true},
+		{name: "no timeout", idle: 24 * time.Hour, wantKept: true, wantHandle: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newSessionBinder(tt.timeout, testLogger())
+			if !b.acquire("s1", "alice") {
+				t.Fatal("alice could not create her session")
+			}
+			if !tt.active {
+				b.release("s1")
+			}
+			handle := newHandle()
+			identities.Store(handle, identity{authCtx: &AuthContext{Username: "alice"}, session: "s1"})
+			defer identities.Delete(handle)
+
+			// Age the session and let the next request sweep
+			b.mu.Lock()
+			b.sessions["s1"].lastSeen = b.sessions["s1"].lastSeen.Add(-tt.idle)
+			b.swept = time.Time{}
+			b.mu.Unlock()
+			b.acquire("s2", "bob")
+
+			b.mu.Lock()
+			_, kept := b.sessions["s1"]
+			b.mu.Unlock()
+			if kept != tt.wantKept {
+				t.Errorf("session kept = %v, want %v", kept, tt.wantKept)
+			}
+			if _, ok := identities.Load(handle); ok != tt.wantHandle {
+				t.Errorf("identity of the session kept = %v, want %v", ok, tt.wantHandle)
+			}
+			if claimed := b.acquire("s1", "mallory"); claimed == tt.wantKept {
+				t.Errorf("another user claiming the session: %v", claimed)
+			}
+		})
+	}
+}
+
+func TestSessionDeleteForgetsOwner(t *testing.T) {
+	b := newSessionBinder(time.Minute, testLogger())
+	alice := &AuthContext{Username: "alice"}
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	request := func(method string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/", nil)
+		r.Header.Set(sessionIDHeader, "s1")
+		w := httptest.NewRecorder()
+		b.serve(w, r, alice, noop)
+		return w
+	}
+
+	request(http.MethodPost)
+	b.mu.Lock()
+	s, ok := b.sessions["s1"]
+	b.mu.Unlock()
+	if !ok || s.owner != "alice" || s.active != 0 {
+		t.Fatalf("session after a served request = %+v, want alice's with none in flight", s)
+	}
+	request(http.MethodDelete)
+	b.mu.Lock()
+	_, ok = b.sessions["s1"]
+	b.mu.Unlock()
+	if ok {
+		t.Error("deleted session is still bound")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"math/big"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"time"
@@ -34,6 +35,11 @@ type CertConfig struct {
 	DNSNames     []string
 	IPAddresses  []net.IP
 	IsServer     bool // true for server cert, false for client cert
+
+	// Client identity, read by auth.MTLSProvider
+	OrganizationalUnits []string   // groups when groups are taken from OU
+	EmailAddresses      []string   // email SAN, usable as username
+	URIs                []*url.URL // URI SAN (e.g. SPIFFE ID), usable as username
 }
 
 // CAResult holds the generated CA certificate and private key
@@ -147,6 +153,9 @@ func GenerateCertificate(config CertConfig, ca *CAResult) (*CertResult, error) {
 		template.IPAddresses = config.IPAddresses
 	} else {
 		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		template.Subject.OrganizationalUnit = config.OrganizationalUnits
+		template.EmailAddresses = config.EmailAddresses
+		template.URIs = config.URIs
 	}
 
 	// Sign the certificate with CA
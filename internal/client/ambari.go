@@ -28,35 +28,87 @@ type Config struct {
 	Username string
 	Password string
 	Timeout  time.Duration
-	Retries  int
+	// Retries of failed idempotent requests; see retryDelay for what is retried
+	Retries int
+	// Auth authenticates the service identity; nil means basic auth with Username/Password
+	Auth Authenticator
+	TLS  TLSConfig
+	// Breaker stops requests to a failing server; MaxConcurrent bounds the
+	// requests in flight to it (default DefaultMaxConcurrent)
+	Breaker       BreakerConfig
+	MaxConcurrent int
+}
+
+// Credentials authenticate a single request as a specific Ambari user instead
+// of the client's service account. Token, when set, is sent as the TokenCookie
+// cookie (Ambari JWT SSO); otherwise Username/Password are used for basic auth.
+type Credentials struct {
+	Username    string
+	Password    string
+	Token       string
+	TokenCookie string
+}
+
+type credentialsKey struct{}
+
+// WithCredentials returns a context whose requests use creds
+func WithCredentials(ctx context.Context, creds Credentials) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, creds)
+}
+
+// CredentialsFromContext returns per-request credentials, if any
+func CredentialsFromContext(ctx context.Context) (Credentials, bool) {
+	creds, ok := ctx.Value(credentialsKey{}).(Credentials)
+	return creds, ok
 }
 
 type ambariClient struct {
 	baseURL    string
-	username   string
-	password   string
+	auth       Authenticator
 	httpClient *http.Client
 	retries    int
+	breaker    *breaker
+	limiter    *limiter
 	logger     *logrus.Logger
 }
 
 // NewAmbariClient creates a new Ambari HTTP client with connection pooling
-func NewAmbariClient(cfg Config, logger *logrus.Logger) AmbariClient {
+func NewAmbariClient(cfg Config, logger *logrus.Logger) (AmbariClient, error) {
+	auth := cfg.Auth
+	if auth == nil {
+		auth = &BasicAuthenticator{Username: cfg.Username, Password: cfg.Password}
+	}
+	tlsConfig, err := cfg.TLS.build()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.TLS.InsecureSkipVerify {
+		logger.WithField("url", cfg.BaseURL).Warn("TLS certificate verification for Ambari is DISABLED (AMBARI_TLS_INSECURE_SKIP_VERIFY); connections can be intercepted")
+	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	}
+	breaker, err := cfg.Breaker.build(cfg.BaseURL, logger)
+	if err != nil {
+		return nil, err
+	}
 	return &ambariClient{
-		baseURL:  cfg.BaseURL,
-		username: cfg.Username,
-		password: cfg.Password,
-		retries:  cfg.Retries,
-		logger:   logger,
+		baseURL: cfg.BaseURL,
+		auth:    auth,
+		retries: cfg.Retries,
+		breaker: breaker,
+		limiter: newLimiter(cfg.MaxConcurrent),
+		logger:  logger,
 		httpClient: &http.Client{
 			Timeout: cfg.Timeout,
 			Transport: &http.Transport{
 				MaxIdleConns:        100,
 				MaxIdleConnsPerHost: 10,
 				IdleConnTimeout:     90 * time.Second,
+				TLSClientConfig:     tlsConfig,
 			},
 		},
-	}
+	}, nil
 }
 
 func (c *ambariClient) Get(ctx context.Context, path string, params map[string]string) (map[string]interface{}, error) {
@@ -75,25 +127,53 @@ func (c *ambariClient) Delete(ctx context.Context, path string, params map[strin
 	return c.doRequest(ctx, "DELETE", path, params, nil)
 }
 
+type requestIDKey struct{}
+
 func (c *ambariClient) doRequest(ctx context.Context, method, path string, params map[string]string, body interface{}) (map[string]interface{}, error) {
-	var lastErr error
-	for attempt := 0; attempt <= c.retries; attempt++ {
-		result, err := c.execute(ctx, method, path, params, body)
+	// All attempts of a call share one X-Request-ID
+	requestID := newRequestID()
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	for attempt := 0; ; attempt++ {
+		result, err := c.attempt(ctx, method, path, params, body)
 		if err == nil {
 			return result, nil
 		}
-		lastErr = err
-		if attempt < c.retries {
-			backoff := time.Duration(attempt+1) * 100 * time.Millisecond
-			c.logger.WithFields(logrus.Fields{"attempt": attempt + 1, "method": method, "path": path}).Warn("Retrying")
-			select {
-			case <-time.After(backoff):
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			}
+		if attempt >= c.retries {
+			return nil, attempts(attempt+1, err)
+		}
+		backoff, stop := c.retryDelay(ctx, method, path, attempt, err)
+		if stop != nil {
+			return nil, attempts(attempt+1, stop)
+		}
+		c.logger.WithFields(logrus.Fields{"attempt": attempt + 1, "method": method, "path": path, "request_id": requestID, "backoff": backoff.String(), "error": err.Error()}).Warn("Retrying")
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		}
 	}
-	return nil, fmt.Errorf("request failed after %d attempts: %w", c.retries+1, lastErr)
+}
+
+// attempt sends one request, waiting for a free slot of the concurrency
+// limiter and failing fast while the circuit breaker is open
+func (c *ambariClient) attempt(ctx context.Context, method, path string, params map[string]string, body interface{}) (map[string]interface{}, error) {
+	if err := c.limiter.acquire(ctx); err != nil {
+		return nil, fmt.Errorf("waiting for a free Ambari request slot: %w", err)
+	}
+	defer c.limiter.release()
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	result, err := c.execute(ctx, method, path, params, body)
+	c.breaker.record(err)
+	return result, err
+}
+
+func attempts(n int, err error) error {
+	if n == 1 {
+		return err
+	}
+	return fmt.Errorf("request failed after %d attempts: %w", n, err)
 }
 
 func (c *ambariClient) execute(ctx context.Context, method, path string, params map[string]string, body interface{}) (map[string]interface{}, error) {
@@ -105,40 +185,51 @@ func (c *ambariClient) execute(ctx context.Context, method, path string, params
 	if len(params) > 0 {
 		q := reqURL.Query()
 		for k, v := range params {
-			q.Set(k, v)
+			if k != PredicateParam {
+				q.Set(k, v)
+			}
 		}
 		reqURL.RawQuery = q.Encode()
+		// Predicates are already encoded by Predicate.String
+		if pred := params[PredicateParam]; pred != "" {
+			if reqURL.RawQuery != "" {
+				reqURL.RawQuery += "&"
+			}
+			reqURL.RawQuery += pred
+		}
 	}
 
-	var bodyReader io.Reader
+	var payload []byte
 	if body != nil {
-		b, err := json.Marshal(body)
+		payload, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("marshal body: %w", err)
 		}
-		bodyReader = bytes.NewReader(b)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), bodyReader)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-	req.SetBasicAuth(c.username, c.password)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Requested-By", "mcp-ambari")
-
 	start := time.Now()
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(ctx, method, reqURL.String(), payload)
+	if err == nil {
+		// A rejected session is retried once with full authentication
+		if observer, ok := c.auth.(ResponseObserver); ok && !hasCredentials(ctx) && observer.Observe(resp) {
+			resp.Body.Close()
+			c.logger.WithFields(logrus.Fields{"method": method, "path": path, "request_id": requestIDFrom(ctx)}).Debug("Ambari session expired; re-authenticating")
+			resp, err = c.send(ctx, method, reqURL.String(), payload)
+			if err == nil {
+				observer.Observe(resp)
+			}
+		}
+	}
 	dur := time.Since(start)
 	if err != nil {
-		c.logger.WithFields(logrus.Fields{"method": method, "url": reqURL.String(), "duration": dur}).Error("Request failed")
+		c.logger.WithFields(logrus.Fields{"method": method, "url": reqURL.String(), "request_id": requestIDFrom(ctx), "duration": dur}).Error("Request failed")
 		return nil, fmt.Errorf("HTTP %s %s failed: %w", method, path, err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return nil, fmt.Errorf("read response: %w", newTransportError(err))
 	}
 
 	var result map[string]interface{}
@@ -148,10 +239,52 @@ func (c *ambariClient) execute(ctx context.Context, method, path string, params
 		}
 	}
 
-	c.logger.WithFields(logrus.Fields{"method": method, "path": path, "status": resp.StatusCode, "duration": dur}).Debug("Request done")
+	c.logger.WithFields(logrus.Fields{"method": method, "path": path, "request_id": requestIDFrom(ctx), "status": resp.StatusCode, "duration": dur}).Debug("Request done")
 
 	if resp.StatusCode >= 400 {
-		return result, fmt.Errorf("HTTP %d from %s %s", resp.StatusCode, method, path)
+		return result, newAmbariError(resp.StatusCode, method, path, requestIDFrom(ctx), result, resp.Header)
 	}
 	return result, nil
 }
+
+// send issues one request, authenticated with the caller's credentials from
+// ctx when present and with the service authenticator otherwise
+func (c *ambariClient) send(ctx context.Context, method, reqURL string, payload []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if payload != nil {
+		bodyReader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if creds, ok := CredentialsFromContext(ctx); ok {
+		if creds.Token != "" {
+			req.AddCookie(&http.Cookie{Name: creds.TokenCookie, Value: creds.Token})
+		} else {
+			req.SetBasicAuth(creds.Username, creds.Password)
+		}
+	} else if err := c.auth.Authenticate(req); err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Requested-By", "mcp-ambari")
+	if id := requestIDFrom(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, newTransportError(err)
+	}
+	return resp, nil
+}
+
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func hasCredentials(ctx context.Context) bool {
+	_, ok := CredentialsFromContext(ctx)
+	return ok
+}
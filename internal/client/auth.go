@@ -0,0 +1,194 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	krbclient "github.com/jcmturner/gokrb5/v8/client"
+	krbconfig "github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+// Authenticator is the Strategy used to authenticate requests made with the
+// client's own (service) identity
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+	Name() string
+}
+
+// ResponseObserver is implemented by authenticators that keep state from
+// responses. Observe returns true when the request should be sent once more,
+// e.g. because a reused session has expired.
+type ResponseObserver interface {
+	Observe(resp *http.Response) (retry bool)
+}
+
+// Authentication modes for AuthConfig
+const (
+	AuthBasic  = "basic"
+	AuthSPNEGO = "spnego"
+)
+
+// AuthConfig selects how the client authenticates to Ambari
+type AuthConfig struct {
+	Mode string `json:"mode"`
+	// SessionReuse sends the AMBARISESSIONID cookie from an earlier response
+	// instead of authenticating every request
+	SessionReuse bool           `json:"session_reuse"`
+	Kerberos     KerberosConfig `json:"kerberos"`
+}
+
+// KerberosConfig configures SPNEGO with a keytab
+type KerberosConfig struct {
+	Krb5Conf  string `json:"krb5_conf"`
+	Keytab    string `json:"keytab"`
+	Principal string `json:"principal"` // user@REALM
+	SPN       string `json:"spn"`       // defaults to HTTP/<ambari host>
+}
+
+// NewAuthenticator creates the authenticator for cfg (Factory pattern)
+func NewAuthenticator(cfg AuthConfig, username, password string) (Authenticator, error) {
+	var auth Authenticator
+	switch strings.ToLower(cfg.Mode) {
+	case "", AuthBasic:
+		auth = &BasicAuthenticator{Username: username, Password: password}
+	case AuthSPNEGO:
+		negotiator, err := NewKerberosNegotiator(cfg.Kerberos)
+		if err != nil {
+			return nil, err
+		}
+		auth = NewSPNEGOAuthenticator(negotiator, cfg.Kerberos.SPN)
+	default:
+		return nil, fmt.Errorf("unknown Ambari auth mode %q (supported: basic, spnego)", cfg.Mode)
+	}
+	if cfg.SessionReuse {
+		auth = NewSessionAuthenticator(auth)
+	}
+	return auth, nil
+}
+
+// BasicAuthenticator sends HTTP basic credentials
+type BasicAuthenticator struct {
+	Username string
+	Password string
+}
+
+func (a *BasicAuthenticator) Name() string { return AuthBasic }
+
+func (a *BasicAuthenticator) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// Negotiator produces the SPNEGO Authorization header for a request. It is
+// separate from SPNEGOAuthenticator so a stand-in can replace the KDC.
+type Negotiator interface {
+	SetSPNEGOHeader(req *http.Request, spn string) error
+}
+
+// SPNEGOAuthenticator authenticates with Kerberos (Authorization: Negotiate)
+type SPNEGOAuthenticator struct {
+	negotiator Negotiator
+	spn        string
+}
+
+// NewSPNEGOAuthenticator creates a SPNEGO authenticator. An empty spn is
+// derived from the request host.
+func NewSPNEGOAuthenticator(negotiator Negotiator, spn string) *SPNEGOAuthenticator {
+	return &SPNEGOAuthenticator{negotiator: negotiator, spn: spn}
+}
+
+func (a *SPNEGOAuthenticator) Name() string { return AuthSPNEGO }
+
+func (a *SPNEGOAuthenticator) Authenticate(req *http.Request) error {
+	if err := a.negotiator.SetSPNEGOHeader(req, a.spn); err != nil {
+		return fmt.Errorf("spnego: %w", err)
+	}
+	return nil
+}
+
+// kerberosNegotiator obtains service tickets from the KDC with a keytab
+type kerberosNegotiator struct {
+	client *krbclient.Client
+}
+
+// NewKerberosNegotiator logs in to the KDC configured in krb5.conf with the keytab
+func NewKerberosNegotiator(cfg KerberosConfig) (Negotiator, error) {
+	if cfg.Krb5Conf == "" || cfg.Keytab == "" || cfg.Principal == "" {
+		return nil, fmt.Errorf("spnego requires krb5.conf, keytab and principal")
+	}
+	username, realm, ok := strings.Cut(cfg.Principal, "@")
+	if !ok {
+		return nil, fmt.Errorf("kerberos principal %q must be user@REALM", cfg.Principal)
+	}
+	krb5, err := krbconfig.Load(cfg.Krb5Conf)
+	if err != nil {
+		return nil, fmt.Errorf("load krb5.conf %s: %w", cfg.Krb5Conf, err)
+	}
+	kt, err := keytab.Load(cfg.Keytab)
+	if err != nil {
+		return nil, fmt.Errorf("load keytab %s: %w", cfg.Keytab, err)
+	}
+	cl := krbclient.NewWithKeytab(username, realm, kt, krb5, krbclient.DisablePAFXFAST(true))
+	if err := cl.Login(); err != nil {
+		return nil, fmt.Errorf("kerberos login as %s: %w", cfg.Principal, err)
+	}
+	return &kerberosNegotiator{client: cl}, nil
+}
+
+func (n *kerberosNegotiator) SetSPNEGOHeader(req *http.Request, spn string) error {
+	return spnego.SetSPNEGOHeader(n.client, req, spn)
+}
+
+// SessionCookie is the cookie Ambari issues for an authenticated session
+const SessionCookie = "AMBARISESSIONID"
+
+// SessionAuthenticator reuses Ambari's session cookie so the inner
+// authenticator (e.g. a SPNEGO round trip) only runs when there is no valid session.
+type SessionAuthenticator struct {
+	inner  Authenticator
+	mu     sync.Mutex
+	cookie *http.Cookie
+}
+
+// NewSessionAuthenticator wraps inner with session reuse
+func NewSessionAuthenticator(inner Authenticator) *SessionAuthenticator {
+	return &SessionAuthenticator{inner: inner}
+}
+
+func (a *SessionAuthenticator) Name() string { return a.inner.Name() + "+session" }
+
+func (a *SessionAuthenticator) Authenticate(req *http.Request) error {
+	a.mu.Lock()
+	cookie := a.cookie
+	a.mu.Unlock()
+	if cookie != nil {
+		req.AddCookie(cookie)
+		return nil
+	}
+	return a.inner.Authenticate(req)
+}
+
+// Observe stores a newly issued session cookie and drops a rejected one, asking
+// for a retry with full authentication
+func (a *SessionAuthenticator) Observe(resp *http.Response) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		sent, err := resp.Request.Cookie(SessionCookie)
+		if err == nil && a.cookie != nil && sent.Value == a.cookie.Value {
+			a.cookie = nil
+			return true
+		}
+		return false
+	}
+	for _, c := range resp.Cookies() {
+		if c.Name == SessionCookie && c.Value != "" {
+			a.cookie = &http.Cookie{Name: c.Name, Value: c.Value}
+		}
+	}
+	return false
+}
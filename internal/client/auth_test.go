@@ -0,0 +1,225 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// fakeNegotiator stands in for the KDC: every negotiation yields a new token
+type fakeNegotiator struct {
+	mu    sync.Mutex
+	calls int
+	spns  []string
+	err   error
+}
+
+func (n *fakeNegotiator) SetSPNEGOHeader(req *http.Request, spn string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err != nil {
+		return n.err
+	}
+	n.calls++
+	n.spns = append(n.spns, spn)
+	req.Header.Set("Authorization", fmt.Sprintf("Negotiate token-%d", n.calls))
+	return nil
+}
+
+func (n *fakeNegotiator) negotiations() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.calls
+}
+
+// fakeAmbari accepts Negotiate tokens, issues a session cookie for each and
+// accepts the current session until it is expired
+type fakeAmbari struct {
+	mu       sync.Mutex
+	sessions int
+	current  string
+	// seen records how each request authenticated: a token or a session
+	seen []string
+}
+
+func (a *fakeAmbari) expire() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.current = ""
+}
+
+func (a *fakeAmbari) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if c, err := r.Cookie(SessionCookie); err == nil {
+		a.seen = append(a.seen, "session "+c.Value)
+		if c.Value != a.current {
+			http.Error(w, `{"status":401,"message":"session expired"}`, http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+		return
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Negotiate ")
+	if !ok {
+		a.seen = append(a.seen, "anonymous "+r.Header.Get("Authorization"))
+		w.Header().Set("WWW-Authenticate", "Negotiate")
+		http.Error(w, `{"status":401}`, http.StatusUnauthorized)
+		return
+	}
+	a.seen = append(a.seen, token)
+	a.sessions++
+	a.current = fmt.Sprintf("s%d", a.sessions)
+	http.SetCookie(w, &http.Cookie{Name: SessionCookie, Value: a.current, Path: "/"})
+	fmt.Fprint(w, `{}`)
+}
+
+func testClient(t *testing.T, url string, auth Authenticator) AmbariClient {
+	t.Helper()
+	c, err := NewAmbariClient(Config{BaseURL: url, Auth: auth}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestSPNEGOAuthenticator(t *testing.T) {
+	tests := []struct {
+		name    string
+		spn     string
+		err     error
+		wantSPN string
+	}{
+		{name: "configured spn", spn: "HTTP/ambari.example.com", wantSPN: "HTTP/ambari.example.com"},
+		{name: "spn from host", spn: "", wantSPN: ""},
+		{name: "negotiation fails", err: errors.New("no ticket")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &fakeNegotiator{err: tt.err}
+			a := NewSPNEGOAuthenticator(n, tt.spn)
+			req := httptest.NewRequest(http.MethodGet, "http://ambari.example.com/api/v1/clusters", nil)
+			err := a.Authenticate(req)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) || !strings.HasPrefix(err.Error(), "spnego: ") {
+					t.Fatalf("Authenticate() error = %v, want spnego: %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if got := req.Header.Get("Authorization"); got != "Negotiate token-1" {
+				t.Errorf("Authorization = %q, want Negotiate token-1", got)
+			}
+			if len(n.spns) != 1 || n.spns[0] != tt.wantSPN {
+				t.Errorf("negotiated for %q, want %q", n.spns, tt.wantSPN)
+			}
+		})
+	}
+}
+
+func TestSessionReuse(t *testing.T) {
+	ambari := &fakeAmbari{}
+	srv := httptest.NewServer(ambari)
+	defer srv.Close()
+	n := &fakeNegotiator{}
+	c := testClient(t, srv.URL, NewSessionAuthenticator(NewSPNEGOAuthenticator(n, "HTTP/ambari")))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get(ctx, "/clusters", nil); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	if n.negotiations() != 1 {
+		t.Errorf("negotiated %d times, want 1", n.negotiations())
+	}
+	want := []string{"token-1", "session s1", "session s1"}
+	if fmt.Sprint(ambari.seen) != fmt.Sprint(want) {
+		t.Errorf("requests authenticated with %v, want %v", ambari.seen, want)
+	}
+}
+
+func TestSessionRenegotiatesOn401(t *testing.T) {
+	ambari := &fakeAmbari{}
+	srv := httptest.NewServer(ambari)
+	defer srv.Close()
+	n := &fakeNegotiator{}
+	c := testClient(t, srv.URL, NewSessionAuthenticator(NewSPNEGOAuthenticator(n, "HTTP/ambari")))
+	ctx := context.Background()
+
+	if _, err := c.Get(ctx, "/clusters", nil); err != nil {
+		t.Fatal(err)
+	}
+	ambari.expire()
+	if _, err := c.Get(ctx, "/clusters", nil); err != nil {
+		t.Fatalf("request after session expiry: %v", err)
+	}
+	if _, err := c.Get(ctx, "/clusters", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if n.negotiations() != 2 {
+		t.Errorf("negotiated %d times, want 2", n.negotiations())
+	}
+	want := []string{"token-1", "session s1", "token-2", "session s2"}
+	if fmt.Sprint(ambari.seen) != fmt.Sprint(want) {
+		t.Errorf("requests authenticated with %v, want %v", ambari.seen, want)
+	}
+}
+
+func TestSessionNoRetryWithoutSession(t *testing.T) {
+	ambari := &fakeAmbari{}
+	srv := httptest.NewServer(ambari)
+	defer srv.Close()
+	// Basic credentials are rejected by the fake; without a session to drop
+	// the 401 is final
+	c := testClient(t, srv.URL, NewSessionAuthenticator(&BasicAuthenticator{Username: "u", Password: "p"}))
+
+	_, err := c.Get(context.Background(), "/clusters", nil)
+	var ae *AmbariError
+	if !errors.As(err, &ae) || ae.Status != http.StatusUnauthorized {
+		t.Fatalf("Get() error = %v, want 401", err)
+	}
+	if len(ambari.seen) != 1 {
+		t.Errorf("sent %d requests, want 1", len(ambari.seen))
+	}
+}
+
+func TestSessionNotUsedForCallerCredentials(t *testing.T) {
+	ambari := &fakeAmbari{}
+	srv := httptest.NewServer(ambari)
+	defer srv.Close()
+	n := &fakeNegotiator{}
+	c := testClient(t, srv.URL, NewSessionAuthenticator(NewSPNEGOAuthenticator(n, "HTTP/ambari")))
+
+	if _, err := c.Get(context.Background(), "/clusters", nil); err != nil {
+		t.Fatal(err)
+	}
+	// A caller's own credentials must not pick up the service session
+	ctx := WithCredentials(context.Background(), Credentials{Username: "alice", Password: "pw"})
+	c.Get(ctx, "/clusters", nil)
+
+	if n.negotiations() != 1 {
+		t.Errorf("negotiated %d times, want 1", n.negotiations())
+	}
+	want := []string{"token-1", "anonymous Basic YWxpY2U6cHc="}
+	if fmt.Sprint(ambari.seen) != fmt.Sprint(want) {
+		t.Errorf("requests authenticated with %v, want %v", ambari.seen, want)
+	}
+}
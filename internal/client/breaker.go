@@ -0,0 +1,227 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Defaults of the per-server protection
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+	DefaultMaxConcurrent    = 16
+)
+
+// ErrCircuitOpen is returned without contacting Ambari while its breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// BreakerConfig configures the circuit breaker of one Ambari server
+type BreakerConfig struct {
+	Disabled bool `json:"disabled"`
+	// FailureThreshold consecutive failures open the breaker (default 5)
+	FailureThreshold int `json:"failure_threshold"`
+	// Cooldown is how long the breaker stays open before a probe request is let through (default 30s)
+	Cooldown string `json:"cooldown"`
+}
+
+// breaker is a consecutive-failure circuit breaker. While open it rejects
+// requests; after the cooldown it lets a single probe through (half-open) and
+// closes again if the probe succeeds.
+type breaker struct {
+	mu        sync.Mutex
+	name      string
+	threshold int
+	cooldown  time.Duration
+	logger    *logrus.Logger
+
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+	rejected int64
+}
+
+func (c BreakerConfig) build(name string, logger *logrus.Logger) (*breaker, error) {
+	if c.Disabled {
+		return nil, nil
+	}
+	b := &breaker{name: name, threshold: c.FailureThreshold, cooldown: DefaultBreakerCooldown, logger: logger, state: BreakerClosed}
+	if b.threshold <= 0 {
+		b.threshold = DefaultBreakerThreshold
+	}
+	if c.Cooldown != "" {
+		d, err := time.ParseDuration(c.Cooldown)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid breaker cooldown %q", c.Cooldown)
+		}
+		b.cooldown = d
+	}
+	return b, nil
+}
+
+// allow reports whether a request may be sent now
+func (b *breaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if wait := b.cooldown - time.Since(b.openedAt); wait > 0 {
+			b.rejected++
+			return fmt.Errorf("Ambari at %s failed %d times in a row, not sending requests for another %s: %w",
+				b.name, b.failures, wait.Round(time.Second), ErrCircuitOpen)
+		}
+		b.transition(BreakerHalfOpen)
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			b.rejected++
+			return fmt.Errorf("Ambari at %s is being probed after repeated failures: %w", b.name, ErrCircuitOpen)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record counts the outcome of a request let through by allow
+func (b *breaker) record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	// Cancellation by the caller says nothing about Ambari
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if !serverFailure(err) {
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.transition(BreakerClosed)
+		}
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		if b.state != BreakerOpen {
+			b.transition(BreakerOpen)
+		}
+	}
+}
+
+func (b *breaker) transition(state string) {
+	entry := b.logger.WithFields(logrus.Fields{"ambari": b.name, "from": b.state, "to": state, "failures": b.failures})
+	b.state = state
+	if state == BreakerOpen {
+		entry.WithField("cooldown", b.cooldown.String()).Warn("Ambari circuit breaker opened")
+	} else {
+		entry.Info("Ambari circuit breaker state changed")
+	}
+}
+
+// serverFailure reports whether err means Ambari is unhealthy. Client errors
+// (4xx other than 429) are answers from a working server.
+func serverFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var ae *AmbariError
+	if errors.As(err, &ae) {
+		return ae.Status >= 500 || ae.Status == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// limiter bounds the requests in flight to one Ambari server
+type limiter struct {
+	slots   chan struct{}
+	mu      sync.Mutex
+	waiting int
+}
+
+func newLimiter(n int) *limiter {
+	if n <= 0 {
+		n = DefaultMaxConcurrent
+	}
+	return &limiter{slots: make(chan struct{}, n)}
+}
+
+func (l *limiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	l.mu.Lock()
+	l.waiting++
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.waiting--
+		l.mu.Unlock()
+	}()
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *limiter) release() {
+	<-l.slots
+}
+
+// ProtectionStatus is the state of the breaker and limiter of one Ambari server
+type ProtectionStatus struct {
+	Breaker             string     `json:"breaker"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"`
+	Rejected            int64      `json:"rejected"`
+	InFlight            int        `json:"in_flight"`
+	Waiting             int        `json:"waiting"`
+	MaxConcurrent       int        `json:"max_concurrent"`
+}
+
+// StatusReporter is implemented by clients that protect their Ambari server
+type StatusReporter interface {
+	Protection() ProtectionStatus
+}
+
+// Protection reports the breaker and limiter state
+func (c *ambariClient) Protection() ProtectionStatus {
+	c.limiter.mu.Lock()
+	s := ProtectionStatus{
+		Breaker: "disabled", InFlight: len(c.limiter.slots), Waiting: c.limiter.waiting, MaxConcurrent: cap(c.limiter.slots),
+	}
+	c.limiter.mu.Unlock()
+	if b := c.breaker; b != nil {
+		b.mu.Lock()
+		s.Breaker, s.ConsecutiveFailures, s.Rejected = b.state, b.failures, b.rejected
+		if b.state != BreakerClosed {
+			opened, retry := b.openedAt, b.openedAt.Add(b.cooldown)
+			s.OpenedAt, s.RetryAt = &opened, &retry
+		}
+		b.mu.Unlock()
+	}
+	return s
+}
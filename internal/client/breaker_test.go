@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	fail := errors.New("connection refused")
+	unavailable := &AmbariError{Status: http.StatusServiceUnavailable}
+	throttled := &AmbariError{Status: http.StatusTooManyRequests}
+	notFound := &AmbariError{Status: http.StatusNotFound}
+
+	// step sends one request: after cooldown has passed if wait is set, with
+	// outcome recorded when it is let through
+	type step struct {
+		wait        bool
+		outcome     error
+		wantAllowed bool
+		wantState   string
+	}
+	failures := func(n int, err error) []step {
+		steps := make([]step, n)
+		for i := range steps {
+			steps[i] = step{outcome: err, wantAllowed: true, wantState: BreakerClosed}
+		}
+		return steps
+	}
+	join := func(parts ...[]step) []step {
+		var out []step
+		for _, p := range parts {
+			out = append(out, p...)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "opens after threshold",
+			steps: join(failures(2, fail), []step{
+				{outcome: fail, wantAllowed: true, wantState: BreakerOpen},
+				{wantAllowed: false, wantState: BreakerOpen},
+			}),
+		},
+		{
+			name: "success resets the count",
+			steps: join(failures(2, fail), []step{{wantAllowed: true, wantState: BreakerClosed}}, failures(2, fail), []step{
+				{outcome: unavailable, wantAllowed: true, wantState: BreakerOpen},
+			}),
+		},
+		{
+			name: "client errors are answers",
+			steps: join(failures(2, fail), failures(1, notFound), failures(2, throttled), []step{
+				{outcome: unavailable, wantAllowed: true, wantState: BreakerOpen},
+			}),
+		},
+		{
+			name: "cancellation is not counted",
+			steps: join(failures(2, fail), failures(3, fmt.Errorf("HTTP GET failed: %w", context.Canceled)), []step{
+				{outcome: fail, wantAllowed: true, wantState: BreakerOpen},
+			}),
+		},
+		{
+			name: "successful probe closes",
+			steps: join(failures(2, fail), []step{
+				{outcome: fail, wantAllowed: true, wantState: BreakerOpen},
+				{wait: true, wantAllowed: true, wantState: BreakerClosed},
+				{outcome: fail, wantAllowed: true, wantState: BreakerClosed},
+			}),
+		},
+		{
+			name: "failed probe reopens",
+			steps: join(failures(2, fail), []step{
+				{outcome: fail, wantAllowed: true, wantState: BreakerOpen},
+				{wait: true, outcome: unavailable, wantAllowed: true, wantState: BreakerOpen},
+				{wantAllowed: false, wantState: BreakerOpen},
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := BreakerConfig{FailureThreshold: 3, Cooldown: "1m"}.build("https://ambari", testLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, s := range tt.steps {
+				if s.wait {
+					b.openedAt = b.openedAt.Add(-b.cooldown)
+				}
+				err := b.allow()
+				if allowed := err == nil; allowed != s.wantAllowed {
+					t.Fatalf("step %d: allow() = %v, want allowed %v", i, err, s.wantAllowed)
+				}
+				if err != nil {
+					if !errors.Is(err, ErrCircuitOpen) {
+						t.Fatalf("step %d: allow() = %v, want ErrCircuitOpen", i, err)
+					}
+				} else {
+					b.record(s.outcome)
+				}
+				if b.state != s.wantState {
+					t.Fatalf("step %d: state = %s, want %s", i, b.state, s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerSingleProbe(t *testing.T) {
+	b, err := BreakerConfig{FailureThreshold: 1, Cooldown: "1m"}.build("https://ambari", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.allow()
+	b.record(errors.New("connection refused"))
+	b.openedAt = b.openedAt.Add(-b.cooldown)
+
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe: allow() = %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) || !strings.Contains(err.Error(), "being probed") {
+		t.Fatalf("request during probe: allow() = %v, want ErrCircuitOpen", err)
+	}
+	if b.rejected != 1 {
+		t.Errorf("rejected = %d, want 1", b.rejected)
+	}
+}
+
+func TestBreakerConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           BreakerConfig
+		wantThreshold int
+		wantCooldown  time.Duration
+		wantNil       bool
+		wantErr       bool
+	}{
+		{name: "defaults", wantThreshold: DefaultBreakerThreshold, wantCooldown: DefaultBreakerCooldown},
+		{name: "configured", cfg: BreakerConfig{FailureThreshold: 2, Cooldown: "10s"}, wantThreshold: 2, wantCooldown: 10 * time.Second},
+		{name: "disabled", cfg: BreakerConfig{Disabled: true}, wantNil: true},
+		{name: "invalid cooldown", cfg: BreakerConfig{Cooldown: "soon"}, wantErr: true},
+		{name: "negative cooldown", cfg: BreakerConfig{Cooldown: "-1s"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.cfg.build("https://ambari", testLogger())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("build() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantNil {
+				if b != nil || b.allow() != nil {
+					t.Fatal("disabled breaker is not a no-op")
+				}
+				b.record(errors.New("connection refused"))
+				return
+			}
+			if b.threshold != tt.wantThreshold || b.cooldown != tt.wantCooldown {
+				t.Errorf("build() = threshold %d, cooldown %v, want %d, %v", b.threshold, b.cooldown, tt.wantThreshold, tt.wantCooldown)
+			}
+		})
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	c := &ambariClient{limiter: newLimiter(2)}
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := c.limiter.acquire(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	acquired := make(chan error, 1)
+	go func() { acquired <- c.limiter.acquire(ctx) }()
+	deadline := time.Now().Add(time.Second)
+	for c.Protection().Waiting != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("third request is not waiting for a slot")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if s := c.Protection(); s.InFlight != 2 || s.MaxConcurrent != 2 || s.Breaker != "disabled" {
+		t.Errorf("Protection() = %+v", s)
+	}
+
+	c.limiter.release()
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("released slot was not handed to the waiting request")
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := c.limiter.acquire(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire() with all slots taken = %v, want DeadlineExceeded", err)
+	}
+	if s := c.Protection(); s.Waiting != 0 || s.InFlight != 2 {
+		t.Errorf("Protection() after giving up = %+v, want 0 waiting, 2 in flight", s)
+	}
+}
+
+func TestClientFailsFastWhileOpen(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, `{"status":503}`, http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	c, err := NewAmbariClient(Config{BaseURL: srv.URL, Breaker: BreakerConfig{FailureThreshold: 2, Cooldown: "1m"}}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		c.Get(context.Background(), "/clusters", nil)
+	}
+	if requests != 2 {
+		t.Errorf("Ambari received %d requests, want 2", requests)
+	}
+	_, err = c.Get(context.Background(), "/clusters", nil)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Get() error = %v, want ErrCircuitOpen", err)
+	}
+	s := c.(StatusReporter).Protection()
+	if s.Breaker != BreakerOpen || s.Rejected != 3 || s.RetryAt == nil {
+		t.Errorf("Protection() = %+v, want open with 3 rejected", s)
+	}
+}
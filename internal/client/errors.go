@@ -0,0 +1,107 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AmbariError is an error response from the Ambari API. Use errors.As to
+// inspect it:
+//
+//	var ae *client.AmbariError
+//	if errors.As(err, &ae) && ae.Status == http.StatusNotFound { ... }
+type AmbariError struct {
+	Status int
+	// Message is Ambari's own message from the {"status", "message"} body, if any
+	Message string
+	Method  string
+	Path    string
+	// RequestID is the X-Request-ID sent with the request; it also appears in
+	// the client's log entries for the request
+	RequestID string
+	// Retryable means the same request may succeed later (429, 502, 503, 504)
+	Retryable  bool
+	RetryAfter time.Duration
+}
+
+func newAmbariError(status int, method, path, requestID string, body map[string]interface{}, header http.Header) *AmbariError {
+	e := &AmbariError{Status: status, Method: method, Path: path, RequestID: requestID}
+	if msg, ok := body["message"].(string); ok {
+		e.Message = strings.TrimSpace(msg)
+	}
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		e.Retryable = true
+		e.RetryAfter = parseRetryAfter(header.Get("Retry-After"))
+	}
+	return e
+}
+
+func (e *AmbariError) Error() string {
+	if summary := e.Summary(); summary != "" {
+		return fmt.Sprintf("%s (HTTP %d from %s %s)", summary, e.Status, e.Method, e.Path)
+	}
+	return fmt.Sprintf("HTTP %d from %s %s", e.Status, e.Method, e.Path)
+}
+
+// NotFound reports whether Ambari answered 404
+func (e *AmbariError) NotFound() bool {
+	return e.Status == http.StatusNotFound
+}
+
+// Summary rewrites Ambari's message into a sentence, e.g. "The requested
+// resource doesn't exist: Service not found, clusterName=c1, serviceName=HDFS"
+// becomes "Service HDFS not found in cluster c1". Other messages are returned
+// unchanged.
+func (e *AmbariError) Summary() string {
+	msg := strings.TrimPrefix(e.Message, "The requested resource doesn't exist: ")
+	parts := strings.Split(msg, ", ")
+	kind, found := strings.CutSuffix(parts[0], " not found")
+	if !found || len(parts) < 2 {
+		return e.Message
+	}
+	var name, cluster string
+	for _, kv := range parts[1:] {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return e.Message
+		}
+		if k == "clusterName" {
+			cluster = v
+		} else {
+			name = v
+		}
+	}
+	// A missing cluster is named by clusterName alone
+	if name == "" && kind == "Cluster" {
+		name = cluster
+	}
+	out := kind + " not found"
+	if name != "" {
+		out = kind + " " + name + " not found"
+	}
+	if cluster != "" && name != cluster {
+		out += " in cluster " + cluster
+	}
+	return out
+}
+
+// IsNotFound reports whether err is an Ambari 404
+func IsNotFound(err error) bool {
+	var ae *AmbariError
+	return errors.As(err, &ae) && ae.NotFound()
+}
+
+// newRequestID returns a random ID correlating a call with its log entries
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
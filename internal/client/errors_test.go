@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAmbariErrorFromResponse(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		contentType   string
+		body          string
+		wantMessage   string
+		wantError     string
+		wantRetryable bool
+	}{
+		{
+			name:        "JSON body",
+			status:      http.StatusNotFound,
+			contentType: "application/json",
+			body:        `{"status": 404, "message": "The requested resource doesn't exist: Service not found, clusterName=c1, serviceName=HDFS"}`,
+			wantMessage: "The requested resource doesn't exist: Service not found, clusterName=c1, serviceName=HDFS",
+			wantError:   "Service HDFS not found in cluster c1 (HTTP 404 from GET /clusters/c1/services/HDFS)",
+		},
+		{
+			name:        "JSON body with padded message",
+			status:      http.StatusBadRequest,
+			contentType: "application/json",
+			body:        `{"status": 400, "message": "  Invalid desired state  \n"}`,
+			wantMessage: "Invalid desired state",
+			wantError:   "Invalid desired state (HTTP 400 from GET /clusters/c1/services/HDFS)",
+		},
+		{
+			name:          "HTML body",
+			status:        http.StatusBadGateway,
+			contentType:   "text/html",
+			body:          "<html><body><h1>502 Bad Gateway</h1></body></html>",
+			wantError:     "HTTP 502 from GET /clusters/c1/services/HDFS",
+			wantRetryable: true,
+		},
+		{
+			name:          "empty body",
+			status:        http.StatusServiceUnavailable,
+			wantError:     "HTTP 503 from GET /clusters/c1/services/HDFS",
+			wantRetryable: true,
+		},
+		{
+			name:        "truncated body",
+			status:      http.StatusInternalServerError,
+			contentType: "application/json",
+			body:        `{"status": 500, "message": "Server Er`,
+			wantError:   "HTTP 500 from GET /clusters/c1/services/HDFS",
+		},
+		{
+			name:        "message of another type",
+			status:      http.StatusForbidden,
+			contentType: "application/json",
+			body:        `{"status": 403, "message": {"reason": "denied"}}`,
+			wantError:   "HTTP 403 from GET /clusters/c1/services/HDFS",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sentID string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sentID = r.Header.Get("X-Request-ID")
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+			c, err := NewAmbariClient(Config{BaseURL: srv.URL, Breaker: BreakerConfig{Disabled: true}}, testLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = c.Get(context.Background(), "/clusters/c1/services/HDFS", nil)
+
+			var ae *AmbariError
+			if !errors.As(err, &ae) {
+				t.Fatalf("Get() error = %v, want an AmbariError", err)
+			}
+			if ae.Status != tt.status || ae.Message != tt.wantMessage || ae.Retryable != tt.wantRetryable {
+				t.Errorf("AmbariError = status %d message %q retryable %v, want %d %q %v",
+					ae.Status, ae.Message, ae.Retryable, tt.status, tt.wantMessage, tt.wantRetryable)
+			}
+			if ae.Error() != tt.wantError {
+				t.Errorf("Error() = %q, want %q", ae.Error(), tt.wantError)
+			}
+			if ae.RequestID == "" || ae.RequestID != sentID {
+				t.Errorf("RequestID = %q, want the X-Request-ID sent (%q)", ae.RequestID, sentID)
+			}
+		})
+	}
+}
+
+func TestAmbariErrorTruncatedTransfer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"status": 500`)
+	}))
+	defer srv.Close()
+	c, err := NewAmbariClient(Config{BaseURL: srv.URL}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Get(context.Background(), "/clusters", nil)
+	if err == nil || !strings.Contains(err.Error(), "read response") {
+		t.Fatalf("Get() error = %v, want a read error", err)
+	}
+	var ae *AmbariError
+	if errors.As(err, &ae) {
+		t.Errorf("Get() error = %v reports a status from a response that was cut off", err)
+	}
+}
+
+func TestAmbariErrorSummary(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{message: "The requested resource doesn't exist: Service not found, clusterName=c1, serviceName=HDFS", want: "Service HDFS not found in cluster c1"},
+		{message: "The requested resource doesn't exist: Cluster not found, clusterName=c9", want: "Cluster c9 not found"},
+		{message: "The requested resource doesn't exist: Host not found, hostName=n1", want: "Host n1 not found"},
+		{message: "ServiceComponent not found, clusterName=c1, serviceName=HDFS, serviceComponentName=JOURNALNODE", want: "ServiceComponent JOURNALNODE not found in cluster c1"},
+		{message: "The requested resource doesn't exist: Alert not found", want: "The requested resource doesn't exist: Alert not found"},
+		{message: "Service not found, clusterName c1", want: "Service not found, clusterName c1"},
+		{message: "Invalid desired state", want: "Invalid desired state"},
+		{message: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if got := (&AmbariError{Message: tt.message}).Summary(); got != tt.want {
+				t.Errorf("Summary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	if !IsNotFound(fmt.Errorf("get service: %w", &AmbariError{Status: http.StatusNotFound})) {
+		t.Error("wrapped 404 not recognized")
+	}
+	if IsNotFound(&AmbariError{Status: http.StatusForbidden}) || IsNotFound(errors.New("not found")) || IsNotFound(nil) {
+		t.Error("IsNotFound() true for an error other than a 404")
+	}
+}
@@ -0,0 +1,302 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultInstance names the single instance configured from AMBARI_* variables
+const DefaultInstance = "default"
+
+// ErrUnknownInstance is returned for calls naming an instance that is not configured
+var ErrUnknownInstance = errors.New("unknown Ambari instance")
+
+// InstanceConfig describes one named Ambari server
+type InstanceConfig struct {
+	Name         string `json:"name"`
+	BaseURL      string `json:"base_url"`
+	Username     string `json:"username"`
+	PasswordFile string `json:"password_file"`
+	Password     string `json:"-"`
+	Timeout      string `json:"timeout"`
+	// Retries defaults to DefaultRetries when unset; 0 disables retrying
+	Retries *int          `json:"retries"`
+	Auth    AuthConfig    `json:"auth"`
+	TLS     TLSConfig     `json:"tls"`
+	Breaker BreakerConfig `json:"breaker"`
+	// MaxConcurrent bounds the requests in flight (default DefaultMaxConcurrent)
+	MaxConcurrent int `json:"max_concurrent"`
+}
+
+// InstancesConfig is the connection registry file (AMBARI_INSTANCES_FILE)
+type InstancesConfig struct {
+	// Default is used when a call names no instance; the first entry otherwise
+	Default   string           `json:"default"`
+	Instances []InstanceConfig `json:"instances"`
+}
+
+// LoadInstances reads the connection registry file and the instance passwords
+func LoadInstances(path string) (InstancesConfig, error) {
+	var cfg InstancesConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("read Ambari instances %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse Ambari instances %s: %w", path, err)
+	}
+	for i := range cfg.Instances {
+		inst := &cfg.Instances[i]
+		if inst.PasswordFile == "" {
+			continue
+		}
+		secret, err := os.ReadFile(inst.PasswordFile)
+		if err != nil {
+			return cfg, fmt.Errorf("read password for Ambari instance %s: %w", inst.Name, err)
+		}
+		inst.Password = strings.TrimSpace(string(secret))
+	}
+	return cfg, nil
+}
+
+// Instance names become the first segment of resource URIs, so they must not
+// collide with the resource paths themselves
+var (
+	instanceNamePattern   = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	reservedInstanceNames = map[string]bool{"clusters": true, "cluster": true, "host": true}
+)
+
+type instanceKey struct{}
+
+// WithInstance returns a context whose requests are sent to the named instance
+func WithInstance(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, instanceKey{}, name)
+}
+
+// InstanceFromContext returns the instance selected for the request, if any
+func InstanceFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(instanceKey{}).(string)
+	return name, ok && name != ""
+}
+
+// Instance is a configured Ambari server and its client
+type Instance struct {
+	Name    string
+	BaseURL string
+	Client  AmbariClient
+}
+
+// Registry holds one client per named Ambari instance. It implements
+// AmbariClient itself, routing each request to the instance selected with
+// WithInstance, or to the default instance.
+type Registry struct {
+	instances map[string]*Instance
+	order     []string
+	def       string
+	logger    *logrus.Logger
+}
+
+// NewRegistry creates a client for every configured instance
+func NewRegistry(cfg InstancesConfig, logger *logrus.Logger) (*Registry, error) {
+	if len(cfg.Instances) == 0 {
+		return nil, fmt.Errorf("no Ambari instances configured")
+	}
+	r := &Registry{instances: make(map[string]*Instance), logger: logger}
+	for _, ic := range cfg.Instances {
+		if !instanceNamePattern.MatchString(ic.Name) || reservedInstanceNames[ic.Name] {
+			return nil, fmt.Errorf("invalid Ambari instance name %q", ic.Name)
+		}
+		if _, exists := r.instances[ic.Name]; exists {
+			return nil, fmt.Errorf("duplicate Ambari instance %q", ic.Name)
+		}
+		c, err := newInstanceClient(ic, logger)
+		if err != nil {
+			return nil, fmt.Errorf("ambari instance %s: %w", ic.Name, err)
+		}
+		r.instances[ic.Name] = &Instance{Name: ic.Name, BaseURL: ic.BaseURL, Client: c}
+		r.order = append(r.order, ic.Name)
+	}
+	r.def = cfg.Default
+	if r.def == "" {
+		r.def = r.order[0]
+	}
+	if _, ok := r.instances[r.def]; !ok {
+		return nil, fmt.Errorf("default Ambari instance %q is not configured", r.def)
+	}
+	return r, nil
+}
+
+func newInstanceClient(ic InstanceConfig, logger *logrus.Logger) (AmbariClient, error) {
+	if ic.BaseURL == "" {
+		return nil, fmt.Errorf("base_url is required")
+	}
+	timeout := 30 * time.Second
+	if ic.Timeout != "" {
+		d, err := time.ParseDuration(ic.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", ic.Timeout, err)
+		}
+		timeout = d
+	}
+	retries := DefaultRetries
+	if ic.Retries != nil {
+		if *ic.Retries < 0 {
+			return nil, fmt.Errorf("invalid retries %d", *ic.Retries)
+		}
+		retries = *ic.Retries
+	}
+	auth, err := NewAuthenticator(ic.Auth, ic.Username, ic.Password)
+	if err != nil {
+		return nil, err
+	}
+	logger.WithFields(logrus.Fields{"instance": ic.Name, "url": ic.BaseURL, "auth": auth.Name()}).Info("Ambari instance configured")
+	return NewAmbariClient(Config{
+		BaseURL:       ic.BaseURL,
+		Timeout:       timeout,
+		Retries:       retries,
+		Auth:          auth,
+		TLS:           ic.TLS,
+		Breaker:       ic.Breaker,
+		MaxConcurrent: ic.MaxConcurrent,
+	}, logger)
+}
+
+// Default returns the name of the default instance
+func (r *Registry) Default() string {
+	return r.def
+}
+
+// Has reports whether name is a configured instance
+func (r *Registry) Has(name string) bool {
+	_, ok := r.instances[name]
+	return ok
+}
+
+// Instances returns the configured instances in configuration order
+func (r *Registry) Instances() []*Instance {
+	out := make([]*Instance, 0, len(r.order))
+	for _, name := range r.order {
+		out = append(out, r.instances[name])
+	}
+	return out
+}
+
+// Client returns the client of the named instance; an empty name selects the default
+func (r *Registry) Client(name string) (AmbariClient, error) {
+	if name == "" {
+		name = r.def
+	}
+	inst, ok := r.instances[name]
+	if !ok {
+		names := append([]string(nil), r.order...)
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w %q (configured: %s)", ErrUnknownInstance, name, strings.Join(names, ", "))
+	}
+	return inst.Client, nil
+}
+
+func (r *Registry) route(ctx context.Context) (AmbariClient, error) {
+	name, _ := InstanceFromContext(ctx)
+	return r.Client(name)
+}
+
+func (r *Registry) Get(ctx context.Context, path string, params map[string]string) (map[string]interface{}, error) {
+	c, err := r.route(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Get(ctx, path, params)
+}
+
+func (r *Registry) Post(ctx context.Context, path string, params map[string]string, body interface{}) (map[string]interface{}, error) {
+	c, err := r.route(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Post(ctx, path, params, body)
+}
+
+func (r *Registry) Put(ctx context.Context, path string, params map[string]string, body interface{}) (map[string]interface{}, error) {
+	c, err := r.route(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Put(ctx, path, params, body)
+}
+
+func (r *Registry) Delete(ctx context.Context, path string, params map[string]string) (map[string]interface{}, error) {
+	c, err := r.route(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Delete(ctx, path, params)
+}
+
+// InstanceStatus is the reachability of one instance
+type InstanceStatus struct {
+	Name      string `json:"name"`
+	BaseURL   string `json:"base_url"`
+	Default   bool   `json:"default"`
+	Reachable bool   `json:"reachable"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Probe requests the cluster list from every instance concurrently. Each probe
+// is bounded by timeout and made with the credentials carried by ctx.
+func (r *Registry) Probe(ctx context.Context, timeout time.Duration) []InstanceStatus {
+	instances := r.Instances()
+	statuses := make([]InstanceStatus, len(instances))
+	var wg sync.WaitGroup
+	for i, inst := range instances {
+		wg.Add(1)
+		go func(i int, inst *Instance) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			_, err := inst.Client.Get(probeCtx, "/clusters", map[string]string{"fields": "Clusters/cluster_name"})
+			status := InstanceStatus{
+				Name:      inst.Name,
+				BaseURL:   inst.BaseURL,
+				Default:   inst.Name == r.def,
+				Reachable: err == nil,
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				status.Error = err.Error()
+			}
+			statuses[i] = status
+		}(i, inst)
+	}
+	wg.Wait()
+	return statuses
+}
+
+// InstanceProtection is the circuit breaker and concurrency limiter state of one instance
+type InstanceProtection struct {
+	Name string `json:"name"`
+	ProtectionStatus
+}
+
+// Protection reports the breaker and limiter state of every instance without
+// contacting Ambari
+func (r *Registry) Protection() []InstanceProtection {
+	var out []InstanceProtection
+	for _, inst := range r.Instances() {
+		if sr, ok := inst.Client.(StatusReporter); ok {
+			out = append(out, InstanceProtection{Name: inst.Name, ProtectionStatus: sr.Protection()})
+		}
+	}
+	return out
+}
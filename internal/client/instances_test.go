@@ -0,0 +1,228 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// instanceServer is a fake Ambari that answers every request with its name
+func instanceServer(t *testing.T, name string, seen *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*seen = append(*seen, name+" "+r.Method+" "+strings.TrimPrefix(r.URL.Path, "/api/v1"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"instance":"` + name + `"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRegistryRouting(t *testing.T) {
+	var seen []string
+	none := 0
+	cfg := InstancesConfig{Instances: []InstanceConfig{
+		{Name: "east", BaseURL: instanceServer(t, "east", &seen).URL + "/api/v1", Retries: &none},
+		{Name: "west", BaseURL: instanceServer(t, "west", &seen).URL + "/api/v1", Retries: &none},
+	}}
+	tests := []struct {
+		name     string
+		def      string
+		instance string
+		want     string
+		wantErr  string
+	}{
+		{name: "first instance is the default", want: "east"},
+		{name: "configured default", def: "west", want: "west"},
+		{name: "named instance", instance: "west", want: "west"},
+		{name: "named default", def: "west", instance: "east", want: "east"},
+		{name: "unknown instance", instance: "north", wantErr: `unknown Ambari instance "north" (configured: east, west)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Default = tt.def
+			r, err := NewRegistry(cfg, testLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			if tt.instance != "" {
+				ctx = WithInstance(ctx, tt.instance)
+			}
+			seen = nil
+			calls := []func() (map[string]interface{}, error){
+				func() (map[string]interface{}, error) { return r.Get(ctx, "/clusters", nil) },
+				func() (map[string]interface{}, error) {
+					return r.Post(ctx, "/clusters/c1/requests", nil, map[string]string{})
+				},
+				func() (map[string]interface{}, error) { return r.Put(ctx, "/clusters/c1", nil, map[string]string{}) },
+				func() (map[string]interface{}, error) { return r.Delete(ctx, "/users/bob", nil) },
+			}
+			for _, call := range calls {
+				resp, err := call()
+				if tt.wantErr != "" {
+					if !errors.Is(err, ErrUnknownInstance) || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("error = %v, want %q", err, tt.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp["instance"] != tt.want {
+					t.Errorf("answered by %v, want %s", resp["instance"], tt.want)
+				}
+			}
+			if tt.wantErr != "" && len(seen) != 0 {
+				t.Errorf("requests sent for an unknown instance: %v", seen)
+			}
+			if tt.wantErr == "" {
+				want := []string{tt.want + " GET /clusters", tt.want + " POST /clusters/c1/requests", tt.want + " PUT /clusters/c1", tt.want + " DELETE /users/bob"}
+				if strings.Join(seen, "\n") != strings.Join(want, "\n") {
+					t.Errorf("requests = %q, want %q", seen, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadInstances(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "password")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		content    string
+		wantErr    string
+		wantNames  string
+		wantDef    string
+		wantSecret string
+	}{
+		{
+			name:      "valid",
+			content:   `{"default":"west","instances":[{"name":"east","base_url":"https://east/api/v1"},{"name":"west","base_url":"https://west/api/v1"}]}`,
+			wantNames: "east,west", wantDef: "west",
+		},
+		{
+			name:      "password file",
+			content:   `{"instances":[{"name":"east","base_url":"https://east/api/v1","username":"admin","password_file":"` + secret + `"}]}`,
+			wantNames: "east", wantDef: "east", wantSecret: "s3cret",
+		},
+		{name: "not JSON", content: `instances:`, wantErr: "parse Ambari instances"},
+		{name: "missing password file", content: `{"instances":[{"name":"east","base_url":"https://east","password_file":"` + filepath.Join(dir, "missing") + `"}]}`, wantErr: "read password for Ambari instance east"},
+		{name: "no instances", content: `{"instances":[]}`, wantErr: "no Ambari instances configured"},
+		{name: "reserved name", content: `{"instances":[{"name":"clusters","base_url":"https://east"}]}`, wantErr: `invalid Ambari instance name "clusters"`},
+		{name: "invalid name", content: `{"instances":[{"name":"prod/east","base_url":"https://east"}]}`, wantErr: `invalid Ambari instance name "prod/east"`},
+		{name: "empty name", content: `{"instances":[{"base_url":"https://east"}]}`, wantErr: `invalid Ambari instance name ""`},
+		{name: "duplicate name", content: `{"instances":[{"name":"east","base_url":"https://a"},{"name":"east","base_url":"https://b"}]}`, wantErr: `duplicate Ambari instance "east"`},
+		{name: "unknown default", content: `{"default":"north","instances":[{"name":"east","base_url":"https://east"}]}`, wantErr: `default Ambari instance "north" is not configured`},
+		{name: "missing base URL", content: `{"instances":[{"name":"east"}]}`, wantErr: "ambari instance east: base_url is required"},
+		{name: "invalid timeout", content: `{"instances":[{"name":"east","base_url":"https://east","timeout":"soon"}]}`, wantErr: `invalid timeout "soon"`},
+		{name: "negative retries", content: `{"instances":[{"name":"east","base_url":"https://east","retries":-1}]}`, wantErr: "invalid retries -1"},
+		{name: "invalid TLS", content: `{"instances":[{"name":"east","base_url":"https://east","tls":{"min_version":"1.0"}}]}`, wantErr: "unsupported minimum TLS version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "instances.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadInstances(path)
+			var r *Registry
+			if err == nil {
+				r, err = NewRegistry(cfg, testLogger())
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, inst := range r.Instances() {
+				names = append(names, inst.Name)
+			}
+			if strings.Join(names, ",") != tt.wantNames || r.Default() != tt.wantDef {
+				t.Errorf("instances = %v default %s, want %s default %s", names, r.Default(), tt.wantNames, tt.wantDef)
+			}
+			if cfg.Instances[0].Password != tt.wantSecret {
+				t.Errorf("Password = %q, want %q", cfg.Instances[0].Password, tt.wantSecret)
+			}
+		})
+	}
+
+	if _, err := LoadInstances(filepath.Join(dir, "missing.json")); err == nil || !strings.Contains(err.Error(), "read Ambari instances") {
+		t.Errorf("LoadInstances() of a missing file error = %v", err)
+	}
+}
+
+func TestRegistryProbe(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/clusters" || r.URL.Query().Get("fields") != "Clusters/cluster_name" {
+			t.Errorf("probe requested %s", r.URL)
+		}
+		w.Write([]byte(`{"items":[]}`))
+	}))
+	defer up.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"status":403,"message":"Access is denied"}`, http.StatusForbidden)
+	}))
+	defer failing.Close()
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer hanging.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	down.Close()
+
+	none := 0
+	r, err := NewRegistry(InstancesConfig{Default: "failing", Instances: []InstanceConfig{
+		{Name: "up", BaseURL: up.URL + "/api/v1", Retries: &none},
+		{Name: "failing", BaseURL: failing.URL + "/api/v1", Retries: &none},
+		{Name: "hanging", BaseURL: hanging.URL + "/api/v1", Retries: &none},
+		{Name: "down", BaseURL: down.URL + "/api/v1", Retries: &none},
+	}}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	statuses := r.Probe(context.Background(), 200*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Probe() took %v; probes are not concurrent or not bounded", elapsed)
+	}
+	tests := []struct {
+		name          string
+		wantDefault   bool
+		wantReachable bool
+		wantErr       string
+	}{
+		{name: "up", wantReachable: true},
+		{name: "failing", wantDefault: true, wantErr: "HTTP 403"},
+		{name: "hanging", wantErr: "deadline exceeded"},
+		{name: "down", wantErr: "connection refused"},
+	}
+	if len(statuses) != len(tests) {
+		t.Fatalf("Probe() = %d statuses, want %d", len(statuses), len(tests))
+	}
+	for i, tt := range tests {
+		s := statuses[i]
+		if s.Name != tt.name || s.Default != tt.wantDefault || s.Reachable != tt.wantReachable || s.BaseURL == "" {
+			t.Errorf("status %d = %+v, want %s default=%v reachable=%v", i, s, tt.name, tt.wantDefault, tt.wantReachable)
+		}
+		if tt.wantErr == "" && s.Error != "" || !strings.Contains(s.Error, tt.wantErr) {
+			t.Errorf("%s: Error = %q, want it to contain %q", tt.name, s.Error, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// PageRequest selects one page of an Ambari collection with Ambari's
+// page_size, from and sortBy query parameters. Offsets are only stable when
+// the collection is sorted, so SortBy should always be set.
+type PageRequest struct {
+	PageSize int
+	From     int
+	SortBy   string
+}
+
+// Params returns params extended with the paging parameters
+func (r PageRequest) Params(params map[string]string) map[string]string {
+	out := make(map[string]string, len(params)+3)
+	for k, v := range params {
+		out[k] = v
+	}
+	if r.PageSize > 0 {
+		out["page_size"] = strconv.Itoa(r.PageSize)
+		out["from"] = strconv.Itoa(r.From)
+	}
+	if r.SortBy != "" {
+		out["sortBy"] = r.SortBy
+	}
+	return out
+}
+
+// Page is one page of a collection response
+type Page struct {
+	Response map[string]interface{}
+	Items    []interface{}
+	From     int
+	PageSize int
+	// Total is Ambari's itemTotal, or -1 when the response does not carry it
+	Total int
+	// NextFrom is the offset of the following page, or -1 on the last page
+	NextFrom int
+}
+
+// HasNext reports whether another page follows
+func (p *Page) HasNext() bool {
+	return p.NextFrom >= 0
+}
+
+// GetPage fetches one page of the collection at path
+func GetPage(ctx context.Context, c AmbariClient, path string, params map[string]string, req PageRequest) (*Page, error) {
+	resp, err := c.Get(ctx, path, req.Params(params))
+	if err != nil {
+		return nil, err
+	}
+	items, _ := resp["items"].([]interface{})
+	page := &Page{Response: resp, Items: items, From: req.From, PageSize: req.PageSize, Total: -1, NextFrom: -1}
+	// itemTotal is a string in most Ambari versions and a number in some
+	switch total := resp["itemTotal"].(type) {
+	case string:
+		if n, err := strconv.Atoi(total); err == nil {
+			page.Total = n
+		}
+	case float64:
+		page.Total = int(total)
+	}
+	next := req.From + len(items)
+	if req.PageSize > 0 && len(items) == req.PageSize && (page.Total < 0 || next < page.Total) {
+		page.NextFrom = next
+	}
+	return page, nil
+}
+
+// Pager iterates over every page of a collection:
+//
+//	p := client.NewPager(c, "/hosts", params, client.PageRequest{PageSize: 500, SortBy: "Hosts/host_name.asc"})
+//	for p.Next(ctx) {
+//		use(p.Page().Items)
+//	}
+//	if err := p.Err(); err != nil { ... }
+type Pager struct {
+	client AmbariClient
+	path   string
+	params map[string]string
+	req    PageRequest
+	page   *Page
+	err    error
+	done   bool
+}
+
+// NewPager creates a pager starting at req.From
+func NewPager(c AmbariClient, path string, params map[string]string, req PageRequest) *Pager {
+	return &Pager{client: c, path: path, params: params, req: req}
+}
+
+// Next fetches the following page and reports whether there was one
+func (p *Pager) Next(ctx context.Context) bool {
+	if p.done {
+		return false
+	}
+	page, err := GetPage(ctx, p.client, p.path, p.params, p.req)
+	if err != nil {
+		p.err = fmt.Errorf("page from %d of %s: %w", p.req.From, p.path, err)
+		p.done = true
+		return false
+	}
+	p.page = page
+	if page.HasNext() {
+		p.req.From = page.NextFrom
+	} else {
+		p.done = true
+	}
+	return true
+}
+
+// Page returns the page fetched by the last call to Next
+func (p *Pager) Page() *Page {
+	return p.page
+}
+
+// Err returns the error that stopped the iteration, if any
+func (p *Pager) Err() error {
+	return p.err
+}
+
+// GetAll walks every page and returns all items
+func GetAll(ctx context.Context, c AmbariClient, path string, params map[string]string, req PageRequest) ([]interface{}, error) {
+	var items []interface{}
+	p := NewPager(c, path, params, req)
+	for p.Next(ctx) {
+		items = append(items, p.Page().Items...)
+	}
+	return items, p.Err()
+}
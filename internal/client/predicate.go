@@ -0,0 +1,249 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// PredicateParam is the params key that carries an encoded Predicate. Unlike
+// other params it is appended to the query string as-is, since Ambari
+// predicates are not key=value pairs.
+const PredicateParam = "\x00predicate"
+
+// Predicate is an Ambari query predicate, e.g.
+//
+//	And(Eq("Hosts/host_status", "HEALTHY"), Or(In("Hosts/rack_info", "/r1", "/r2"), Gt("Hosts/cpu_count", "16")))
+//
+// renders Hosts/host_status=HEALTHY&(Hosts/rack_info.in(/r1,/r2)|Hosts/cpu_count>16).
+type Predicate struct {
+	op       string
+	field    string
+	values   []string
+	children []Predicate
+}
+
+// Comparison operators
+func Eq(field, value string) Predicate {
+	return Predicate{op: "=", field: field, values: []string{value}}
+}
+func NotEq(field, value string) Predicate {
+	return Predicate{op: "!=", field: field, values: []string{value}}
+}
+func Lt(field, value string) Predicate {
+	return Predicate{op: "<", field: field, values: []string{value}}
+}
+func Le(field, value string) Predicate {
+	return Predicate{op: "<=", field: field, values: []string{value}}
+}
+func Gt(field, value string) Predicate {
+	return Predicate{op: ">", field: field, values: []string{value}}
+}
+func Ge(field, value string) Predicate {
+	return Predicate{op: ">=", field: field, values: []string{value}}
+}
+
+// In matches any of values
+func In(field string, values ...string) Predicate {
+	return Predicate{op: ".in", field: field, values: values}
+}
+
+// Matches matches a Java regular expression against the whole value. Top-level
+// alternatives (a|b) are sent as ORed conditions and other grammar characters
+// as \xHH escapes; groups, class intersections (&&) and {n,m} quantifiers
+// cannot be sent and fail Validate.
+func Matches(field, regex string) Predicate {
+	return Predicate{op: ".matches", field: field, values: []string{regex}}
+}
+
+// IsEmpty matches resources whose collection field has no entries
+func IsEmpty(field string) Predicate {
+	return Predicate{op: ".isEmpty", field: field}
+}
+
+// And requires all predicates
+func And(ps ...Predicate) Predicate { return Predicate{op: "&", children: ps} }
+
+// Or requires any predicate
+func Or(ps ...Predicate) Predicate { return Predicate{op: "|", children: ps} }
+
+// Not negates p
+func Not(p Predicate) Predicate { return Predicate{op: "!", children: []Predicate{p}} }
+
+var (
+	predicateField = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(/[A-Za-z0-9_]+)*$`)
+	// Characters with a meaning in Ambari's predicate grammar are rejected
+	// in values so a value cannot extend the predicate
+	predicateValue = regexp.MustCompile(`^[^&|()!=<>,]*$`)
+)
+
+// grammarChars have a meaning in Ambari's predicate grammar. Ambari parses the
+// decoded query string, so percent-encoding does not hide them.
+const grammarChars = "&|()!=<>,"
+
+// quoteRegex rewrites a Java regex so that no grammar character remains:
+// literal ones become \xHH escapes and top-level alternatives are returned
+// separately, to be ORed
+func quoteRegex(regex string) ([]string, error) {
+	var (
+		alts   []string
+		b      strings.Builder
+		class  int  // nesting depth of [...] character classes
+		quoted bool // inside \Q...\E
+		braces bool // inside a {n,m} quantifier
+	)
+	grammar := func(c byte) bool { return strings.IndexByte(grammarChars, c) >= 0 }
+	hex := func(c byte) { fmt.Fprintf(&b, `\x%02X`, c) }
+	for i := 0; i < len(regex); i++ {
+		c := regex[i]
+		switch {
+		case quoted:
+			if strings.HasPrefix(regex[i:], `\E`) {
+				quoted = false
+				b.WriteString(`\E`)
+				i++
+			} else if grammar(c) {
+				b.WriteString(`\E`)
+				hex(c)
+				b.WriteString(`\Q`)
+			} else {
+				b.WriteByte(c)
+			}
+		case c == '\\':
+			if i+1 == len(regex) {
+				return nil, fmt.Errorf("trailing backslash")
+			}
+			i++
+			if grammar(regex[i]) {
+				hex(regex[i])
+				break
+			}
+			b.WriteByte(c)
+			b.WriteByte(regex[i])
+			quoted = regex[i] == 'Q'
+		case !grammar(c):
+			switch c {
+			case '[':
+				class++
+			case ']':
+				if class > 0 {
+					class--
+				}
+			case '{':
+				braces = class == 0
+			case '}':
+				braces = false
+			}
+			b.WriteByte(c)
+		case class > 0:
+			if strings.HasPrefix(regex[i:], "&&") {
+				return nil, fmt.Errorf("class intersections (&&) are not supported")
+			}
+			hex(c)
+		case c == '|':
+			alts = append(alts, b.String())
+			b.Reset()
+		case c == '(' || c == ')':
+			return nil, fmt.Errorf("groups are not supported, use | at the top level or a character class")
+		case c == ',' && braces:
+			return nil, fmt.Errorf("{n,m} quantifiers are not supported")
+		default:
+			hex(c)
+		}
+	}
+	return append(alts, b.String()), nil
+}
+
+// Validate checks field names and values so the predicate cannot inject
+// operators or parameters of its own
+func (p Predicate) Validate() error {
+	switch p.op {
+	case "&", "|", "!":
+		if len(p.children) == 0 {
+			return fmt.Errorf("empty %q predicate", p.op)
+		}
+		for _, c := range p.children {
+			if err := c.Validate(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "":
+		return fmt.Errorf("empty predicate")
+	}
+	if !predicateField.MatchString(p.field) {
+		return fmt.Errorf("invalid predicate field %q", p.field)
+	}
+	if p.op == ".in" && len(p.values) == 0 {
+		return fmt.Errorf("%s.in needs at least one value", p.field)
+	}
+	if p.op == ".matches" {
+		if _, err := quoteRegex(p.values[0]); err != nil {
+			return fmt.Errorf("invalid regex %q for %s: %w", p.values[0], p.field, err)
+		}
+	}
+	for _, v := range p.values {
+		if strings.ContainsAny(v, "\x00\n\r") || p.op != ".matches" && !predicateValue.MatchString(v) {
+			return fmt.Errorf("invalid value %q for %s: must not contain & | ( ) ! = < > ,", v, p.field)
+		}
+	}
+	return nil
+}
+
+// String renders the predicate in Ambari's query syntax with values
+// percent-encoded. Or groups are always parenthesized so the result can be
+// joined to other predicates with &.
+func (p Predicate) String() string {
+	switch p.op {
+	case "&", "|":
+		parts := make([]string, len(p.children))
+		for i, c := range p.children {
+			parts[i] = c.String()
+		}
+		if p.op == "|" {
+			return "(" + strings.Join(parts, "|") + ")"
+		}
+		return strings.Join(parts, "&")
+	case "!":
+		return "!(" + p.children[0].String() + ")"
+	case ".matches":
+		// Validate has rejected regexes that cannot be quoted
+		alts, _ := quoteRegex(p.values[0])
+		parts := make([]string, len(alts))
+		for i, a := range alts {
+			parts[i] = p.field + ".matches(" + url.QueryEscape(a) + ")"
+		}
+		if len(parts) == 1 {
+			return parts[0]
+		}
+		return "(" + strings.Join(parts, "|") + ")"
+	case ".in":
+		values := make([]string, len(p.values))
+		for i, v := range p.values {
+			values[i] = url.QueryEscape(v)
+		}
+		return p.field + p.op + "(" + strings.Join(values, ",") + ")"
+	case ".isEmpty":
+		return p.field + ".isEmpty()"
+	}
+	return p.field + p.op + url.QueryEscape(p.values[0])
+}
+
+// WithPredicate returns params extended with p, ANDed with any predicate
+// params already carry
+func WithPredicate(params map[string]string, p Predicate) (map[string]string, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(params)+1)
+	for k, v := range params {
+		out[k] = v
+	}
+	if existing := out[PredicateParam]; existing != "" {
+		out[PredicateParam] = existing + "&" + p.String()
+	} else {
+		out[PredicateParam] = p.String()
+	}
+	return out, nil
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPredicate(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       Predicate
+		want    string
+		wantErr string
+	}{
+		{name: "eq", p: Eq("Hosts/host_status", "HEALTHY"), want: "Hosts/host_status=HEALTHY"},
+		{name: "comparisons", p: And(NotEq("a", "1"), Lt("b", "2"), Le("c", "3"), Gt("d", "4"), Ge("e", "5")), want: "a!=1&b<2&c<=3&d>4&e>=5"},
+		{name: "in", p: In("Hosts/rack_info", "/r1", "/r2"), want: "Hosts/rack_info.in(/r1,/r2)"},
+		{name: "or parenthesized", p: And(Eq("a", "1"), Or(Eq("b", "2"), IsEmpty("c"))), want: "a=1&(b=2|c.isEmpty())"},
+		{name: "not", p: Not(Eq("a", "1")), want: "!(a=1)"},
+		{name: "matches", p: Matches("Hosts/host_name", "web-.*"), want: "Hosts/host_name.matches(web-.*)"},
+		{name: "matches alternatives", p: Matches("Hosts/host_name", "web-.*|db-[0-9]+"), want: "(Hosts/host_name.matches(web-.*)|Hosts/host_name.matches(db-[0-9]+))"},
+		{name: "matches escaped grammar", p: Matches("f", `a\(b\)\|c`), want: `f.matches(a\x28b\x29\x7Cc)`},
+		{name: "matches class", p: Matches("f", "[|,&]x"), want: `f.matches([\x7C\x2C\x26]x)`},
+		{name: "matches literal grammar", p: Matches("f", "a=b!<c>,d"), want: `f.matches(a\x3Db\x21\x3Cc\x3E\x2Cd)`},
+		{name: "matches quoted", p: Matches("f", `\Qa|b\E.*`), want: `f.matches(\Qa\E\x7C\Qb\E.*)`},
+		{name: "matches quantifier", p: Matches("f", "a{2}"), want: "f.matches(a{2})"},
+		{name: "matches group", p: Matches("f", "(a|b)c"), wantErr: "groups are not supported"},
+		{name: "matches flags", p: Matches("f", "(?i)web"), wantErr: "groups are not supported"},
+		{name: "matches intersection", p: Matches("f", "[a-z&&[^e]]"), wantErr: "class intersections"},
+		{name: "matches range quantifier", p: Matches("f", "a{2,3}"), wantErr: "quantifiers"},
+		{name: "matches trailing backslash", p: Matches("f", `a\`), wantErr: "trailing backslash"},
+		{name: "matches newline", p: Matches("f", "a\nb"), wantErr: "invalid value"},
+		{name: "value injection", p: Eq("a", "1&b=2"), wantErr: "must not contain"},
+		{name: "in value injection", p: In("a", "1)|(b"), wantErr: "must not contain"},
+		{name: "invalid field", p: Eq("a=1&b", "2"), wantErr: "invalid predicate field"},
+		{name: "empty in", p: In("a"), wantErr: "at least one value"},
+		{name: "empty and", p: And(), wantErr: "empty"},
+		{name: "nested invalid", p: Or(Eq("a", "1"), Not(Eq("b", "|"))), wantErr: "must not contain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.Validate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Validate() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			// Compared decoded so the expectations stay readable
+			got, err := url.QueryUnescape(tt.p.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPredicateEncodesValues(t *testing.T) {
+	if got, want := Eq("Hosts/rack_info", "/r1 a+b").String(), "Hosts/rack_info=%2Fr1+a%2Bb"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got, want := Matches("f", `a\|b`).String(), "f.matches(a%5Cx7Cb)"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultRetries is the number of retries when a configuration sets none
+const DefaultRetries = 3
+
+// Backoff between attempts. A Retry-After sent by Ambari or a proxy replaces
+// the computed delay, up to maxRetryAfter.
+const (
+	retryBaseDelay = 200 * time.Millisecond
+	retryMaxDelay  = 5 * time.Second
+	maxRetryAfter  = 30 * time.Second
+)
+
+// transportError is a failure to get any response. dialFailed means the
+// connection was never established, so the request cannot have landed.
+type transportError struct {
+	err        error
+	dialFailed bool
+}
+
+func (e *transportError) Error() string { return e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
+
+func newTransportError(err error) *transportError {
+	var opErr *net.OpError
+	return &transportError{err: err, dialFailed: errors.As(err, &opErr) && opErr.Op == "dial"}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// IdempotencyGuard reports whether a POST whose outcome is unknown (timeout,
+// dropped connection, gateway error) was applied by Ambari anyway. The client
+// retries such a POST only when its guard confirms it did not land.
+type IdempotencyGuard func(ctx context.Context) (landed bool, err error)
+
+type guardKey struct{}
+
+type guardEntry struct {
+	path  string
+	guard IdempotencyGuard
+}
+
+// WithIdempotencyGuard returns a context in which failed POSTs to path may be
+// retried after guard confirms the first attempt did not land
+func WithIdempotencyGuard(ctx context.Context, path string, guard IdempotencyGuard) context.Context {
+	return context.WithValue(ctx, guardKey{}, guardEntry{path: path, guard: guard})
+}
+
+func guardFor(ctx context.Context, path string) IdempotencyGuard {
+	if e, ok := ctx.Value(guardKey{}).(guardEntry); ok && e.path == path {
+		return e.guard
+	}
+	return nil
+}
+
+// ResourceExists is a guard for POSTs that create the resource at path: the
+// POST landed if the resource now exists
+func ResourceExists(c AmbariClient, path string) IdempotencyGuard {
+	return func(ctx context.Context) (bool, error) {
+		_, err := c.Get(ctx, path, map[string]string{"fields": "*"})
+		switch {
+		case err == nil:
+			return true, nil
+		case IsNotFound(err):
+			return false, nil
+		}
+		return false, err
+	}
+}
+
+// RequestCreatedSince is a guard for POSTs to /clusters/{cluster}/requests:
+// the POST landed if a request with the same context was created after since.
+// Clock skew between this host and Ambari is absorbed by looking back an
+// extra minute, which errs on the side of not retrying.
+func RequestCreatedSince(c AmbariClient, cluster, requestContext string, since time.Time) IdempotencyGuard {
+	return func(ctx context.Context) (bool, error) {
+		params, err := WithPredicate(map[string]string{"fields": "Requests/id"}, And(
+			Eq("Requests/request_context", requestContext),
+			Ge("Requests/create_time", strconv.FormatInt(since.Add(-time.Minute).UnixMilli(), 10)),
+		))
+		if err != nil {
+			return false, err
+		}
+		resp, err := c.Get(ctx, fmt.Sprintf("/clusters/%s/requests", cluster), params)
+		if err != nil {
+			return false, err
+		}
+		items, _ := resp["items"].([]interface{})
+		return len(items) > 0, nil
+	}
+}
+
+// retryDelay decides whether a failed attempt is retried and how long to wait.
+// Only network errors and 429/502/503/504 are retried; a POST is retried only
+// when the request provably was not processed (refused connection, 429) or
+// its idempotency guard says so.
+func (c *ambariClient) retryDelay(ctx context.Context, method, path string, attempt int, err error) (time.Duration, error) {
+	if ctx.Err() != nil {
+		return 0, err
+	}
+	var (
+		retryAfter time.Duration
+		notApplied bool
+		te         *transportError
+		ae         *AmbariError
+	)
+	switch {
+	case errors.As(err, &te):
+		notApplied = te.dialFailed
+	case errors.As(err, &ae):
+		if !ae.Retryable {
+			return 0, err
+		}
+		notApplied = ae.Status == http.StatusTooManyRequests
+		retryAfter = ae.RetryAfter
+	default:
+		return 0, err
+	}
+
+	if method == http.MethodPost && !notApplied {
+		guard := guardFor(ctx, path)
+		if guard == nil {
+			return 0, fmt.Errorf("not retrying POST %s, it may have been applied: %w", path, err)
+		}
+		landed, gerr := guard(ctx)
+		if gerr != nil {
+			return 0, fmt.Errorf("not retrying POST %s, checking whether it was applied failed (%v): %w", path, gerr, err)
+		}
+		if landed {
+			return 0, fmt.Errorf("POST %s was applied despite the error; not retrying: %w", path, err)
+		}
+		c.logger.WithFields(logrus.Fields{"path": path}).Debug("Idempotency guard confirmed POST did not land")
+	}
+
+	if retryAfter > 0 {
+		if retryAfter > maxRetryAfter {
+			retryAfter = maxRetryAfter
+		}
+		return retryAfter, nil
+	}
+	// Exponential backoff with equal jitter: half fixed, half random
+	d := retryBaseDelay << attempt
+	if d > retryMaxDelay || d <= 0 {
+		d = retryMaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1)), nil
+}
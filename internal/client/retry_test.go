@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	dialErr := newTransportError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+	resetErr := newTransportError(&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")})
+	status := func(code int, retryAfter string) error {
+		return newAmbariError(code, "", "/clusters", "", nil, http.Header{"Retry-After": {retryAfter}})
+	}
+	guard := func(landed bool, err error) IdempotencyGuard {
+		return func(context.Context) (bool, error) { return landed, err }
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		method  string
+		err     error
+		guard   IdempotencyGuard
+		want    time.Duration // exact delay; 0 means backoff
+		wantErr string        // non-empty: not retried
+	}{
+		{name: "GET dial failure", method: http.MethodGet, err: dialErr},
+		{name: "GET reset connection", method: http.MethodGet, err: resetErr},
+		{name: "GET wrapped transport error", method: http.MethodGet, err: fmt.Errorf("HTTP GET /clusters failed: %w", resetErr)},
+		{name: "GET 502", method: http.MethodGet, err: status(http.StatusBadGateway, "")},
+		{name: "GET 503", method: http.MethodGet, err: status(http.StatusServiceUnavailable, "")},
+		{name: "GET 504", method: http.MethodGet, err: status(http.StatusGatewayTimeout, "")},
+		{name: "GET 503 with Retry-After", method: http.MethodGet, err: status(http.StatusServiceUnavailable, "7"), want: 7 * time.Second},
+		{name: "Retry-After capped", method: http.MethodGet, err: status(http.StatusServiceUnavailable, "3600"), want: maxRetryAfter},
+		{name: "GET 500", method: http.MethodGet, err: status(http.StatusInternalServerError, ""), wantErr: "HTTP 500"},
+		{name: "GET 404", method: http.MethodGet, err: status(http.StatusNotFound, ""), wantErr: "HTTP 404"},
+		{name: "GET 401", method: http.MethodGet, err: status(http.StatusUnauthorized, ""), wantErr: "HTTP 401"},
+		{name: "other error", method: http.MethodGet, err: errors.New("marshal body"), wantErr: "marshal body"},
+		{name: "canceled", ctx: canceled, method: http.MethodGet, err: dialErr, wantErr: "connection refused"},
+		{name: "PUT reset connection", method: http.MethodPut, err: resetErr},
+		{name: "DELETE 504", method: http.MethodDelete, err: status(http.StatusGatewayTimeout, "")},
+		{name: "POST dial failure", method: http.MethodPost, err: dialErr},
+		{name: "POST 429", method: http.MethodPost, err: status(http.StatusTooManyRequests, "2"), want: 2 * time.Second},
+		{name: "POST reset without guard", method: http.MethodPost, err: resetErr, wantErr: "may have been applied"},
+		{name: "POST 504 without guard", method: http.MethodPost, err: status(http.StatusGatewayTimeout, ""), wantErr: "may have been applied"},
+		{name: "POST 500 with guard", method: http.MethodPost, err: status(http.StatusInternalServerError, ""), guard: guard(false, nil), wantErr: "HTTP 500"},
+		{name: "POST guard says not applied", method: http.MethodPost, err: resetErr, guard: guard(false, nil)},
+		{name: "POST guard says applied", method: http.MethodPost, err: status(http.StatusBadGateway, ""), guard: guard(true, nil), wantErr: "was applied despite the error"},
+		{name: "POST guard fails", method: http.MethodPost, err: resetErr, guard: guard(false, errors.New("timeout")), wantErr: "checking whether it was applied failed (timeout)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &ambariClient{logger: testLogger()}
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if tt.guard != nil {
+				ctx = WithIdempotencyGuard(ctx, "/clusters", tt.guard)
+			}
+			d, err := c.retryDelay(ctx, tt.method, "/clusters", 1, tt.err)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("retryDelay() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if !errors.Is(err, tt.err) {
+					t.Errorf("retryDelay() error = %v does not wrap %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("retryDelay() error = %v, want a retry", err)
+			}
+			if tt.want != 0 {
+				if d != tt.want {
+					t.Errorf("retryDelay() = %v, want %v", d, tt.want)
+				}
+				return
+			}
+			// Attempt 1 backs off between half and all of twice the base delay
+			if d < retryBaseDelay || d > 2*retryBaseDelay {
+				t.Errorf("retryDelay() = %v, want between %v and %v", d, retryBaseDelay, 2*retryBaseDelay)
+			}
+		})
+	}
+}
+
+func TestRetryBackoffBounded(t *testing.T) {
+	c := &ambariClient{logger: testLogger()}
+	err := newTransportError(&net.OpError{Op: "dial", Err: errors.New("connection refused")})
+	for attempt := 0; attempt < 70; attempt++ {
+		d, stop := c.retryDelay(context.Background(), http.MethodGet, "/clusters", attempt, err)
+		if stop != nil {
+			t.Fatal(stop)
+		}
+		if d <= 0 || d > retryMaxDelay {
+			t.Fatalf("attempt %d: backoff %v outside (0, %v]", attempt, d, retryMaxDelay)
+		}
+	}
+}
+
+func TestGuardOnlyCoversItsPath(t *testing.T) {
+	c := &ambariClient{logger: testLogger()}
+	ctx := WithIdempotencyGuard(context.Background(), "/clusters/c1/requests", func(context.Context) (bool, error) { return false, nil })
+	err := newTransportError(errors.New("EOF"))
+	if _, stop := c.retryDelay(ctx, http.MethodPost, "/clusters/c1/services", 0, err); stop == nil {
+		t.Fatal("POST to another path was retried under the guard")
+	}
+	if _, stop := c.retryDelay(ctx, http.MethodPost, "/clusters/c1/requests", 0, err); stop != nil {
+		t.Fatalf("guarded POST not retried: %v", stop)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{value: "", min: 0, max: 0},
+		{value: "5", min: 5 * time.Second, max: 5 * time.Second},
+		{value: " 5 ", min: 5 * time.Second, max: 5 * time.Second},
+		{value: "0", min: 0, max: 0},
+		{value: "-3", min: 0, max: 0},
+		{value: "soon", min: 0, max: 0},
+		{value: time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), min: 8 * time.Second, max: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+				t.Errorf("parseRetryAfter(%q) = %v, want between %v and %v", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestRetryAttempts(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int
+		guard        bool
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{name: "GET recovers", method: http.MethodGet, statuses: []int{503, 502, 200}, wantAttempts: 3},
+		{name: "GET gives up", method: http.MethodGet, statuses: []int{503, 503, 503, 503, 200}, wantAttempts: 3, wantErr: true},
+		{name: "GET not retried on 500", method: http.MethodGet, statuses: []int{500, 200}, wantAttempts: 1, wantErr: true},
+		{name: "POST not retried on 503", method: http.MethodPost, statuses: []int{503, 200}, wantAttempts: 1, wantErr: true},
+		{name: "POST retried on 429", method: http.MethodPost, statuses: []int{429, 200}, wantAttempts: 2},
+		{name: "guarded POST retried", method: http.MethodPost, statuses: []int{504, 200}, guard: true, wantAttempts: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var n int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet && r.URL.Path == "/api/v1/clusters/c1/services/HDFS" {
+					http.Error(w, `{"status":404}`, http.StatusNotFound)
+					return
+				}
+				i := atomic.AddInt32(&n, 1) - 1
+				w.WriteHeader(tt.statuses[i])
+				fmt.Fprint(w, `{}`)
+			}))
+			defer srv.Close()
+			c, err := NewAmbariClient(Config{BaseURL: srv.URL + "/api/v1", Retries: 2}, testLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			if tt.guard {
+				ctx = WithIdempotencyGuard(ctx, "/clusters/c1/services", ResourceExists(c, "/clusters/c1/services/HDFS"))
+			}
+			if tt.method == http.MethodPost {
+				_, err = c.Post(ctx, "/clusters/c1/services", nil, map[string]interface{}{})
+			} else {
+				_, err = c.Get(ctx, "/clusters/c1/services", nil)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&n); got != tt.wantAttempts {
+				t.Errorf("sent %d attempts, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TLSConfig configures HTTPS connections to Ambari
+type TLSConfig struct {
+	// CAFile is a PEM bundle that replaces the system roots when set
+	CAFile string `json:"ca_file"`
+	// CertFile and KeyFile hold a client certificate presented to Ambari
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+	// ServerName overrides the name verified against Ambari's certificate
+	ServerName string `json:"server_name"`
+	// MinVersion is "1.2" (default) or "1.3"
+	MinVersion string `json:"min_version"`
+	// InsecureSkipVerify disables certificate verification entirely
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+	// PinnedSHA256 are SHA-256 fingerprints of the SubjectPublicKeyInfo of
+	// Ambari's certificate or of a CA in its verified chain, in base64 or
+	// hex; when set, one of them must match
+	PinnedSHA256 []string `json:"pinned_sha256"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// build converts the configuration into a *tls.Config
+func (c TLSConfig) build() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.MinVersion != "" {
+		v, ok := tlsVersions[strings.TrimPrefix(strings.ToLower(c.MinVersion), "tls")]
+		if !ok {
+			return nil, fmt.Errorf("unsupported minimum TLS version %q (supported: 1.2, 1.3)", c.MinVersion)
+		}
+		cfg.MinVersion = v
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read Ambari CA bundle %s: %w", c.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in Ambari CA bundle %s", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, fmt.Errorf("Ambari client certificate requires both cert and key files")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load Ambari client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if len(c.PinnedSHA256) > 0 {
+		pins, err := parsePins(c.PinnedSHA256)
+		if err != nil {
+			return nil, err
+		}
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error { return checkPins(cs, pins) }
+	}
+	return cfg, nil
+}
+
+// parsePins decodes SPKI fingerprints given in base64 (as in "openssl ... |
+// base64") or hex, with or without colons
+func parsePins(values []string) ([][]byte, error) {
+	pins := make([][]byte, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "sha256/")
+		pin, err := hex.DecodeString(strings.ReplaceAll(v, ":", ""))
+		if err != nil || len(pin) != sha256.Size {
+			pin, err = base64.StdEncoding.DecodeString(v)
+		}
+		if err != nil || len(pin) != sha256.Size {
+			return nil, fmt.Errorf("invalid Ambari certificate pin %q (expected a base64 or hex SHA-256 fingerprint)", v)
+		}
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
+
+// checkPins accepts a connection when a certificate of its verified chains
+// matches a pin. Without verification (InsecureSkipVerify) only the leaf
+// counts, since the other certificates sent prove nothing.
+func checkPins(cs tls.ConnectionState, pins [][]byte) error {
+	var certs []*x509.Certificate
+	for _, chain := range cs.VerifiedChains {
+		certs = append(certs, chain...)
+	}
+	if len(cs.VerifiedChains) == 0 && len(cs.PeerCertificates) > 0 {
+		certs = cs.PeerCertificates[:1]
+	}
+	for _, cert := range certs {
+		sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		for _, pin := range pins {
+			if subtle.ConstantTimeCompare(sum[:], pin) == 1 {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("Ambari certificate does not match any pinned SHA-256 fingerprint")
+}
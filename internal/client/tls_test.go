@@ -0,0 +1,111 @@
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTLSConfigBuild(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name           string
+		cfg            TLSConfig
+		wantMinVersion uint16
+		wantErr        string
+	}{
+		{name: "defaults", wantMinVersion: tls.VersionTLS12},
+		{name: "TLS 1.3", cfg: TLSConfig{MinVersion: "1.3"}, wantMinVersion: tls.VersionTLS13},
+		{name: "TLS prefix", cfg: TLSConfig{MinVersion: "TLS1.3"}, wantMinVersion: tls.VersionTLS13},
+		{name: "bad min version", cfg: TLSConfig{MinVersion: "1.1"}, wantErr: "unsupported minimum TLS version"},
+		{name: "missing CA bundle", cfg: TLSConfig{CAFile: filepath.Join(dir, "missing.pem")}, wantErr: "read Ambari CA bundle"},
+		{name: "CA bundle without certificates", cfg: TLSConfig{CAFile: empty}, wantErr: "no certificates found"},
+		{name: "cert without key", cfg: TLSConfig{CertFile: empty}, wantErr: "requires both cert and key"},
+		{name: "key without cert", cfg: TLSConfig{KeyFile: empty}, wantErr: "requires both cert and key"},
+		{name: "unreadable key pair", cfg: TLSConfig{CertFile: empty, KeyFile: empty}, wantErr: "load Ambari client certificate"},
+		{name: "invalid pin", cfg: TLSConfig{PinnedSHA256: []string{"abc"}}, wantErr: "invalid Ambari certificate pin"},
+		{name: "short pin", cfg: TLSConfig{PinnedSHA256: []string{hex.EncodeToString(make([]byte, 20))}}, wantErr: "invalid Ambari certificate pin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := tt.cfg.build()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("build() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("build() error = %v", err)
+			}
+			if cfg.MinVersion != tt.wantMinVersion {
+				t.Errorf("MinVersion = %x, want %x", cfg.MinVersion, tt.wantMinVersion)
+			}
+		})
+	}
+}
+
+func TestTLSPinning(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	block := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(ca, block, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(srv.Certificate().RawSubjectPublicKeyInfo)
+	other := sha256.Sum256([]byte("another key"))
+	colons := strings.ToUpper(hex.EncodeToString(sum[:]))
+	for i := len(colons) - 2; i > 0; i -= 2 {
+		colons = colons[:i] + ":" + colons[i:]
+	}
+
+	tests := []struct {
+		name    string
+		cfg     TLSConfig
+		wantErr string
+	}{
+		{name: "base64 pin", cfg: TLSConfig{CAFile: ca, PinnedSHA256: []string{base64.StdEncoding.EncodeToString(sum[:])}}},
+		{name: "hex pin", cfg: TLSConfig{CAFile: ca, PinnedSHA256: []string{hex.EncodeToString(sum[:])}}},
+		{name: "colon-separated pin", cfg: TLSConfig{CAFile: ca, PinnedSHA256: []string{colons}}},
+		{name: "one of several pins", cfg: TLSConfig{CAFile: ca, PinnedSHA256: []string{hex.EncodeToString(other[:]), hex.EncodeToString(sum[:])}}},
+		{name: "pin mismatch", cfg: TLSConfig{CAFile: ca, PinnedSHA256: []string{hex.EncodeToString(other[:])}}, wantErr: "does not match any pinned"},
+		{name: "pin without verification", cfg: TLSConfig{InsecureSkipVerify: true, PinnedSHA256: []string{hex.EncodeToString(sum[:])}}},
+		{name: "mismatch without verification", cfg: TLSConfig{InsecureSkipVerify: true, PinnedSHA256: []string{hex.EncodeToString(other[:])}}, wantErr: "does not match any pinned"},
+		{name: "untrusted certificate", cfg: TLSConfig{PinnedSHA256: []string{hex.EncodeToString(sum[:])}}, wantErr: "certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := tt.cfg.build()
+			if err != nil {
+				t.Fatalf("build() error = %v", err)
+			}
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+			resp, err := client.Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GET error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+		})
+	}
+}
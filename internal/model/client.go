@@ -0,0 +1,240 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"mcp-ambari/internal/client"
+)
+
+// Client reads typed resources through an AmbariClient. Requests go through
+// the wrapped client, so instance routing and per-user credentials on ctx apply.
+type Client struct {
+	raw client.AmbariClient
+}
+
+// NewClient wraps c
+func NewClient(c client.AmbariClient) *Client {
+	return &Client{raw: c}
+}
+
+// Raw returns the wrapped client for requests without a typed view
+func (c *Client) Raw() client.AmbariClient {
+	return c.raw
+}
+
+// pageSize is the page size used to walk collections
+const pageSize = 500
+
+// list fetches every item of a collection, page by page in sortBy order.
+// Collections without a sort order are fetched in one request.
+func (c *Client) list(ctx context.Context, path string, params map[string]string, sortBy string) ([]map[string]interface{}, error) {
+	req := client.PageRequest{SortBy: sortBy}
+	if sortBy != "" {
+		req.PageSize = pageSize
+	}
+	items, err := client.GetAll(ctx, c.raw, path, params, req)
+	if err != nil {
+		return nil, err
+	}
+	return Items(map[string]interface{}{"items": items}), nil
+}
+
+func clusterPath(cluster, format string, args ...interface{}) string {
+	return "/clusters/" + url.PathEscape(cluster) + fmt.Sprintf(format, args...)
+}
+
+// Clusters lists all clusters
+func (c *Client) Clusters(ctx context.Context) ([]Cluster, error) {
+	items, err := c.list(ctx, "/clusters", map[string]string{"fields": "Clusters/*"}, "Clusters/cluster_name.asc")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Cluster, 0, len(items))
+	for _, item := range items {
+		out = append(out, DecodeCluster(item))
+	}
+	return out, nil
+}
+
+// Cluster returns one cluster
+func (c *Client) Cluster(ctx context.Context, name string) (Cluster, error) {
+	resp, err := c.raw.Get(ctx, clusterPath(name, ""), map[string]string{"fields": "Clusters/*"})
+	if err != nil {
+		return Cluster{}, err
+	}
+	return DecodeCluster(resp), nil
+}
+
+// Services lists the services of a cluster with their components
+func (c *Client) Services(ctx context.Context, cluster string) ([]Service, error) {
+	items, err := c.list(ctx, clusterPath(cluster, "/services"), map[string]string{
+		"fields": "ServiceInfo/*,components/ServiceComponentInfo/*",
+	}, "ServiceInfo/service_name.asc")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Service, 0, len(items))
+	for _, item := range items {
+		out = append(out, DecodeService(item))
+	}
+	return out, nil
+}
+
+// Service returns one service with its components and their host components
+func (c *Client) Service(ctx context.Context, cluster, service string) (Service, error) {
+	resp, err := c.raw.Get(ctx, clusterPath(cluster, "/services/%s", url.PathEscape(service)), map[string]string{
+		"fields": "ServiceInfo/*,components/ServiceComponentInfo/*,components/host_components/HostRoles/*",
+	})
+	if err != nil {
+		return Service{}, err
+	}
+	return DecodeService(resp), nil
+}
+
+// HostComponents lists the host components of a cluster; params are added as
+// query predicates (e.g. "HostRoles/stale_configs": "true")
+func (c *Client) HostComponents(ctx context.Context, cluster string, params map[string]string) ([]HostComponent, error) {
+	query := map[string]string{"fields": "HostRoles/*"}
+	for k, v := range params {
+		query[k] = v
+	}
+	items, err := c.list(ctx, clusterPath(cluster, "/host_components"), query, "HostRoles/host_name.asc,HostRoles/component_name.asc")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]HostComponent, 0, len(items))
+	for _, item := range items {
+		out = append(out, DecodeHostComponent(item))
+	}
+	return out, nil
+}
+
+// Hosts lists the hosts of a cluster
+func (c *Client) Hosts(ctx context.Context, cluster string) ([]Host, error) {
+	items, err := c.list(ctx, clusterPath(cluster, "/hosts"), map[string]string{
+		"fields": "Hosts/*,host_components/HostRoles/component_name,host_components/HostRoles/state",
+	}, "Hosts/host_name.asc")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Host, 0, len(items))
+	for _, item := range items {
+		out = append(out, DecodeHost(item))
+	}
+	return out, nil
+}
+
+// Alerts lists the current alerts of a cluster; params are added as query predicates
+func (c *Client) Alerts(ctx context.Context, cluster string, params map[string]string) ([]Alert, error) {
+	query := map[string]string{"fields": "Alert/*"}
+	for k, v := range params {
+		query[k] = v
+	}
+	items, err := c.list(ctx, clusterPath(cluster, "/alerts"), query, "Alert/id.asc")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Alert, 0, len(items))
+	for _, item := range items {
+		out = append(out, DecodeAlert(item))
+	}
+	return out, nil
+}
+
+// AlertDefinitions lists the alert definitions of a cluster
+func (c *Client) AlertDefinitions(ctx context.Context, cluster string) ([]AlertDefinition, error) {
+	items, err := c.list(ctx, clusterPath(cluster, "/alert_definitions"), map[string]string{"fields": "AlertDefinition/*"}, "AlertDefinition/id.asc")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]AlertDefinition, 0, len(items))
+	for _, item := range items {
+		out = append(out, DecodeAlertDefinition(item))
+	}
+	return out, nil
+}
+
+// Requests lists the most recent requests of a cluster, newest first
+func (c *Client) Requests(ctx context.Context, cluster string, limit int) ([]Request, error) {
+	page, err := client.GetPage(ctx, c.raw, clusterPath(cluster, "/requests"), map[string]string{"fields": "Requests/*"},
+		client.PageRequest{PageSize: limit, SortBy: "Requests/id.desc"})
+	if err != nil {
+		return nil, err
+	}
+	items := Items(page.Response)
+	out := make([]Request, 0, len(items))
+	for _, item := range items {
+		out = append(out, DecodeRequest(item))
+	}
+	return out, nil
+}
+
+// Request returns one request with its tasks
+func (c *Client) Request(ctx context.Context, cluster string, id int64) (Request, error) {
+	resp, err := c.raw.Get(ctx, clusterPath(cluster, "/requests/%d", id), map[string]string{
+		"fields": "Requests/*,tasks/Tasks/*",
+	})
+	if err != nil {
+		return Request{}, err
+	}
+	return DecodeRequest(resp), nil
+}
+
+// ConfigTypes lists the configuration versions of a cluster; params are added
+// as query predicates (e.g. "type": "hdfs-site", "fields": "properties")
+func (c *Client) ConfigTypes(ctx context.Context, cluster string, params map[string]string) ([]ConfigType, error) {
+	query := map[string]string{}
+	for k, v := range params {
+		query[k] = v
+	}
+	items, err := c.list(ctx, clusterPath(cluster, "/configurations"), query, "")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ConfigType, 0, len(items))
+	for _, item := range items {
+		ct := DecodeConfigType(item)
+		if ct.Cluster == "" {
+			ct.Cluster = cluster
+		}
+		out = append(out, ct)
+	}
+	return out, nil
+}
+
+// Users lists Ambari users
+func (c *Client) Users(ctx context.Context) ([]User, error) {
+	items, err := c.list(ctx, "/users", map[string]string{"fields": "Users/*"}, "Users/user_name.asc")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]User, 0, len(items))
+	for _, item := range items {
+		out = append(out, DecodeUser(item))
+	}
+	return out, nil
+}
+
+// User returns one user with the groups it belongs to
+func (c *Client) User(ctx context.Context, name string) (User, error) {
+	resp, err := c.raw.Get(ctx, "/users/"+url.PathEscape(name), map[string]string{"fields": "Users/*"})
+	if err != nil {
+		return User{}, err
+	}
+	return DecodeUser(resp), nil
+}
+
+// Groups lists Ambari groups with their members
+func (c *Client) Groups(ctx context.Context) ([]Group, error) {
+	items, err := c.list(ctx, "/groups", map[string]string{"fields": "Groups/*,members/MemberInfo/user_name"}, "Groups/group_name.asc")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Group, 0, len(items))
+	for _, item := range items {
+		out = append(out, DecodeGroup(item))
+	}
+	return out, nil
+}
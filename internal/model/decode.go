@@ -0,0 +1,425 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ambari's JSON differs between versions: numbers are sometimes strings,
+// fields get renamed (host_state → host_status, ldap_user → user_type) and
+// configuration metadata moves between the item and its Config object. The
+// helpers below read a field from the first key present and convert loosely,
+// so decoding never fails on a shape it does not recognize.
+
+// Items returns the objects of a collection response
+func Items(resp map[string]interface{}) []map[string]interface{} {
+	raw, _ := resp["items"].([]interface{})
+	items := make([]map[string]interface{}, 0, len(raw))
+	for _, item := range raw {
+		if m, ok := item.(map[string]interface{}); ok {
+			items = append(items, m)
+		}
+	}
+	return items
+}
+
+// object returns the nested object m[key], or an empty map
+func object(m map[string]interface{}, key string) map[string]interface{} {
+	if v, ok := m[key].(map[string]interface{}); ok {
+		return v
+	}
+	return map[string]interface{}{}
+}
+
+// objects returns the nested list of objects m[key]
+func objects(m map[string]interface{}, key string) []map[string]interface{} {
+	return Items(map[string]interface{}{"items": m[key]})
+}
+
+func str(m map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch v := m[key].(type) {
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		case json.Number:
+			return v.String()
+		case bool:
+			return strconv.FormatBool(v)
+		}
+	}
+	return ""
+}
+
+func num(m map[string]interface{}, keys ...string) float64 {
+	for _, key := range keys {
+		switch v := m[key].(type) {
+		case float64:
+			return v
+		case json.Number:
+			f, _ := v.Float64()
+			return f
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f
+			}
+		}
+	}
+	return 0
+}
+
+func integer(m map[string]interface{}, keys ...string) int64 {
+	return int64(num(m, keys...))
+}
+
+func boolean(m map[string]interface{}, keys ...string) bool {
+	for _, key := range keys {
+		switch v := m[key].(type) {
+		case bool:
+			return v
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err == nil {
+				return b
+			}
+		}
+	}
+	return false
+}
+
+// millis converts an epoch-milliseconds field; Ambari uses -1 or 0 for "not set"
+func millis(m map[string]interface{}, keys ...string) *time.Time {
+	ms := integer(m, keys...)
+	if ms <= 0 {
+		return nil
+	}
+	t := time.UnixMilli(ms).UTC()
+	return &t
+}
+
+func stringList(m map[string]interface{}, key string) []string {
+	raw, _ := m[key].([]interface{})
+	var out []string
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// DecodeCluster decodes an item with a Clusters object
+func DecodeCluster(item map[string]interface{}) Cluster {
+	info := object(item, "Clusters")
+	c := Cluster{
+		ID:           integer(info, "cluster_id"),
+		Name:         str(info, "cluster_name"),
+		Version:      str(info, "version"),
+		SecurityType: str(info, "security_type"),
+		State:        str(info, "provisioning_state", "state"),
+		TotalHosts:   integer(info, "total_hosts"),
+	}
+	if report := object(info, "health_report"); len(report) > 0 {
+		c.HealthReport = make(map[string]int64, len(report))
+		for k := range report {
+			c.HealthReport[k] = integer(report, k)
+		}
+	}
+	if desired := object(info, "desired_configs"); len(desired) > 0 {
+		c.DesiredConfigs = make(map[string]ConfigRef, len(desired))
+		for typ, v := range desired {
+			ref, _ := v.(map[string]interface{})
+			c.DesiredConfigs[typ] = ConfigRef{Tag: str(ref, "tag"), Version: integer(ref, "version")}
+		}
+	}
+	return c
+}
+
+// DecodeService decodes an item with a ServiceInfo object and optional components
+func DecodeService(item map[string]interface{}) Service {
+	info := object(item, "ServiceInfo")
+	s := Service{
+		Cluster:          str(info, "cluster_name"),
+		Name:             str(info, "service_name"),
+		State:            str(info, "state"),
+		MaintenanceState: str(info, "maintenance_state"),
+	}
+	for _, comp := range objects(item, "components") {
+		s.Components = append(s.Components, DecodeServiceComponent(comp))
+	}
+	return s
+}
+
+// DecodeServiceComponent decodes an item with a ServiceComponentInfo object
+func DecodeServiceComponent(item map[string]interface{}) ServiceComponent {
+	info := object(item, "ServiceComponentInfo")
+	c := ServiceComponent{
+		Cluster:        str(info, "cluster_name"),
+		Service:        str(info, "service_name"),
+		Name:           str(info, "component_name"),
+		Category:       str(info, "category"),
+		State:          str(info, "state"),
+		StartedCount:   integer(info, "started_count"),
+		InstalledCount: integer(info, "installed_count"),
+		TotalCount:     integer(info, "total_count"),
+	}
+	for _, hc := range objects(item, "host_components") {
+		c.HostComponents = append(c.HostComponents, DecodeHostComponent(hc))
+	}
+	return c
+}
+
+// DecodeHostComponent decodes an item with a HostRoles object
+func DecodeHostComponent(item map[string]interface{}) HostComponent {
+	info := object(item, "HostRoles")
+	return HostComponent{
+		Cluster:          str(info, "cluster_name"),
+		Service:          str(info, "service_name"),
+		Component:        str(info, "component_name"),
+		Host:             str(info, "host_name"),
+		State:            str(info, "state"),
+		DesiredState:     str(info, "desired_state"),
+		StaleConfigs:     boolean(info, "stale_configs"),
+		MaintenanceState: str(info, "maintenance_state"),
+	}
+}
+
+// DecodeHost decodes an item with a Hosts object and optional host_components
+func DecodeHost(item map[string]interface{}) Host {
+	info := object(item, "Hosts")
+	h := Host{
+		Cluster:          str(info, "cluster_name"),
+		Name:             str(info, "host_name"),
+		IP:               str(info, "ip"),
+		Status:           str(info, "host_status"),
+		State:            str(info, "host_state"),
+		OSType:           str(info, "os_type"),
+		Rack:             str(info, "rack_info"),
+		CPUCount:         integer(info, "cpu_count"),
+		TotalMemKB:       integer(info, "total_mem"),
+		MaintenanceState: str(info, "maintenance_state"),
+		LastHeartbeat:    millis(info, "last_heartbeat_time"),
+	}
+	// Ambari before 2.0 only reports host_state
+	if h.Status == "" {
+		h.Status = h.State
+	}
+	for _, hc := range objects(item, "host_components") {
+		h.Components = append(h.Components, DecodeHostComponent(hc))
+	}
+	return h
+}
+
+// DecodeAlert decodes an item with an Alert object
+func DecodeAlert(item map[string]interface{}) Alert {
+	info := object(item, "Alert")
+	return Alert{
+		ID:               integer(info, "id"),
+		Cluster:          str(info, "cluster_name"),
+		DefinitionID:     integer(info, "definition_id"),
+		DefinitionName:   str(info, "definition_name"),
+		Label:            str(info, "label"),
+		Service:          str(info, "service_name"),
+		Component:        str(info, "component_name"),
+		Host:             str(info, "host_name"),
+		State:            str(info, "state"),
+		Text:             str(info, "text"),
+		MaintenanceState: str(info, "maintenance_state"),
+		Latest:           millis(info, "latest_timestamp"),
+		Original:         millis(info, "original_timestamp"),
+	}
+}
+
+// DecodeAlertDefinition decodes an item with an AlertDefinition object
+func DecodeAlertDefinition(item map[string]interface{}) AlertDefinition {
+	info := object(item, "AlertDefinition")
+	return AlertDefinition{
+		ID:         integer(info, "id"),
+		Cluster:    str(info, "cluster_name"),
+		Name:       str(info, "name"),
+		Label:      str(info, "label"),
+		Service:    str(info, "service_name"),
+		Component:  str(info, "component_name"),
+		Enabled:    boolean(info, "enabled"),
+		Interval:   integer(info, "interval"),
+		Scope:      str(info, "scope"),
+		SourceType: str(object(info, "source"), "type"),
+	}
+}
+
+// DecodeAlertSummaries decodes a format=groupedSummary alerts response
+func DecodeAlertSummaries(resp map[string]interface{}) []AlertSummary {
+	var out []AlertSummary
+	for _, group := range objects(resp, "alerts_summary_grouped") {
+		s := AlertSummary{
+			DefinitionID:   integer(group, "definition_id"),
+			DefinitionName: str(group, "definition_name"),
+			Counts:         map[string]int64{},
+		}
+		for state, v := range object(group, "summary") {
+			counts, _ := v.(map[string]interface{})
+			s.Counts[state] = integer(counts, "count")
+			if n := integer(counts, "maintenance_count"); n > 0 {
+				if s.MaintenanceCounts == nil {
+					s.MaintenanceCounts = map[string]int64{}
+				}
+				s.MaintenanceCounts[state] = n
+			}
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// DecodeAlertGroup decodes an item with an AlertGroup object
+func DecodeAlertGroup(item map[string]interface{}) AlertGroup {
+	info := object(item, "AlertGroup")
+	g := AlertGroup{
+		ID:      integer(info, "id"),
+		Cluster: str(info, "cluster_name"),
+		Name:    str(info, "name"),
+		Default: boolean(info, "default"),
+	}
+	for _, d := range objects(info, "definitions") {
+		g.Definitions = append(g.Definitions, str(d, "name"))
+	}
+	for _, t := range objects(info, "targets") {
+		g.Targets = append(g.Targets, str(t, "name"))
+	}
+	return g
+}
+
+// DecodeAlertTarget decodes an item with an AlertTarget object
+func DecodeAlertTarget(item map[string]interface{}) AlertTarget {
+	info := object(item, "AlertTarget")
+	return AlertTarget{
+		ID:               integer(info, "id"),
+		Name:             str(info, "name"),
+		Description:      str(info, "description"),
+		NotificationType: str(info, "notification_type"),
+		Global:           boolean(info, "global"),
+		AlertStates:      stringList(info, "alert_states"),
+	}
+}
+
+// DecodeRequest decodes an item with a Requests object and optional tasks
+func DecodeRequest(item map[string]interface{}) Request {
+	info := object(item, "Requests")
+	r := Request{
+		ID:              integer(info, "id"),
+		Cluster:         str(info, "cluster_name"),
+		Context:         str(info, "request_context"),
+		Status:          str(info, "request_status"),
+		ProgressPercent: num(info, "progress_percent"),
+		TaskCount:       integer(info, "task_count"),
+		CompletedTasks:  integer(info, "completed_task_count"),
+		FailedTasks:     integer(info, "failed_task_count"),
+		Created:         millis(info, "create_time"),
+		Started:         millis(info, "start_time"),
+		Ended:           millis(info, "end_time"),
+	}
+	for _, task := range objects(item, "tasks") {
+		r.Tasks = append(r.Tasks, DecodeTask(task))
+	}
+	return r
+}
+
+// DecodeTask decodes an item with a Tasks object
+func DecodeTask(item map[string]interface{}) Task {
+	info := object(item, "Tasks")
+	return Task{
+		ID:        integer(info, "id"),
+		RequestID: integer(info, "request_id"),
+		Host:      str(info, "host_name"),
+		Role:      str(info, "role"),
+		Command:   str(info, "command", "command_detail"),
+		Status:    str(info, "status"),
+		ExitCode:  integer(info, "exit_code"),
+		Started:   millis(info, "start_time"),
+		Ended:     millis(info, "end_time"),
+	}
+}
+
+// DecodeConfigType decodes a configurations item. type, tag and version are
+// top-level fields of the item in most versions and inside Config in others.
+func DecodeConfigType(item map[string]interface{}) ConfigType {
+	info := object(item, "Config")
+	c := ConfigType{
+		Cluster: str(info, "cluster_name"),
+		Type:    str(item, "type"),
+		Tag:     str(item, "tag"),
+		Version: integer(item, "version"),
+	}
+	if c.Type == "" {
+		c.Type = str(info, "type")
+	}
+	if c.Tag == "" {
+		c.Tag = str(info, "tag")
+	}
+	if c.Version == 0 {
+		c.Version = integer(info, "version")
+	}
+	if props := object(item, "properties"); len(props) > 0 {
+		c.Properties = make(map[string]string, len(props))
+		for k := range props {
+			c.Properties[k] = str(props, k)
+		}
+	}
+	return c
+}
+
+// DecodeUser decodes an item with a Users object. Ambari 2.5 replaced the
+// ldap_user flag with user_type.
+func DecodeUser(item map[string]interface{}) User {
+	info := object(item, "Users")
+	u := User{
+		Name:        str(info, "user_name"),
+		DisplayName: str(info, "display_name"),
+		Active:      boolean(info, "active"),
+		Admin:       boolean(info, "admin"),
+		Type:        str(info, "user_type"),
+		Groups:      stringList(info, "groups"),
+	}
+	if u.Type == "" {
+		u.Type = "LOCAL"
+		if boolean(info, "ldap_user") {
+			u.Type = "LDAP"
+		}
+	}
+	return u
+}
+
+// DecodePrivilege decodes an item with a PrivilegeInfo object
+func DecodePrivilege(item map[string]interface{}) Privilege {
+	info := object(item, "PrivilegeInfo")
+	return Privilege{
+		ID:              integer(info, "privilege_id"),
+		Permission:      str(info, "permission_name"),
+		PermissionLabel: str(info, "permission_label"),
+		ResourceType:    str(info, "type"),
+		Cluster:         str(info, "cluster_name"),
+		PrincipalType:   str(info, "principal_type"),
+		PrincipalName:   str(info, "principal_name", "user_name", "group_name"),
+	}
+}
+
+// DecodeGroup decodes an item with a Groups object and optional members
+func DecodeGroup(item map[string]interface{}) Group {
+	info := object(item, "Groups")
+	g := Group{Name: str(info, "group_name"), Type: str(info, "group_type")}
+	if g.Type == "" {
+		g.Type = "LOCAL"
+		if boolean(info, "ldap_group") {
+			g.Type = "LDAP"
+		}
+	}
+	for _, m := range objects(item, "members") {
+		if name := str(object(m, "MemberInfo"), "user_name"); name != "" {
+			g.Members = append(g.Members, name)
+		}
+	}
+	return g
+}
@@ -0,0 +1,203 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// decode parses an Ambari JSON payload; useNumber decodes numbers as
+// json.Number, as callers reading with a json.Decoder may
+func decode(t *testing.T, payload string, useNumber bool) map[string]interface{} {
+	t.Helper()
+	dec := json.NewDecoder(strings.NewReader(payload))
+	if useNumber {
+		dec.UseNumber()
+	}
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		t.Fatalf("payload %s: %v", payload, err)
+	}
+	return m
+}
+
+func at(ms int64) *time.Time {
+	t := time.UnixMilli(ms).UTC()
+	return &t
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   string
+		useNumber bool
+		decode    func(map[string]interface{}) interface{}
+		want      interface{}
+	}{
+		{
+			name:    "cluster",
+			payload: `{"Clusters":{"cluster_id":2,"cluster_name":"c1","version":"HDP-3.1","security_type":"KERBEROS","provisioning_state":"INSTALLED","total_hosts":3,"health_report":{"Host/host_state/HEALTHY":3},"desired_configs":{"hdfs-site":{"tag":"version1","version":4}}}}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeCluster(m) },
+			want: Cluster{ID: 2, Name: "c1", Version: "HDP-3.1", SecurityType: "KERBEROS", State: "INSTALLED", TotalHosts: 3,
+				HealthReport: map[string]int64{"Host/host_state/HEALTHY": 3}, DesiredConfigs: map[string]ConfigRef{"hdfs-site": {Tag: "version1", Version: 4}}},
+		},
+		{
+			name:    "cluster with numbers as strings",
+			payload: `{"Clusters":{"cluster_id":"2","cluster_name":"c1","state":"INSTALLED","total_hosts":" 3 ","desired_configs":{"hdfs-site":{"tag":"version1","version":"4"}}}}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeCluster(m) },
+			want:    Cluster{ID: 2, Name: "c1", State: "INSTALLED", TotalHosts: 3, DesiredConfigs: map[string]ConfigRef{"hdfs-site": {Tag: "version1", Version: 4}}},
+		},
+		{
+			name:      "cluster with json.Number",
+			payload:   `{"Clusters":{"cluster_id":2,"cluster_name":"c1","total_hosts":3}}`,
+			useNumber: true,
+			decode:    func(m map[string]interface{}) interface{} { return DecodeCluster(m) },
+			want:      Cluster{ID: 2, Name: "c1", TotalHosts: 3},
+		},
+		{
+			name:    "cluster without Clusters object",
+			payload: `{"href":"http://ambari/api/v1/clusters/c1"}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeCluster(m) },
+			want:    Cluster{},
+		},
+		{
+			name:    "cluster with wrong types",
+			payload: `{"Clusters":{"cluster_id":"two","cluster_name":["c1"],"health_report":"n/a"}}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeCluster(m) },
+			want:    Cluster{},
+		},
+		{
+			name:    "host with host_status",
+			payload: `{"Hosts":{"host_name":"n1","ip":"10.0.0.1","host_status":"HEALTHY","host_state":"HEARTBEAT_LOST","cpu_count":8,"total_mem":16384,"last_heartbeat_time":1700000000000},"host_components":[{"HostRoles":{"component_name":"DATANODE","state":"STARTED","stale_configs":"true"}}]}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeHost(m) },
+			want: Host{Name: "n1", IP: "10.0.0.1", Status: "HEALTHY", State: "HEARTBEAT_LOST", CPUCount: 8, TotalMemKB: 16384, LastHeartbeat: at(1700000000000),
+				Components: []HostComponent{{Component: "DATANODE", State: "STARTED", StaleConfigs: true}}},
+		},
+		{
+			name:    "host before Ambari 2.0",
+			payload: `{"Hosts":{"host_name":"n1","host_state":"HEALTHY","cpu_count":"8","last_heartbeat_time":0}}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeHost(m) },
+			want:    Host{Name: "n1", Status: "HEALTHY", State: "HEALTHY", CPUCount: 8},
+		},
+		{
+			name:    "service with components",
+			payload: `{"ServiceInfo":{"cluster_name":"c1","service_name":"HDFS","state":"STARTED"},"components":[{"ServiceComponentInfo":{"component_name":"NAMENODE","started_count":"1","total_count":1},"host_components":[{"HostRoles":{"host_name":"n1"}}]},"not an object"]}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeService(m) },
+			want: Service{Cluster: "c1", Name: "HDFS", State: "STARTED", Components: []ServiceComponent{
+				{Name: "NAMENODE", StartedCount: 1, TotalCount: 1, HostComponents: []HostComponent{{Host: "n1"}}},
+			}},
+		},
+		{
+			name:    "alert",
+			payload: `{"Alert":{"id":"7","definition_id":3,"definition_name":"datanode_process","state":"CRITICAL","host_name":"n1","latest_timestamp":1700000000000,"original_timestamp":-1}}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeAlert(m) },
+			want:    Alert{ID: 7, DefinitionID: 3, DefinitionName: "datanode_process", State: "CRITICAL", Host: "n1", Latest: at(1700000000000)},
+		},
+		{
+			name:    "alert definition",
+			payload: `{"AlertDefinition":{"id":3,"name":"datanode_process","enabled":"false","interval":"1","source":{"type":"PORT"}}}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeAlertDefinition(m) },
+			want:    AlertDefinition{ID: 3, Name: "datanode_process", Interval: 1, SourceType: "PORT"},
+		},
+		{
+			name:    "alert summaries",
+			payload: `{"alerts_summary_grouped":[{"definition_id":3,"definition_name":"datanode_process","summary":{"CRITICAL":{"count":2,"maintenance_count":"1"},"OK":{"count":"5","maintenance_count":0}}}]}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeAlertSummaries(m) },
+			want: []AlertSummary{{DefinitionID: 3, DefinitionName: "datanode_process",
+				Counts: map[string]int64{"CRITICAL": 2, "OK": 5}, MaintenanceCounts: map[string]int64{"CRITICAL": 1}}},
+		},
+		{
+			name:    "alert summaries missing",
+			payload: `{"items":[]}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeAlertSummaries(m) },
+			want:    []AlertSummary(nil),
+		},
+		{
+			name:    "request with progress as string",
+			payload: `{"Requests":{"id":12,"request_status":"IN_PROGRESS","progress_percent":"37.5","task_count":8,"create_time":1700000000000,"end_time":-1},"tasks":[{"Tasks":{"id":1,"command_detail":"DATANODE START","exit_code":"0","status":"COMPLETED"}}]}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeRequest(m) },
+			want: Request{ID: 12, Status: "IN_PROGRESS", ProgressPercent: 37.5, TaskCount: 8, Created: at(1700000000000),
+				Tasks: []Task{{ID: 1, Command: "DATANODE START", Status: "COMPLETED"}}},
+		},
+		{
+			name:    "config type at top level",
+			payload: `{"type":"hdfs-site","tag":"version2","version":5,"Config":{"cluster_name":"c1"},"properties":{"dfs.replication":3,"dfs.permissions":true,"dfs.name":"nn"}}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeConfigType(m) },
+			want: ConfigType{Cluster: "c1", Type: "hdfs-site", Tag: "version2", Version: 5,
+				Properties: map[string]string{"dfs.replication": "3", "dfs.permissions": "true", "dfs.name": "nn"}},
+		},
+		{
+			name:    "config type inside Config",
+			payload: `{"Config":{"cluster_name":"c1","type":"hdfs-site","tag":"version2","version":"5"}}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeConfigType(m) },
+			want:    ConfigType{Cluster: "c1", Type: "hdfs-site", Tag: "version2", Version: 5},
+		},
+		{
+			name:    "user with user_type",
+			payload: `{"Users":{"user_name":"alice","active":true,"admin":"false","user_type":"PAM","groups":["ops",3]}}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeUser(m) },
+			want:    User{Name: "alice", Active: true, Type: "PAM", Groups: []string{"ops"}},
+		},
+		{
+			name:    "user with ldap_user",
+			payload: `{"Users":{"user_name":"bob","active":"true","ldap_user":true}}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeUser(m) },
+			want:    User{Name: "bob", Active: true, Type: "LDAP"},
+		},
+		{
+			name:    "user without type",
+			payload: `{"Users":{"user_name":"admin","admin":true}}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeUser(m) },
+			want:    User{Name: "admin", Admin: true, Type: "LOCAL"},
+		},
+		{
+			name:    "group with ldap_group",
+			payload: `{"Groups":{"group_name":"ops","ldap_group":"true"},"members":[{"MemberInfo":{"user_name":"alice"}},{"MemberInfo":{}}]}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeGroup(m) },
+			want:    Group{Name: "ops", Type: "LDAP", Members: []string{"alice"}},
+		},
+		{
+			name:    "privilege of a group",
+			payload: `{"PrivilegeInfo":{"privilege_id":"4","permission_name":"CLUSTER.USER","type":"CLUSTER","cluster_name":"c1","principal_type":"GROUP","group_name":"ops"}}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodePrivilege(m) },
+			want:    Privilege{ID: 4, Permission: "CLUSTER.USER", ResourceType: "CLUSTER", Cluster: "c1", PrincipalType: "GROUP", PrincipalName: "ops"},
+		},
+		{
+			name:    "alert group",
+			payload: `{"AlertGroup":{"id":1,"name":"HDFS","default":true,"definitions":[{"name":"datanode_process"}],"targets":[{"name":"ops-mail"}]}}`,
+			decode:  func(m map[string]interface{}) interface{} { return DecodeAlertGroup(m) },
+			want:    AlertGroup{ID: 1, Name: "HDFS", Default: true, Definitions: []string{"datanode_process"}, Targets: []string{"ops-mail"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.decode(decode(t, tt.payload, tt.useNumber))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decoded %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    int
+	}{
+		{name: "objects", payload: `{"items":[{"a":1},{"b":2}]}`, want: 2},
+		{name: "non-objects skipped", payload: `{"items":[{"a":1},"x",3,null]}`, want: 1},
+		{name: "missing", payload: `{}`, want: 0},
+		{name: "not a list", payload: `{"items":{"a":1}}`, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := Items(decode(t, tt.payload, false))
+			if len(items) != tt.want || items == nil {
+				t.Errorf("Items() = %v, want %d objects", items, tt.want)
+			}
+		})
+	}
+}
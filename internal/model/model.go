@@ -0,0 +1,202 @@
+// Package model provides typed views of Ambari resources on top of
+// client.AmbariClient. Operations that combine several resources (health,
+// diffs, filters) use these types; the raw map API remains available through
+// the client for everything else.
+package model
+
+import "time"
+
+// ConfigRef identifies one version of a configuration type
+type ConfigRef struct {
+	Tag     string `json:"tag"`
+	Version int64  `json:"version,omitempty"`
+}
+
+// Cluster is an Ambari cluster (Clusters/*)
+type Cluster struct {
+	ID             int64                `json:"id"`
+	Name           string               `json:"name"`
+	Version        string               `json:"version"`
+	SecurityType   string               `json:"security_type"`
+	State          string               `json:"state,omitempty"`
+	TotalHosts     int64                `json:"total_hosts"`
+	HealthReport   map[string]int64     `json:"health_report,omitempty"`
+	DesiredConfigs map[string]ConfigRef `json:"desired_configs,omitempty"`
+}
+
+// Service is a service of a cluster (ServiceInfo/*)
+type Service struct {
+	Cluster          string             `json:"cluster"`
+	Name             string             `json:"name"`
+	State            string             `json:"state"`
+	MaintenanceState string             `json:"maintenance_state"`
+	Components       []ServiceComponent `json:"components,omitempty"`
+}
+
+// ServiceComponent is a component of a service (ServiceComponentInfo/*)
+type ServiceComponent struct {
+	Cluster        string          `json:"cluster"`
+	Service        string          `json:"service"`
+	Name           string          `json:"name"`
+	Category       string          `json:"category"`
+	State          string          `json:"state"`
+	StartedCount   int64           `json:"started_count"`
+	InstalledCount int64           `json:"installed_count"`
+	TotalCount     int64           `json:"total_count"`
+	HostComponents []HostComponent `json:"host_components,omitempty"`
+}
+
+// HostComponent is a component instance on a host (HostRoles/*)
+type HostComponent struct {
+	Cluster          string `json:"cluster"`
+	Service          string `json:"service"`
+	Component        string `json:"component"`
+	Host             string `json:"host"`
+	State            string `json:"state"`
+	DesiredState     string `json:"desired_state"`
+	StaleConfigs     bool   `json:"stale_configs"`
+	MaintenanceState string `json:"maintenance_state"`
+}
+
+// Host is a cluster host (Hosts/*)
+type Host struct {
+	Cluster          string          `json:"cluster,omitempty"`
+	Name             string          `json:"name"`
+	IP               string          `json:"ip"`
+	Status           string          `json:"status"`
+	State            string          `json:"state"`
+	OSType           string          `json:"os_type"`
+	Rack             string          `json:"rack,omitempty"`
+	CPUCount         int64           `json:"cpu_count"`
+	TotalMemKB       int64           `json:"total_mem_kb"`
+	MaintenanceState string          `json:"maintenance_state"`
+	LastHeartbeat    *time.Time      `json:"last_heartbeat,omitempty"`
+	Components       []HostComponent `json:"components,omitempty"`
+}
+
+// Alert is a current alert instance (Alert/*)
+type Alert struct {
+	ID               int64      `json:"id"`
+	Cluster          string     `json:"cluster"`
+	DefinitionID     int64      `json:"definition_id"`
+	DefinitionName   string     `json:"definition_name"`
+	Label            string     `json:"label"`
+	Service          string     `json:"service"`
+	Component        string     `json:"component"`
+	Host             string     `json:"host,omitempty"`
+	State            string     `json:"state"`
+	Text             string     `json:"text"`
+	MaintenanceState string     `json:"maintenance_state"`
+	Latest           *time.Time `json:"latest,omitempty"`
+	Original         *time.Time `json:"original,omitempty"`
+}
+
+// AlertDefinition is an alert definition (AlertDefinition/*)
+type AlertDefinition struct {
+	ID         int64  `json:"id"`
+	Cluster    string `json:"cluster"`
+	Name       string `json:"name"`
+	Label      string `json:"label"`
+	Service    string `json:"service"`
+	Component  string `json:"component"`
+	Enabled    bool   `json:"enabled"`
+	Interval   int64  `json:"interval_minutes"`
+	Scope      string `json:"scope"`
+	SourceType string `json:"source_type"`
+}
+
+// AlertSummary counts the alerts of one definition by state
+// (format=groupedSummary)
+type AlertSummary struct {
+	DefinitionID      int64            `json:"definition_id"`
+	DefinitionName    string           `json:"definition_name"`
+	Counts            map[string]int64 `json:"counts"`
+	MaintenanceCounts map[string]int64 `json:"maintenance_counts,omitempty"`
+}
+
+// AlertGroup is a set of alert definitions notified together (AlertGroup/*)
+type AlertGroup struct {
+	ID          int64    `json:"id"`
+	Cluster     string   `json:"cluster"`
+	Name        string   `json:"name"`
+	Default     bool     `json:"default"`
+	Definitions []string `json:"definitions,omitempty"`
+	Targets     []string `json:"targets,omitempty"`
+}
+
+// AlertTarget is an alert notification target (AlertTarget/*)
+type AlertTarget struct {
+	ID               int64    `json:"id"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description,omitempty"`
+	NotificationType string   `json:"notification_type"`
+	Global           bool     `json:"global"`
+	AlertStates      []string `json:"alert_states,omitempty"`
+}
+
+// Request is an Ambari operation request (Requests/*)
+type Request struct {
+	ID              int64      `json:"id"`
+	Cluster         string     `json:"cluster"`
+	Context         string     `json:"context"`
+	Status          string     `json:"status"`
+	ProgressPercent float64    `json:"progress_percent"`
+	TaskCount       int64      `json:"task_count"`
+	CompletedTasks  int64      `json:"completed_task_count"`
+	FailedTasks     int64      `json:"failed_task_count"`
+	Created         *time.Time `json:"created,omitempty"`
+	Started         *time.Time `json:"started,omitempty"`
+	Ended           *time.Time `json:"ended,omitempty"`
+	Tasks           []Task     `json:"tasks,omitempty"`
+}
+
+// Task is one command of a request (Tasks/*)
+type Task struct {
+	ID        int64      `json:"id"`
+	RequestID int64      `json:"request_id"`
+	Host      string     `json:"host"`
+	Role      string     `json:"role"`
+	Command   string     `json:"command"`
+	Status    string     `json:"status"`
+	ExitCode  int64      `json:"exit_code"`
+	Started   *time.Time `json:"started,omitempty"`
+	Ended     *time.Time `json:"ended,omitempty"`
+}
+
+// ConfigType is one version of a configuration type (Config/*)
+type ConfigType struct {
+	Cluster    string            `json:"cluster"`
+	Type       string            `json:"type"`
+	Tag        string            `json:"tag"`
+	Version    int64             `json:"version"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// User is an Ambari user (Users/*)
+type User struct {
+	Name        string   `json:"name"`
+	DisplayName string   `json:"display_name,omitempty"`
+	Active      bool     `json:"active"`
+	Admin       bool     `json:"admin"`
+	Type        string   `json:"type"`
+	Groups      []string `json:"groups,omitempty"`
+}
+
+// Privilege is a permission granted to a user or group on a resource
+// (PrivilegeInfo/*)
+type Privilege struct {
+	ID              int64  `json:"id"`
+	Permission      string `json:"permission"`
+	PermissionLabel string `json:"permission_label,omitempty"`
+	ResourceType    string `json:"resource_type"`
+	Cluster         string `json:"cluster,omitempty"`
+	PrincipalType   string `json:"principal_type"`
+	PrincipalName   string `json:"principal_name"`
+}
+
+// Group is an Ambari group (Groups/*)
+type Group struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Members []string `json:"members,omitempty"`
+}
@@ -23,7 +23,9 @@ func (o *CreateCluster) Definition() ops.ToolDefinition {
 func (o *CreateCluster) Validate(a map[string]interface{}) error { return req(a, "clusterName", "body") }
 func (o *CreateCluster) Execute(ctx context.Context, a map[string]interface{}) (interface{}, error) {
 	var body interface{}; if s, ok := a["body"].(string); ok { json.Unmarshal([]byte(s), &body) } else { body = a["body"] }
-	return o.Client.Post(ctx, fmt.Sprintf("/clusters/%s", a["clusterName"].(string)), nil, body)
+	path := fmt.Sprintf("/clusters/%s", a["clusterName"].(string))
+	ctx = client.WithIdempotencyGuard(ctx, path, client.ResourceExists(o.Client, path))
+	return o.Client.Post(ctx, path, nil, body)
 }
 
 // ---- UpdateAlertDefinition ----
@@ -0,0 +1,63 @@
+package actionable
+
+import (
+	"context"
+	"fmt"
+
+	"mcp-ambari/internal/auth"
+	ops "mcp-ambari/internal/operations"
+	"mcp-ambari/internal/policy"
+	"github.com/sirupsen/logrus"
+)
+
+// ---- ApproveCall ----
+
+type ApproveCall struct {
+	ops.ActionableBase
+	policies *policy.Engine
+}
+
+// NewApproveCall grants held calls. It needs no permission: the rule's
+// approver groups decide who may grant a call.
+func NewApproveCall(p *policy.Engine, l *logrus.Logger) *ApproveCall {
+	return &ApproveCall{ActionableBase: ops.ActionableBase{
+		OpName:        ops.ApproveTool,
+		OpDescription: "Approve a call held by a require-approval policy rule; the requester then repeats it with the same arguments",
+		OpCategory:    "policy",
+		Permissions:   []auth.Permission{},
+		Logger:        l,
+	}, policies: p}
+}
+
+func (o *ApproveCall) Definition() ops.ToolDefinition {
+	return ops.ToolDefinition{
+		Name:        o.OpName,
+		Description: o.OpDescription,
+		InputSchema: ops.ToolSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"approvalId": map[string]interface{}{"type": "string", "description": "Approval ID from the APPROVAL_REQUIRED error or ambari_policy_getapprovals", "pattern": "^[0-9a-f]{32}$"},
+			},
+			Required: []string{"approvalId"},
+		},
+	}
+}
+
+func (o *ApproveCall) Validate(a map[string]interface{}) error {
+	return req(a, "approvalId")
+}
+
+func (o *ApproveCall) Execute(ctx context.Context, a map[string]interface{}) (interface{}, error) {
+	authCtx, ok := auth.GetAuthContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("no caller identity")
+	}
+	approval, err := o.policies.Grant(a["approvalId"].(string), authCtx.Username, authCtx.Groups)
+	if err != nil {
+		return nil, ops.NewToolError(o.OpName, ops.CodePolicyDenied, err)
+	}
+	o.Logger.WithFields(logrus.Fields{
+		"approval": approval.ID, "tool": approval.Tool, "requester": approval.Requester, "approved_by": approval.ApprovedBy,
+	}).Info("Call approved")
+	return approval, nil
+}
@@ -4,6 +4,7 @@ package actionable
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"mcp-ambari/internal/auth"
 	"mcp-ambari/internal/client"
@@ -262,5 +263,9 @@ func (o *RunServiceCheck) Execute(ctx context.Context, args map[string]interface
 			{"service_name": service},
 		},
 	}
-	return o.Client.Post(ctx, fmt.Sprintf("/clusters/%s/requests", cluster), nil, body)
+	// A duplicated service check is harmless but noisy; only retry when
+	// Ambari shows no request of this check since we sent it
+	path := fmt.Sprintf("/clusters/%s/requests", cluster)
+	ctx = client.WithIdempotencyGuard(ctx, path, client.RequestCreatedSince(o.Client, cluster, service+" Service Check", time.Now()))
+	return o.Client.Post(ctx, path, nil, body)
 }
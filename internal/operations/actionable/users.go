@@ -63,6 +63,7 @@ func (o *CreateUser) Execute(ctx context.Context, a map[string]interface{}) (int
 		body["Users"].(map[string]interface{})["local_username"] = localUsername
 	}
 
+	ctx = client.WithIdempotencyGuard(ctx, "/users", client.ResourceExists(o.Client, "/users/"+a["username"].(string)))
 	return o.Client.Post(ctx, "/users", nil, body)
 }
 
@@ -201,6 +202,7 @@ func (o *CreateUserGroup) Execute(ctx context.Context, a map[string]interface{})
 		},
 	}
 
+	ctx = client.WithIdempotencyGuard(ctx, "/groups", client.ResourceExists(o.Client, "/groups/"+a["groupName"].(string)))
 	return o.Client.Post(ctx, "/groups", nil, body)
 }
 
@@ -280,7 +282,9 @@ func (o *AddUserToGroup) Execute(ctx context.Context, a map[string]interface{})
 	username := a["username"].(string)
 	groupName := a["groupName"].(string)
 
-	return o.Client.Post(ctx, fmt.Sprintf("/groups/%s/members/%s", groupName, username), nil, nil)
+	path := fmt.Sprintf("/groups/%s/members/%s", groupName, username)
+	ctx = client.WithIdempotencyGuard(ctx, path, client.ResourceExists(o.Client, path))
+	return o.Client.Post(ctx, path, nil, nil)
 }
 
 // ---- RemoveUserFromGroup ----
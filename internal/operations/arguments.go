@@ -0,0 +1,252 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// normalizeArgs checks args against the tool's input schema and rewrites them
+// in place: JSON nulls are dropped, defaults filled in and values converted to
+// the declared type, so integers arrive as int, numbers as float64 and
+// booleans as bool. Numbers and booleans sent as strings, and numbers sent for
+// string arguments, are converted rather than rejected. Keywords the checks
+// below do not cover are enforced by validating the result against the
+// resolved schema.
+func normalizeArgs(schema *jsonschema.Resolved, args map[string]interface{}) error {
+	if err := normalizeObject("", schema.Schema(), args); err != nil {
+		return err
+	}
+	return schema.Validate(args)
+}
+
+func normalizeObject(path string, s *jsonschema.Schema, obj map[string]interface{}) error {
+	for name, value := range obj {
+		if value == nil {
+			delete(obj, name)
+		}
+	}
+	if closed(s) {
+		var unknown []string
+		for name := range obj {
+			if _, ok := s.Properties[name]; !ok {
+				unknown = append(unknown, path+name)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			return fmt.Errorf("unknown argument %s", strings.Join(unknown, ", "))
+		}
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := s.Properties[name]
+		value, ok := obj[name]
+		if !ok {
+			if prop.Default == nil {
+				continue
+			}
+			if err := json.Unmarshal(prop.Default, &value); err != nil {
+				return fmt.Errorf("%s%s: invalid default: %w", path, name, err)
+			}
+		}
+		value, err := normalizeValue(path+name, prop, value)
+		if err != nil {
+			return err
+		}
+		obj[name] = value
+	}
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%s%s is required", path, name)
+		}
+	}
+	return nil
+}
+
+// closed reports whether s rejects properties it does not declare
+func closed(s *jsonschema.Schema) bool {
+	a := s.AdditionalProperties
+	return a != nil && a.Not != nil && reflect.ValueOf(*a.Not).IsZero()
+}
+
+func normalizeValue(path string, s *jsonschema.Schema, value interface{}) (interface{}, error) {
+	if s.Type != "" {
+		v, err := coerce(path, s.Type, value)
+		if err != nil {
+			return nil, err
+		}
+		value = v
+	} else if len(s.Types) > 0 && !oneOfTypes(s.Types, value) {
+		return nil, fmt.Errorf("%s must be %s, got %s", path, strings.Join(s.Types, " or "), describe(value))
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		allowed := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			allowed[i] = fmt.Sprint(e)
+		}
+		return nil, fmt.Errorf("%s must be one of %s, got %v", path, strings.Join(allowed, ", "), value)
+	}
+
+	switch v := value.(type) {
+	case string:
+		if s.Pattern != "" {
+			re, err := regexp.Compile(s.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid pattern: %w", path, err)
+			}
+			if !re.MatchString(v) {
+				return nil, fmt.Errorf("%s: %q is not a valid value (must match %s)", path, v, s.Pattern)
+			}
+		}
+	case int:
+		if s.Minimum != nil && float64(v) < *s.Minimum {
+			return nil, fmt.Errorf("%s must be at least %v", path, *s.Minimum)
+		}
+		if s.Maximum != nil && float64(v) > *s.Maximum {
+			return nil, fmt.Errorf("%s must be at most %v", path, *s.Maximum)
+		}
+	case map[string]interface{}:
+		if err := normalizeObject(path+".", s, v); err != nil {
+			return nil, err
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				n, err := normalizeValue(fmt.Sprintf("%s[%d]", path, i), s.Items, item)
+				if err != nil {
+					return nil, err
+				}
+				v[i] = n
+			}
+		}
+	}
+	return value, nil
+}
+
+// coerce converts value to the JSON type want where the conversion is lossless
+func coerce(path, want string, value interface{}) (interface{}, error) {
+	switch want {
+	case "string":
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+	case "integer":
+		switch v := value.(type) {
+		case int:
+			return v, nil
+		case float64:
+			if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+				return int(v), nil
+			}
+		case string:
+			if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				return n, nil
+			}
+		}
+	case "number":
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		case string:
+			if n, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return n, nil
+			}
+		}
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+	case "array":
+		if v, ok := value.([]interface{}); ok {
+			return v, nil
+		}
+	case "object":
+		if v, ok := value.(map[string]interface{}); ok {
+			return v, nil
+		}
+	default:
+		return value, nil
+	}
+	return nil, fmt.Errorf("%s must be %s %s, got %s", path, article(want), want, describe(value))
+}
+
+// oneOfTypes reports whether value already has one of the JSON types; values
+// allowed several types are not converted
+func oneOfTypes(types []string, value interface{}) bool {
+	var got string
+	switch v := value.(type) {
+	case string:
+		got = "string"
+	case bool:
+		got = "boolean"
+	case int:
+		got = "integer"
+	case float64:
+		got = "number"
+		if v == math.Trunc(v) {
+			got = "integer"
+		}
+	case []interface{}:
+		got = "array"
+	case map[string]interface{}:
+		got = "object"
+	}
+	for _, t := range types {
+		if t == got || t == "number" && got == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func inEnum(enum []any, value interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func article(typ string) string {
+	if typ == "integer" || typ == "array" || typ == "object" {
+		return "an"
+	}
+	return "a"
+}
+
+func describe(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64, int, bool:
+		return fmt.Sprint(v)
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", value)
+}
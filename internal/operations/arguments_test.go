@@ -0,0 +1,126 @@
+package operations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testSchema(t *testing.T) ToolDefinition {
+	t.Helper()
+	return ToolDefinition{
+		Name: "ambari_test_tool",
+		InputSchema: ToolSchema{
+			Type: "object",
+			Properties: WithFilter(WithPaging(map[string]interface{}{
+				"clusterName": map[string]interface{}{"type": "string"},
+				"alertId":     map[string]interface{}{"type": "string"},
+				"enabled":     map[string]interface{}{"type": "boolean"},
+				"ratio":       map[string]interface{}{"type": "number"},
+				"options": map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"force": map[string]interface{}{"type": "boolean"}},
+				},
+			}, "Hosts/host_name"), FilterFields{"state": "Hosts/host_state"}),
+			Required: []string{"clusterName"},
+		},
+	}
+}
+
+func TestNormalizeArgs(t *testing.T) {
+	schema, err := testSchema(t).JSONSchema("default", "prod")
+	if err != nil {
+		t.Fatalf("JSONSchema: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name: "defaults and conversions",
+			args: map[string]interface{}{"clusterName": "c1", "enabled": "true", "ratio": "0.5", "page_size": "10", "cursor": nil},
+			want: map[string]interface{}{"clusterName": "c1", "enabled": true, "ratio": 0.5, "page_size": 10, "from": 0, "sortBy": "Hosts/host_name"},
+		},
+		{
+			name: "number sent for string",
+			args: map[string]interface{}{"clusterName": "c1", "alertId": float64(42)},
+			want: map[string]interface{}{"clusterName": "c1", "alertId": "42", "page_size": 100, "from": 0, "sortBy": "Hosts/host_name"},
+		},
+		{
+			name:    "missing required",
+			args:    map[string]interface{}{},
+			wantErr: "clusterName is required",
+		},
+		{
+			name:    "undeclared argument",
+			args:    map[string]interface{}{"clusterName": "c1", "serviceName": "HDFS"},
+			wantErr: "unknown argument serviceName",
+		},
+		{
+			name:    "undeclared nested argument",
+			args:    map[string]interface{}{"clusterName": "c1", "options": map[string]interface{}{"force": true, "all": true}},
+			wantErr: "unknown argument options.all",
+		},
+		{
+			name:    "path in name",
+			args:    map[string]interface{}{"clusterName": "../users"},
+			wantErr: "clusterName",
+		},
+		{
+			name:    "path in ID",
+			args:    map[string]interface{}{"clusterName": "c1", "alertId": "../../../users/admin"},
+			wantErr: "alertId",
+		},
+		{
+			name:    "boolean not convertible",
+			args:    map[string]interface{}{"clusterName": "c1", "enabled": "maybe"},
+			wantErr: "enabled must be a boolean",
+		},
+		{
+			name:    "fractional integer",
+			args:    map[string]interface{}{"clusterName": "c1", "page_size": 1.5},
+			wantErr: "page_size must be an integer",
+		},
+		{
+			name:    "unknown instance",
+			args:    map[string]interface{}{"clusterName": "c1", InstanceArg: "staging"},
+			wantErr: "ambariInstance must be one of default, prod",
+		},
+		{
+			name: "nested filter",
+			args: map[string]interface{}{"clusterName": "c1", "filter": map[string]interface{}{
+				"or": []interface{}{map[string]interface{}{"field": "state", "value": "HEALTHY"}},
+			}},
+			want: map[string]interface{}{"clusterName": "c1", "page_size": 100, "from": 0, "sortBy": "Hosts/host_name", "filter": map[string]interface{}{
+				"or": []interface{}{map[string]interface{}{"field": "state", "value": "HEALTHY"}},
+			}},
+		},
+		{
+			name: "undeclared key in nested filter",
+			args: map[string]interface{}{"clusterName": "c1", "filter": map[string]interface{}{
+				"or": []interface{}{map[string]interface{}{"field": "state", "value": "HEALTHY", "raw": "x"}},
+			}},
+			wantErr: "raw",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := normalizeArgs(schema, tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("normalizeArgs() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeArgs() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.args, tt.want) {
+				t.Errorf("normalizeArgs() = %v, want %v", tt.args, tt.want)
+			}
+		})
+	}
+}
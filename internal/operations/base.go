@@ -11,11 +11,17 @@ package operations
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"mcp-ambari/internal/auth"
 	"mcp-ambari/internal/client"
+	"mcp-ambari/internal/model"
+	"mcp-ambari/internal/policy"
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/sirupsen/logrus"
 )
 
@@ -41,6 +47,10 @@ type ToolDefinition struct {
 	InputSchema ToolSchema `json:"inputSchema"`
 }
 
+// InstanceArg is the optional argument that selects the Ambari instance a
+// call runs against; without it the default instance is used
+const InstanceArg = "ambariInstance"
+
 // OperationResult wraps the result of executing an operation
 type OperationResult struct {
 	Tool          string      `json:"tool"`
@@ -48,6 +58,10 @@ type OperationResult struct {
 	ExecutionMs   int64       `json:"execution_ms"`
 	Timestamp     string      `json:"timestamp"`
 	Result        interface{} `json:"result"`
+	// Structured and Text are the typed result and its rendering, set for
+	// operations that implement StructuredOperation
+	Structured interface{} `json:"-"`
+	Text       string      `json:"-"`
 }
 
 // ---------- Operation Interface (Strategy pattern) ----------
@@ -73,71 +87,323 @@ type Operation interface {
 	Execute(ctx context.Context, args map[string]interface{}) (interface{}, error)
 }
 
+// MultiTargetOperation is implemented by operations that act on many
+// clusters in one call, such as cross-cluster views. When AuthorizesTargets
+// returns true the Executor only requires the permissions on some cluster,
+// and the operation checks each cluster itself with HasPermissionOn and
+// AllowsCluster, skipping those the caller may not see.
+type MultiTargetOperation interface {
+	AuthorizesTargets() bool
+}
+
 // ---------- Template Method executor ----------
 
 // Executor runs operations through a standard lifecycle:
 //
-//	authenticate → authorise → validate → execute → audit
+//	authenticate → check arguments → authorise → policy → validate → execute → audit
+//
+// Arguments are checked against the tool's schema first so that permission,
+// scope and policy decisions see the same normalized values Execute gets.
 type Executor struct {
-	client client.AmbariClient
-	logger *logrus.Logger
+	client      client.AmbariClient
+	policies    *policy.Engine
+	credentials *auth.CredentialResolver
+	schemas     map[string]*jsonschema.Resolved
+	logger      *logrus.Logger
 }
 
-// NewExecutor creates a new operation executor
-func NewExecutor(c client.AmbariClient, logger *logrus.Logger) *Executor {
-	return &Executor{client: c, logger: logger}
+// NewExecutor creates a new operation executor. policies may be nil, in which
+// case only permissions are enforced; a nil credentials resolver runs every
+// call with the client's service account. schemas, from
+// Registry.CheckSchemas, are the input schemas arguments are checked and
+// normalized against; tools without one get their arguments as sent.
+func NewExecutor(c client.AmbariClient, policies *policy.Engine, credentials *auth.CredentialResolver, schemas map[string]*jsonschema.Resolved, logger *logrus.Logger) *Executor {
+	return &Executor{client: c, policies: policies, credentials: credentials, schemas: schemas, logger: logger}
 }
 
 // Run applies the Template Method: auth-check → validate → execute → wrap result
 func (e *Executor) Run(ctx context.Context, op Operation, args map[string]interface{}, authCtx *auth.AuthContext) (*OperationResult, error) {
 	start := time.Now()
 
-	// Step 1: Authorization check
-	if err := e.checkPermissions(op, authCtx); err != nil {
+	// Step 1: Check and normalize arguments against the input schema
+	if schema, ok := e.schemas[op.Name()]; ok {
+		if args == nil {
+			args = map[string]interface{}{}
+		}
+		if err := normalizeArgs(schema, args); err != nil {
+			return nil, NewToolError(op.Name(), CodeInvalidArgument, fmt.Errorf("invalid arguments for %s: %w", op.Name(), err))
+		}
+	}
+
+	// Step 2: Authorization check. Operations that authorize each of their
+	// targets only need the permissions somewhere.
+	if m, ok := op.(MultiTargetOperation); ok && m.AuthorizesTargets() {
+		if err := authorizeAny(op.Name(), op.RequiredPermissions(), authCtx); err != nil {
+			return nil, err
+		}
+	} else {
+		target := Target(op, args)
+		target.Instance = e.instance(target.Instance)
+		if err := authorize(op.Name(), op.RequiredPermissions(), target, authCtx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Step 3: Resolve the caller's Ambari account, when configured, and the
+	// requested instance; policy lookups and Execute run with them
+	instance, _ := args[InstanceArg].(string)
+	ctx, err := e.callContext(ctx, op.Name(), authCtx, instance)
+	if err != nil {
 		return nil, err
 	}
 
-	// Step 2: Extra safety for actionable operations
+	// Step 4: Policy rules
+	if err := e.checkPolicy(ctx, op, authCtx, args); err != nil {
+		return nil, AsToolError(op.Name(), err)
+	}
+
+	// Step 5: Extra safety for actionable operations
 	if op.Type() == Actionable {
 		e.logger.WithFields(logrus.Fields{
 			"user": authCtx.Username, "tool": op.Name(), "type": "actionable",
 		}).Info("Actionable operation requested")
 	}
 
-	// Step 3: Validate arguments
+	// Step 6: Validate arguments
 	if err := op.Validate(args); err != nil {
-		return nil, fmt.Errorf("validation failed for %s: %w", op.Name(), err)
+		return nil, NewToolError(op.Name(), CodeInvalidArgument, fmt.Errorf("validation failed for %s: %w", op.Name(), err))
 	}
 
-	// Step 4: Execute
+	// Step 7: Execute
 	result, err := op.Execute(ctx, args)
 	if err != nil {
 		e.logger.WithFields(logrus.Fields{"tool": op.Name(), "error": err}).Error("Operation failed")
-		return nil, fmt.Errorf("operation %s failed: %w", op.Name(), err)
+		return nil, e.failure(ctx, op, args, authCtx, err)
 	}
 
-	// Step 5: Wrap result with metadata
-	elapsed := time.Since(start).Milliseconds()
-	return &OperationResult{
+	// Step 8: Wrap result with metadata, converting it to the operation's
+	// output type when it declares one. Ambari's response is kept alongside
+	// when the call selected fields the type may not cover.
+	res := &OperationResult{
 		Tool:          op.Name(),
 		OperationType: string(op.Type()),
-		ExecutionMs:   elapsed,
-		Timestamp:     time.Now().UTC().Format(time.RFC3339),
 		Result:        result,
-	}, nil
+	}
+	if s, ok := op.(StructuredOperation); ok {
+		res.Structured, res.Text, err = s.Output().Structure(result)
+		if err == nil && rawRequested(op, args) {
+			res.Structured, res.Text, err = withRaw(res.Structured, res.Text, result)
+		}
+		if err != nil {
+			e.logger.WithFields(logrus.Fields{"tool": op.Name(), "error": err}).Error("Result does not match output type")
+			return nil, NewToolError(op.Name(), CodeInternal, fmt.Errorf("result of %s: %w", op.Name(), err))
+		}
+	}
+	res.ExecutionMs = time.Since(start).Milliseconds()
+	res.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	return res, nil
 }
 
-func (e *Executor) checkPermissions(op Operation, authCtx *auth.AuthContext) error {
-	required := op.RequiredPermissions()
-	if len(required) == 0 {
-		return nil
+// failure classifies an error returned by Execute and, for NOT_FOUND, looks
+// up the valid names of the argument that was not found among those authCtx
+// may see
+func (e *Executor) failure(ctx context.Context, op Operation, args map[string]interface{}, authCtx *auth.AuthContext, err error) *ToolError {
+	te := AsToolError(op.Name(), fmt.Errorf("operation %s failed: %w", op.Name(), err))
+	if te.Code == CodeNotFound {
+		instance, _ := args[InstanceArg].(string)
+		te.Hints = append(te.Hints, notFoundHints(ctx, e.client, args, authCtx, e.instance(instance))...)
+	}
+	return te
+}
+
+// Target returns the instance, cluster and service a call of op targets.
+// Cluster and service are taken only from the clusterName and serviceName
+// arguments op declares, so a caller cannot name a cluster for a tool that
+// does not act on one. Tools without a clusterName argument, such as user
+// management, target no cluster. Instance is empty for the default instance.
+func Target(op Operation, args map[string]interface{}) auth.Scope {
+	props := op.Definition().InputSchema.Properties
+	var target auth.Scope
+	target.Instance, _ = args[InstanceArg].(string)
+	if _, ok := props["clusterName"]; ok {
+		target.Cluster, _ = args["clusterName"].(string)
+	}
+	if _, ok := props["serviceName"]; ok && target.Cluster != "" {
+		target.Service, _ = args["serviceName"].(string)
+	}
+	return target
+}
+
+// Admit applies the checks Run makes before calling a tool to a read that
+// is not a tool call, such as an MCP resource: authCtx must hold perms on
+// target and be allowed its cluster. It returns ctx set up like a tool call's,
+// with the caller's Ambari credentials and the instance, if not empty.
+func (e *Executor) Admit(ctx context.Context, name string, perms []auth.Permission, target auth.Scope, instance string, authCtx *auth.AuthContext) (context.Context, error) {
+	target.Instance = e.instance(instance)
+	if err := authorize(name, perms, target, authCtx); err != nil {
+		return nil, err
+	}
+	return e.callContext(ctx, name, authCtx, instance)
+}
+
+// instance returns the name of the instance a call runs against, resolving
+// the default instance when the client routes between several
+func (e *Executor) instance(name string) string {
+	if r, ok := e.client.(*client.Registry); ok && name == "" {
+		return r.Default()
+	}
+	return name
+}
+
+// authorizeAny checks that authCtx holds perms on at least some target
+func authorizeAny(name string, perms []auth.Permission, authCtx *auth.AuthContext) error {
+	if authCtx == nil {
+		return NewToolError(name, CodePermissionDenied, fmt.Errorf("unauthenticated call to %s", name))
+	}
+	var missing []string
+	for _, perm := range perms {
+		if !authCtx.HasPermissionAnywhere(perm) {
+			missing = append(missing, string(perm))
+		}
+	}
+	if len(missing) > 0 {
+		return NewToolError(name, CodePermissionDenied, fmt.Errorf("insufficient permissions for %s: requires %s on some cluster",
+			name, strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+// authorize runs the permission and cluster checks of a call
+func authorize(name string, perms []auth.Permission, target auth.Scope, authCtx *auth.AuthContext) error {
+	if err := checkPermissions(name, perms, target, authCtx); err != nil {
+		return NewToolError(name, CodePermissionDenied, err)
 	}
-	if !authCtx.HasAllPermissions(required...) {
-		return fmt.Errorf("insufficient permissions for %s (requires %v)", op.Name(), required)
+	if err := checkClusters(name, target, authCtx); err != nil {
+		return NewToolError(name, CodePermissionDenied, err)
 	}
 	return nil
 }
 
+// callContext returns ctx with the Ambari credentials of authCtx, when the
+// credential mode uses the caller's, the instance to call and the identity
+func (e *Executor) callContext(ctx context.Context, name string, authCtx *auth.AuthContext, instance string) (context.Context, error) {
+	creds, err := e.credentials.Resolve(authCtx)
+	if err != nil {
+		return nil, NewToolError(name, CodePermissionDenied, fmt.Errorf("ambari credentials for %s: %w", name, err))
+	}
+	if creds != nil {
+		ctx = client.WithCredentials(ctx, *creds)
+	}
+	if instance != "" {
+		ctx = client.WithInstance(ctx, instance)
+	}
+	return auth.WithAuthContext(ctx, authCtx), nil
+}
+
+// checkPermissions evaluates the required permissions against the cluster and
+// service the call targets, so scoped grants only authorize their own
+// resources. Calls that target no cluster need global grants.
+func checkPermissions(name string, perms []auth.Permission, target auth.Scope, authCtx *auth.AuthContext) error {
+	if authCtx == nil {
+		return fmt.Errorf("unauthenticated call to %s", name)
+	}
+
+	var missing []string
+	for _, perm := range perms {
+		if !authCtx.HasPermissionOn(perm, target) {
+			missing = append(missing, string(perm))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("insufficient permissions for %s: requires %s on %s",
+			name, strings.Join(missing, ", "), target)
+	}
+	return nil
+}
+
+// checkClusters applies the identity's cluster restriction, if any, to the
+// cluster the call targets. Restricted identities cannot call tools that
+// target no cluster.
+func checkClusters(name string, target auth.Scope, authCtx *auth.AuthContext) error {
+	if authCtx.AllowsCluster(target.Cluster) {
+		return nil
+	}
+	if target.Cluster == "" {
+		return fmt.Errorf("%s is restricted to clusters %s and %s does not target a cluster",
+			authCtx.Username, strings.Join(authCtx.Clusters, ", "), name)
+	}
+	return fmt.Errorf("%s is not permitted on cluster %s", authCtx.Username, target.Cluster)
+}
+
+// checkPolicy evaluates the policy rules for the call and logs the decision.
+// A call held for approval runs once its approval was granted. Anything else
+// other than allow is returned to the client as a *policy.Violation.
+func (e *Executor) checkPolicy(ctx context.Context, op Operation, authCtx *auth.AuthContext, args map[string]interface{}) error {
+	dangerous := false
+	if d, ok := op.(interface{ IsDangerous() bool }); ok {
+		dangerous = d.IsDangerous()
+	}
+	req := policy.Request{
+		Tool:      op.Name(),
+		Category:  op.Category(),
+		Type:      string(op.Type()),
+		Dangerous: dangerous,
+		Args:      args,
+		Username:  authCtx.Username,
+		Groups:    authCtx.Groups,
+		Time:      time.Now(),
+
+		TargetGroups: e.targetGroups(ctx, op, args),
+	}
+	decision := e.policies.Evaluate(req)
+
+	entry := e.logger.WithFields(logrus.Fields{
+		"user": authCtx.Username, "tool": op.Name(), "decision": decision.Effect, "rule": decision.Rule,
+	})
+	switch decision.Effect {
+	case policy.Allow:
+		if decision.Rule != "" {
+			entry.Info("Policy decision")
+		}
+		return nil
+	case policy.RequireApproval:
+		approval, granted := e.policies.Hold(req, decision)
+		entry = entry.WithFields(logrus.Fields{"approval": approval.ID, "approved_by": approval.ApprovedBy})
+		if granted {
+			entry.Info("Approved call")
+			return nil
+		}
+		entry.WithField("reason", decision.Reason).Warn("Policy decision")
+		return &policy.Violation{Tool: op.Name(), Decision: decision, Approval: &approval}
+	}
+	entry.WithField("reason", decision.Reason).Warn("Policy decision")
+	return &policy.Violation{Tool: op.Name(), Decision: decision}
+}
+
+// targetGroups returns a lookup of the groups of the user named by the
+// username argument, for tools that declare one. A user that does not exist
+// yet belongs to no groups.
+func (e *Executor) targetGroups(ctx context.Context, op Operation, args map[string]interface{}) func() ([]string, error) {
+	if _, ok := op.Definition().InputSchema.Properties["username"]; !ok {
+		return nil
+	}
+	username, _ := args["username"].(string)
+	if username == "" {
+		return nil
+	}
+	return func() ([]string, error) {
+		user, err := model.NewClient(e.client).User(ctx, username)
+		var ae *client.AmbariError
+		if errors.As(err, &ae) && ae.Status == http.StatusNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read groups of user %s: %w", username, err)
+		}
+		return user.Groups, nil
+	}
+}
+
 // ResultJSON is a helper to marshal OperationResult to JSON string
 func (r *OperationResult) JSON() string {
 	b, _ := json.MarshalIndent(r, "", "  ")
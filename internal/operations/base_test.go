@@ -0,0 +1,383 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mcp-ambari/internal/auth"
+	"mcp-ambari/internal/client"
+	"mcp-ambari/internal/policy"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeOperation records whether the Executor let a call through
+type fakeOperation struct {
+	ReadOnlyBase
+	props    map[string]interface{}
+	executed bool
+}
+
+func (o *fakeOperation) Definition() ToolDefinition {
+	return ToolDefinition{Name: o.OpName, InputSchema: ToolSchema{Type: "object", Properties: o.props}}
+}
+func (o *fakeOperation) Validate(map[string]interface{}) error { return nil }
+func (o *fakeOperation) Execute(context.Context, map[string]interface{}) (interface{}, error) {
+	o.executed = true
+	return map[string]interface{}{}, nil
+}
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func TestExecutorScope(t *testing.T) {
+	str := map[string]interface{}{"type": "string"}
+	createUser := func() *fakeOperation {
+		return &fakeOperation{
+			ReadOnlyBase: ReadOnlyBase{OpName: "create_user", Permissions: []auth.Permission{auth.ClusterAdmin}},
+			props:        map[string]interface{}{"username": str},
+		}
+	}
+	restart := func() *fakeOperation {
+		return &fakeOperation{
+			ReadOnlyBase: ReadOnlyBase{OpName: "restart_service", Permissions: []auth.Permission{auth.ServiceRestart}},
+			props:        map[string]interface{}{"clusterName": str, "serviceName": str},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		op       *fakeOperation
+		grants   []auth.Permission
+		args     map[string]interface{}
+		wantCode string
+	}{
+		{
+			name:   "global grant on untargeted tool",
+			op:     createUser(),
+			grants: []auth.Permission{auth.ClusterAdmin},
+			args:   map[string]interface{}{"username": "bob"},
+		},
+		{
+			name:     "scoped grant on untargeted tool",
+			op:       createUser(),
+			grants:   []auth.Permission{"cluster:admin@analytics"},
+			args:     map[string]interface{}{"username": "bob"},
+			wantCode: CodePermissionDenied,
+		},
+		{
+			name:     "undeclared clusterName does not scope an untargeted tool",
+			op:       createUser(),
+			grants:   []auth.Permission{"cluster:admin@analytics"},
+			args:     map[string]interface{}{"username": "bob", "clusterName": "analytics"},
+			wantCode: CodePermissionDenied,
+		},
+		{
+			name:   "scoped grant on its cluster",
+			op:     restart(),
+			grants: []auth.Permission{"service:restart@analytics"},
+			args:   map[string]interface{}{"clusterName": "analytics", "serviceName": "HDFS"},
+		},
+		{
+			name:     "scoped grant on other cluster",
+			op:       restart(),
+			grants:   []auth.Permission{"service:restart@analytics"},
+			args:     map[string]interface{}{"clusterName": "prod", "serviceName": "HDFS"},
+			wantCode: CodePermissionDenied,
+		},
+		{
+			name:     "service grant on other service",
+			op:       restart(),
+			grants:   []auth.Permission{"service:restart@analytics/YARN"},
+			args:     map[string]interface{}{"clusterName": "analytics", "serviceName": "HDFS"},
+			wantCode: CodePermissionDenied,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Without schemas, so the scope check itself must ignore
+			// undeclared arguments rather than rely on argument checking
+			e := NewExecutor(nil, nil, nil, map[string]*jsonschema.Resolved{}, testLogger())
+			_, err := e.Run(context.Background(), tt.op, tt.args, &auth.AuthContext{Username: "alice", Permissions: tt.grants})
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+				if !tt.op.executed {
+					t.Fatal("operation was not executed")
+				}
+				return
+			}
+			var te *ToolError
+			if !errors.As(err, &te) || te.Code != tt.wantCode {
+				t.Fatalf("Run() error = %v, want %s", err, tt.wantCode)
+			}
+			if tt.op.executed {
+				t.Fatal("operation was executed")
+			}
+		})
+	}
+}
+
+func TestExecutorClusterRestriction(t *testing.T) {
+	str := map[string]interface{}{"type": "string"}
+	tests := []struct {
+		name     string
+		props    map[string]interface{}
+		clusters []string
+		args     map[string]interface{}
+		wantErr  bool
+	}{
+		{"unrestricted", map[string]interface{}{"clusterName": str}, nil, map[string]interface{}{"clusterName": "c2"}, false},
+		{"allowed cluster", map[string]interface{}{"clusterName": str}, []string{"c1"}, map[string]interface{}{"clusterName": "c1"}, false},
+		{"other cluster", map[string]interface{}{"clusterName": str}, []string{"c1"}, map[string]interface{}{"clusterName": "c2"}, true},
+		{"unrestricted untargeted tool", map[string]interface{}{"username": str}, nil, map[string]interface{}{"username": "bob"}, false},
+		{"restricted untargeted tool", map[string]interface{}{"username": str}, []string{"c1"}, map[string]interface{}{"username": "bob"}, true},
+		{"undeclared clusterName", map[string]interface{}{"username": str}, []string{"c1"}, map[string]interface{}{"username": "bob", "clusterName": "c1"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := &fakeOperation{
+				ReadOnlyBase: ReadOnlyBase{OpName: "tool", Permissions: []auth.Permission{auth.ClusterView}},
+				props:        tt.props,
+			}
+			authCtx := &auth.AuthContext{Username: "ci-bot", Permissions: []auth.Permission{auth.ClusterView}, Clusters: tt.clusters}
+			e := NewExecutor(nil, nil, nil, nil, testLogger())
+			_, err := e.Run(context.Background(), op, tt.args, authCtx)
+			if (err != nil) != tt.wantErr || op.executed == tt.wantErr {
+				t.Fatalf("Run() error = %v, executed = %v, want error %v", err, op.executed, tt.wantErr)
+			}
+		})
+	}
+}
+
+// usersClient serves the groups of Ambari users; a user not listed is 404
+type usersClient struct {
+	groups map[string][]interface{}
+	err    error
+	reads  int
+}
+
+func (c *usersClient) Get(_ context.Context, path string, _ map[string]string) (map[string]interface{}, error) {
+	c.reads++
+	if c.err != nil {
+		return nil, c.err
+	}
+	name := strings.TrimPrefix(path, "/users/")
+	groups, ok := c.groups[name]
+	if !ok {
+		return nil, &client.AmbariError{Status: http.StatusNotFound, Path: path}
+	}
+	return map[string]interface{}{"Users": map[string]interface{}{"user_name": name, "groups": groups}}, nil
+}
+func (c *usersClient) Post(context.Context, string, map[string]string, interface{}) (map[string]interface{}, error) {
+	panic("unexpected POST")
+}
+func (c *usersClient) Put(context.Context, string, map[string]string, interface{}) (map[string]interface{}, error) {
+	panic("unexpected PUT")
+}
+func (c *usersClient) Delete(context.Context, string, map[string]string) (map[string]interface{}, error) {
+	panic("unexpected DELETE")
+}
+
+func testPolicy(t *testing.T, rules string) *policy.Engine {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := policy.NewEngine(path, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestExecutorPolicyTargetGroups(t *testing.T) {
+	policies := testPolicy(t, `
+rules:
+  - name: admins-cannot-be-deleted
+    decision: deny
+    match:
+      target_groups: [ambari-admins]
+`)
+	str := map[string]interface{}{"type": "string"}
+	tests := []struct {
+		name      string
+		props     map[string]interface{}
+		args      map[string]interface{}
+		err       error
+		wantCode  string
+		wantReads int
+	}{
+		{name: "admin target", props: map[string]interface{}{"username": str}, args: map[string]interface{}{"username": "alice"}, wantCode: CodePolicyDenied, wantReads: 1},
+		{name: "other target", props: map[string]interface{}{"username": str}, args: map[string]interface{}{"username": "bob"}, wantReads: 1},
+		{name: "unknown target", props: map[string]interface{}{"username": str}, args: map[string]interface{}{"username": "carol"}, wantReads: 1},
+		{name: "groups unreadable", props: map[string]interface{}{"username": str}, args: map[string]interface{}{"username": "bob"}, err: errors.New("connection refused"), wantCode: CodePolicyDenied, wantReads: 1},
+		{name: "undeclared username", props: map[string]interface{}{"clusterName": str}, args: map[string]interface{}{"clusterName": "c1", "username": "alice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &usersClient{groups: map[string][]interface{}{"alice": {"users", "ambari-admins"}, "bob": {"users"}}, err: tt.err}
+			op := &fakeOperation{
+				ReadOnlyBase: ReadOnlyBase{OpName: "ambari_users_deleteuser", Permissions: []auth.Permission{auth.ClusterAdmin}},
+				props:        tt.props,
+			}
+			e := NewExecutor(c, policies, nil, map[string]*jsonschema.Resolved{}, testLogger())
+			_, err := e.Run(context.Background(), op, tt.args, &auth.AuthContext{Username: "admin", Permissions: auth.AllPermissions})
+			var te *ToolError
+			if tt.wantCode == "" && err != nil || tt.wantCode != "" && (!errors.As(err, &te) || te.Code != tt.wantCode) {
+				t.Fatalf("Run() error = %v, want code %q", err, tt.wantCode)
+			}
+			if c.reads != tt.wantReads {
+				t.Errorf("read users %d times, want %d", c.reads, tt.wantReads)
+			}
+		})
+	}
+}
+
+func TestExecutorApproval(t *testing.T) {
+	policies := testPolicy(t, `
+rules:
+  - name: stop-needs-approval
+    decision: require-approval
+    approvers: [change-managers]
+    match: {tools: [ambari_services_stopservice]}
+`)
+	e := NewExecutor(nil, policies, nil, map[string]*jsonschema.Resolved{}, testLogger())
+	alice := &auth.AuthContext{Username: "alice", Permissions: auth.AllPermissions}
+	op := &fakeOperation{ReadOnlyBase: ReadOnlyBase{OpName: "ambari_services_stopservice"}}
+	args := map[string]interface{}{"serviceName": "HDFS"}
+
+	_, err := e.Run(context.Background(), op, args, alice)
+	var te *ToolError
+	if !errors.As(err, &te) || te.Code != CodeApprovalRequired || len(te.Hints) == 0 || op.executed {
+		t.Fatalf("Run() error = %v, want %s with a hint", err, CodeApprovalRequired)
+	}
+	var violation *policy.Violation
+	if !errors.As(err, &violation) || violation.Approval == nil {
+		t.Fatalf("Run() error = %v, want the held approval", err)
+	}
+	if _, err := policies.Grant(violation.Approval.ID, "carol", []string{"change-managers"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Other arguments are not covered by the approval
+	if _, err := e.Run(context.Background(), op, map[string]interface{}{"serviceName": "YARN"}, alice); !errors.As(err, &te) || te.Code != CodeApprovalRequired || op.executed {
+		t.Fatalf("Run() with other arguments error = %v, want %s", err, CodeApprovalRequired)
+	}
+	if _, err := e.Run(context.Background(), op, args, alice); err != nil || !op.executed {
+		t.Fatalf("approved Run() error = %v, executed = %v", err, op.executed)
+	}
+	op.executed = false
+	if _, err := e.Run(context.Background(), op, args, alice); !errors.As(err, &te) || te.Code != CodeApprovalRequired || op.executed {
+		t.Fatalf("repeated Run() error = %v, want %s", err, CodeApprovalRequired)
+	}
+}
+
+// clusterInfo is the typed output of typedOperation
+type clusterInfo struct {
+	Name string `json:"name"`
+}
+
+// typedOperation returns an Ambari cluster resource with a typed output
+type typedOperation struct {
+	fakeOperation
+}
+
+func (o *typedOperation) Execute(context.Context, map[string]interface{}) (interface{}, error) {
+	return map[string]interface{}{"Clusters": map[string]interface{}{"cluster_name": "c1", "desired_configs": map[string]interface{}{"hdfs-site": "v2"}}}, nil
+}
+
+func (o *typedOperation) Output() Output {
+	return TypedOutput(DecodeItem(func(m map[string]interface{}) clusterInfo {
+		info, _ := m["Clusters"].(map[string]interface{})
+		name, _ := info["cluster_name"].(string)
+		return clusterInfo{Name: name}
+	}), func(c clusterInfo) string { return "cluster " + c.Name })
+}
+
+func TestExecutorRawFields(t *testing.T) {
+	op := &typedOperation{fakeOperation{
+		ReadOnlyBase: ReadOnlyBase{OpName: "ambari_clusters_getcluster", Permissions: []auth.Permission{auth.ClusterView}},
+		props:        map[string]interface{}{"fields": map[string]interface{}{"type": "string", "default": "Clusters/*"}},
+	}}
+	registry := NewRegistry(testLogger())
+	if err := registry.Register(op); err != nil {
+		t.Fatal(err)
+	}
+	outputs, err := registry.OutputSchemas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := outputs[op.Name()].Properties[RawProperty]; !ok {
+		t.Fatalf("output schema has no %s property", RawProperty)
+	}
+	schemas, err := registry.CheckSchemas()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		wantRaw bool
+	}{
+		{name: "default fields", args: map[string]interface{}{}},
+		{name: "default fields given", args: map[string]interface{}{"fields": "Clusters/*"}},
+		{name: "other fields", args: map[string]interface{}{"fields": "Clusters/desired_configs"}, wantRaw: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewExecutor(nil, nil, nil, schemas, testLogger())
+			res, err := e.Run(context.Background(), op, tt.args, &auth.AuthContext{Username: "alice", Permissions: []auth.Permission{auth.ClusterView}})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			m, isMap := res.Structured.(map[string]interface{})
+			if !tt.wantRaw {
+				if isMap || res.Text != "cluster c1" {
+					t.Errorf("Run() = %#v, %q, want the typed value only", res.Structured, res.Text)
+				}
+				return
+			}
+			raw, _ := m[RawProperty].(map[string]interface{})
+			if m["name"] != "c1" || raw["Clusters"] == nil {
+				t.Errorf("Structured = %v, want name and raw", m)
+			}
+			if !strings.Contains(res.Text, `"desired_configs"`) {
+				t.Errorf("Text = %q, want the raw response", res.Text)
+			}
+			resolved, err := outputs[op.Name()].Resolve(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := resolved.Validate(roundTrip(t, m)); err != nil {
+				t.Errorf("result does not match the output schema: %v", err)
+			}
+		})
+	}
+}
+
+func roundTrip(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
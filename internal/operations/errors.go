@@ -0,0 +1,263 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"mcp-ambari/internal/auth"
+	"mcp-ambari/internal/client"
+	"mcp-ambari/internal/policy"
+)
+
+// ApproveTool is the tool that grants calls held for approval
+const ApproveTool = "ambari_policy_approve"
+
+// Stable codes of failed tool calls
+const (
+	CodeInvalidArgument  = "INVALID_ARGUMENT"
+	CodePermissionDenied = "PERMISSION_DENIED"
+	CodePolicyDenied     = "POLICY_DENIED"
+	CodeApprovalRequired = "APPROVAL_REQUIRED"
+	CodeNotFound         = "NOT_FOUND"
+	CodeConflict         = "CONFLICT"
+	CodeAmbariForbidden  = "AMBARI_FORBIDDEN"
+	CodeAmbariRejected   = "AMBARI_REJECTED"
+	CodeAmbariError      = "AMBARI_ERROR"
+	CodeUnavailable      = "AMBARI_UNAVAILABLE"
+	CodeTimeout          = "TIMEOUT"
+	CodeInternal         = "INTERNAL"
+)
+
+// ToolError is a failed tool call as reported to the client: a stable code,
+// the Ambari status and message when Ambari refused the call, and hints on
+// how to recover. Error() keeps the message of the wrapped error.
+type ToolError struct {
+	Code          string   `json:"code"`
+	Message       string   `json:"message"`
+	Tool          string   `json:"tool"`
+	AmbariStatus  int      `json:"ambari_status,omitempty"`
+	AmbariMessage string   `json:"ambari_message,omitempty"`
+	RequestID     string   `json:"request_id,omitempty"`
+	Retryable     bool     `json:"retryable"`
+	Hints         []string `json:"hints,omitempty"`
+	err           error
+}
+
+func (e *ToolError) Error() string { return e.err.Error() }
+func (e *ToolError) Unwrap() error { return e.err }
+
+// Text renders the error for the text content of a tool result
+func (e *ToolError) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", e.Code, e.Message)
+	for _, h := range e.Hints {
+		b.WriteString("\nHint: " + h)
+	}
+	return b.String()
+}
+
+// NewToolError wraps err with an explicit code
+func NewToolError(tool, code string, err error) *ToolError {
+	return &ToolError{Code: code, Message: err.Error(), Tool: tool, err: err}
+}
+
+// invalidArgument reports a bad argument detected while executing a tool
+func invalidArgument(format string, args ...interface{}) error {
+	return NewToolError("", CodeInvalidArgument, fmt.Errorf(format, args...))
+}
+
+// AsToolError classifies err. Errors that already are a *ToolError are
+// returned as they are.
+func AsToolError(tool string, err error) *ToolError {
+	var te *ToolError
+	if errors.As(err, &te) {
+		if te.Tool == "" {
+			te.Tool = tool
+		}
+		return te
+	}
+	te = NewToolError(tool, CodeInternal, err)
+
+	var (
+		ae        *client.AmbariError
+		violation *policy.Violation
+		netErr    net.Error
+	)
+	switch {
+	case errors.As(err, &violation):
+		te.Code = CodePolicyDenied
+		if a := violation.Approval; a != nil {
+			te.Code = CodeApprovalRequired
+			te.Hints = append(te.Hints, fmt.Sprintf("A member of %s other than %s must approve it with %s {\"approvalId\": %q}; "+
+				"then repeat this call with the same arguments before %s", strings.Join(a.Approvers, ", "), a.Requester, ApproveTool, a.ID, a.Expires.UTC().Format(time.RFC3339)))
+		}
+	case errors.Is(err, client.ErrUnknownInstance):
+		te.Code = CodeInvalidArgument
+	case errors.Is(err, client.ErrCircuitOpen):
+		te.Code, te.Retryable = CodeUnavailable, true
+		te.Hints = append(te.Hints, "Ambari is failing and requests to it are paused; see ambari_instances_getconnectionstatus and retry later")
+	case errors.As(err, &ae):
+		te.AmbariStatus, te.AmbariMessage, te.RequestID, te.Retryable = ae.Status, ae.Message, ae.RequestID, ae.Retryable
+		switch {
+		case ae.Status == http.StatusNotFound:
+			te.Code = CodeNotFound
+		case ae.Status == http.StatusConflict:
+			te.Code = CodeConflict
+			te.Hints = append(te.Hints, "The resource already exists or is in a conflicting state; read it before changing it")
+		case ae.Status == http.StatusUnauthorized || ae.Status == http.StatusForbidden:
+			te.Code = CodeAmbariForbidden
+			te.Hints = append(te.Hints, "The Ambari account used for this call lacks the privilege; this is enforced by Ambari, not by this server")
+		case ae.Retryable:
+			te.Code = CodeUnavailable
+			te.Hints = append(te.Hints, "Ambari is overloaded or restarting; retry later")
+		case ae.Status >= 500:
+			te.Code = CodeAmbariError
+			te.Hints = append(te.Hints, "Ambari failed internally; its server log has details (search for the request_id)")
+		default:
+			te.Code = CodeAmbariRejected
+			te.Hints = append(te.Hints, "Ambari rejected the request; check the arguments against the Ambari message")
+		}
+	case errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout():
+		te.Code, te.Retryable = CodeTimeout, true
+		te.Hints = append(te.Hints, "Retry later, or ask for less data with fields, page_size or filter")
+	case errors.As(err, &netErr):
+		te.Code, te.Retryable = CodeUnavailable, true
+		te.Hints = append(te.Hints, "Ambari could not be reached; see ambari_instances_getinstances")
+	}
+	return te
+}
+
+// hintTimeout bounds the lookups made to explain a NOT_FOUND
+const hintTimeout = 5 * time.Second
+
+// maxHintNames caps the names listed in a hint
+const maxHintNames = 25
+
+// notFoundHints lists the valid names for the argument that most likely
+// caused a 404: the cluster if it does not exist, otherwise the service,
+// host or component. Only names authCtx could list itself on instance are
+// suggested.
+func notFoundHints(ctx context.Context, c client.AmbariClient, args map[string]interface{}, authCtx *auth.AuthContext, instance string) []string {
+	if authCtx == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, hintTimeout)
+	defer cancel()
+	on := func(perm auth.Permission, cluster, service string) bool {
+		return authCtx.AllowsCluster(cluster) && authCtx.HasPermissionOn(perm, auth.Scope{Instance: instance, Cluster: cluster, Service: service})
+	}
+	cluster, _ := args["clusterName"].(string)
+	if cluster != "" {
+		clusters, err := names(ctx, c, "/clusters", "Clusters", "cluster_name")
+		if err != nil {
+			return nil
+		}
+		if !contains(clusters, cluster) {
+			hint := fmt.Sprintf("Cluster %q does not exist", cluster)
+			if visible := filter(clusters, func(name string) bool { return on(auth.ClusterView, name, "") }); len(visible) > 0 {
+				hint += "; clusters: " + list(visible)
+			}
+			return []string{hint}
+		}
+	}
+
+	type lookup struct {
+		arg, kind, path, object, field string
+		visible                        func(name string) bool
+	}
+	var lookups []lookup
+	if cluster != "" {
+		service, _ := args["serviceName"].(string)
+		lookups = append(lookups,
+			lookup{"serviceName", "Service", "/clusters/" + cluster + "/services", "ServiceInfo", "service_name",
+				func(name string) bool { return on(auth.ServiceView, cluster, name) }},
+			lookup{"hostName", "Host", "/clusters/" + cluster + "/hosts", "Hosts", "host_name",
+				func(string) bool { return on(auth.HostView, cluster, "") }},
+		)
+		if service != "" {
+			lookups = append(lookups, lookup{"componentName", "Component", "/clusters/" + cluster + "/services/" + service + "/components", "ServiceComponentInfo", "component_name",
+				func(string) bool { return on(auth.ServiceView, cluster, service) }})
+		}
+	} else {
+		lookups = append(lookups, lookup{"hostName", "Host", "/hosts", "Hosts", "host_name",
+			func(string) bool { return on(auth.HostView, "", "") }})
+	}
+	lookups = append(lookups,
+		lookup{"username", "User", "/users", "Users", "user_name", func(string) bool { return on(auth.ClusterView, "", "") }},
+		lookup{"groupName", "Group", "/groups", "Groups", "group_name", func(string) bool { return on(auth.ClusterView, "", "") }},
+	)
+	for _, l := range lookups {
+		value, _ := args[l.arg].(string)
+		if value == "" {
+			continue
+		}
+		valid, err := names(ctx, c, l.path, l.object, l.field)
+		if err != nil || contains(valid, value) {
+			continue
+		}
+		where := ""
+		if cluster != "" && l.arg != "username" && l.arg != "groupName" {
+			where = " in cluster " + cluster
+		}
+		hint := fmt.Sprintf("%s %q does not exist%s", l.kind, value, where)
+		if visible := filter(valid, l.visible); len(visible) > 0 {
+			hint += fmt.Sprintf("; valid %s values: %s", l.arg, list(visible))
+		}
+		return []string{hint}
+	}
+	return nil
+}
+
+// filter returns the names keep accepts
+func filter(names []string, keep func(string) bool) []string {
+	var out []string
+	for _, name := range names {
+		if keep(name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func names(ctx context.Context, c client.AmbariClient, path, object, field string) ([]string, error) {
+	resp, err := c.Get(ctx, path, map[string]string{"fields": object + "/" + field})
+	if err != nil {
+		return nil, err
+	}
+	items, _ := resp["items"].([]interface{})
+	var out []string
+	for _, item := range items {
+		m, _ := item.(map[string]interface{})
+		info, _ := m[object].(map[string]interface{})
+		if name, ok := info[field].(string); ok {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func list(names []string) string {
+	if len(names) == 0 {
+		return "(none)"
+	}
+	if len(names) > maxHintNames {
+		return strings.Join(names[:maxHintNames], ", ") + fmt.Sprintf(", ... (%d more)", len(names)-maxHintNames)
+	}
+	return strings.Join(names, ", ")
+}
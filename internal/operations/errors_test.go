@@ -0,0 +1,170 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-ambari/internal/auth"
+	"mcp-ambari/internal/client"
+	"mcp-ambari/internal/policy"
+)
+
+// timeoutError is a net.Error that timed out
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// refusedError is a net.Error that did not time out
+type refusedError struct{}
+
+func (refusedError) Error() string   { return "connection refused" }
+func (refusedError) Timeout() bool   { return false }
+func (refusedError) Temporary() bool { return false }
+
+func TestAsToolError(t *testing.T) {
+	status := func(code int) error {
+		return fmt.Errorf("operation failed: %w", &client.AmbariError{Status: code, Message: "msg", RequestID: "r1", Retryable: code == 503})
+	}
+	tests := []struct {
+		name          string
+		err           error
+		wantCode      string
+		wantRetryable bool
+		wantStatus    int
+		wantHint      string
+	}{
+		{name: "404", err: status(http.StatusNotFound), wantCode: CodeNotFound, wantStatus: 404},
+		{name: "409", err: status(http.StatusConflict), wantCode: CodeConflict, wantStatus: 409, wantHint: "already exists"},
+		{name: "401", err: status(http.StatusUnauthorized), wantCode: CodeAmbariForbidden, wantStatus: 401, wantHint: "enforced by Ambari"},
+		{name: "403", err: status(http.StatusForbidden), wantCode: CodeAmbariForbidden, wantStatus: 403},
+		{name: "503", err: status(http.StatusServiceUnavailable), wantCode: CodeUnavailable, wantRetryable: true, wantStatus: 503},
+		{name: "500", err: status(http.StatusInternalServerError), wantCode: CodeAmbariError, wantStatus: 500, wantHint: "request_id"},
+		{name: "400", err: status(http.StatusBadRequest), wantCode: CodeAmbariRejected, wantStatus: 400},
+		{name: "policy deny", err: &policy.Violation{Tool: "t", Decision: policy.Decision{Effect: policy.Deny, Rule: "r"}}, wantCode: CodePolicyDenied},
+		{
+			name: "policy approval",
+			err: &policy.Violation{Tool: "t", Decision: policy.Decision{Effect: policy.RequireApproval, Rule: "r"},
+				Approval: &policy.Approval{ID: "a1", Requester: "alice", Approvers: []string{"change-managers"}, Expires: time.Now()}},
+			wantCode: CodeApprovalRequired,
+			wantHint: `ambari_policy_approve {"approvalId": "a1"}`,
+		},
+		{name: "circuit open", err: fmt.Errorf("GET /clusters: %w", client.ErrCircuitOpen), wantCode: CodeUnavailable, wantRetryable: true, wantHint: "paused"},
+		{name: "unknown instance", err: fmt.Errorf("%w: staging", client.ErrUnknownInstance), wantCode: CodeInvalidArgument},
+		{name: "net timeout", err: fmt.Errorf("HTTP GET failed: %w", timeoutError{}), wantCode: CodeTimeout, wantRetryable: true},
+		{name: "deadline", err: fmt.Errorf("wait: %w", context.DeadlineExceeded), wantCode: CodeTimeout, wantRetryable: true},
+		{name: "connection refused", err: fmt.Errorf("HTTP GET failed: %w", refusedError{}), wantCode: CodeUnavailable, wantRetryable: true},
+		{name: "other", err: errors.New("boom"), wantCode: CodeInternal},
+		{name: "tool error", err: fmt.Errorf("wrapped: %w", NewToolError("", CodeInvalidArgument, errors.New("bad"))), wantCode: CodeInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			te := AsToolError("ambari_test_tool", tt.err)
+			if te.Code != tt.wantCode || te.Retryable != tt.wantRetryable || te.AmbariStatus != tt.wantStatus {
+				t.Errorf("AsToolError() = %s retryable=%v status=%d, want %s retryable=%v status=%d",
+					te.Code, te.Retryable, te.AmbariStatus, tt.wantCode, tt.wantRetryable, tt.wantStatus)
+			}
+			if te.Tool != "ambari_test_tool" {
+				t.Errorf("Tool = %q", te.Tool)
+			}
+			if !errors.Is(te, tt.err) && !errors.Is(tt.err, te) {
+				t.Errorf("Error() = %q lost %q", te.Error(), tt.err)
+			}
+			if tt.wantHint != "" && !strings.Contains(strings.Join(te.Hints, "\n"), tt.wantHint) {
+				t.Errorf("Hints = %q, want one containing %q", te.Hints, tt.wantHint)
+			}
+		})
+	}
+}
+
+// namesClient lists names for the paths notFoundHints reads
+type namesClient struct {
+	names map[string][]string
+	reads []string
+}
+
+func (c *namesClient) Get(_ context.Context, path string, params map[string]string) (map[string]interface{}, error) {
+	c.reads = append(c.reads, path)
+	names, ok := c.names[path]
+	if !ok {
+		return nil, &client.AmbariError{Status: http.StatusNotFound, Path: path}
+	}
+	object, field, _ := strings.Cut(params["fields"], "/")
+	items := make([]interface{}, len(names))
+	for i, name := range names {
+		items[i] = map[string]interface{}{object: map[string]interface{}{field: name}}
+	}
+	return map[string]interface{}{"items": items}, nil
+}
+func (c *namesClient) Post(context.Context, string, map[string]string, interface{}) (map[string]interface{}, error) {
+	panic("unexpected POST")
+}
+func (c *namesClient) Put(context.Context, string, map[string]string, interface{}) (map[string]interface{}, error) {
+	panic("unexpected PUT")
+}
+func (c *namesClient) Delete(context.Context, string, map[string]string) (map[string]interface{}, error) {
+	panic("unexpected DELETE")
+}
+
+func TestNotFoundHints(t *testing.T) {
+	c := &namesClient{names: map[string][]string{
+		"/clusters":                                    {"analytics", "finance", "prod"},
+		"/clusters/analytics/services":                 {"HDFS", "YARN"},
+		"/clusters/analytics/hosts":                    {"a1", "a2"},
+		"/clusters/analytics/services/HDFS/components": {"DATANODE", "NAMENODE"},
+		"/hosts":  {"a1", "a2", "f1"},
+		"/users":  {"admin", "alice"},
+		"/groups": {"ops"},
+	}}
+	global := &auth.AuthContext{Username: "admin", Permissions: auth.AllPermissions}
+	analytics := &auth.AuthContext{Username: "bob", Permissions: []auth.Permission{"cluster:view@analytics", "service:view@analytics/HDFS", "host:view@analytics"}}
+	restricted := &auth.AuthContext{Username: "ci", Permissions: []auth.Permission{auth.ClusterView, auth.ServiceView, auth.HostView}, Clusters: []string{"prod"}}
+	otherInstance := &auth.AuthContext{Username: "eve", Permissions: []auth.Permission{"cluster:view@east:", "host:view@east:"}}
+
+	tests := []struct {
+		name    string
+		authCtx *auth.AuthContext
+		args    map[string]interface{}
+		want    string
+	}{
+		{name: "cluster for global grant", authCtx: global, args: map[string]interface{}{"clusterName": "dev"},
+			want: `Cluster "dev" does not exist; clusters: analytics, finance, prod`},
+		{name: "cluster for scoped grant", authCtx: analytics, args: map[string]interface{}{"clusterName": "dev"},
+			want: `Cluster "dev" does not exist; clusters: analytics`},
+		{name: "cluster for restricted identity", authCtx: restricted, args: map[string]interface{}{"clusterName": "dev"},
+			want: `Cluster "dev" does not exist; clusters: prod`},
+		{name: "cluster for other instance", authCtx: otherInstance, args: map[string]interface{}{"clusterName": "dev"},
+			want: `Cluster "dev" does not exist`},
+		{name: "service for service grant", authCtx: analytics, args: map[string]interface{}{"clusterName": "analytics", "serviceName": "HIVE"},
+			want: `Service "HIVE" does not exist in cluster analytics; valid serviceName values: HDFS`},
+		{name: "component", authCtx: analytics, args: map[string]interface{}{"clusterName": "analytics", "serviceName": "HDFS", "componentName": "JOURNALNODE"},
+			want: `Component "JOURNALNODE" does not exist in cluster analytics; valid componentName values: DATANODE, NAMENODE`},
+		{name: "cluster host", authCtx: analytics, args: map[string]interface{}{"clusterName": "analytics", "hostName": "x"},
+			want: `Host "x" does not exist in cluster analytics; valid hostName values: a1, a2`},
+		{name: "all hosts for global grant", authCtx: global, args: map[string]interface{}{"hostName": "x"},
+			want: `Host "x" does not exist; valid hostName values: a1, a2, f1`},
+		{name: "all hosts for scoped grant", authCtx: analytics, args: map[string]interface{}{"hostName": "x"},
+			want: `Host "x" does not exist`},
+		{name: "users for global grant", authCtx: global, args: map[string]interface{}{"username": "bob"},
+			want: `User "bob" does not exist; valid username values: admin, alice`},
+		{name: "users for scoped grant", authCtx: analytics, args: map[string]interface{}{"username": "bob"},
+			want: `User "bob" does not exist`},
+		{name: "groups for restricted identity", authCtx: restricted, args: map[string]interface{}{"groupName": "admins"},
+			want: `Group "admins" does not exist`},
+		{name: "no caller", args: map[string]interface{}{"clusterName": "dev"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hints := notFoundHints(context.Background(), c, tt.args, tt.authCtx, "")
+			if got := strings.Join(hints, "\n"); got != tt.want {
+				t.Errorf("notFoundHints() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
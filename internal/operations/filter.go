@@ -0,0 +1,207 @@
+package operations
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mcp-ambari/internal/client"
+)
+
+// Limits of the filter argument
+const (
+	MaxFilterDepth = 4
+	MaxFilterTerms = 32
+)
+
+// FilterFields maps the field names a list tool accepts in its filter
+// argument to Ambari properties. Only these properties can be filtered on.
+type FilterFields map[string]string
+
+// PrefixedFields maps each name to prefix/name, e.g. state to HostRoles/state
+func PrefixedFields(prefix string, names ...string) FilterFields {
+	fields := make(FilterFields, len(names))
+	for _, n := range names {
+		fields[n] = prefix + "/" + n
+	}
+	return fields
+}
+
+func (f FilterFields) names() []string {
+	names := make([]string, 0, len(f))
+	for n := range f {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// filterOps are the comparison operators of a filter condition
+var filterOps = []string{"eq", "ne", "lt", "le", "gt", "ge", "in", "matches", "empty"}
+
+// WithFilter adds the filter argument to a list tool's schema properties.
+// Nested nodes refer back to the filter schema; they are compiled and checked
+// by FilterParams.
+func WithFilter(props map[string]interface{}, fields FilterFields) map[string]interface{} {
+	scalar := []string{"string", "number", "boolean"}
+	node := map[string]interface{}{"$ref": "#/properties/filter"}
+	props["filter"] = map[string]interface{}{
+		"type": "object",
+		"description": "Structured filter compiled to an Ambari predicate. A condition is {\"field\", \"op\", \"value\"} " +
+			"with op one of eq, ne, lt, le, gt, ge, in (with \"values\"), matches (Java regex matched against the whole value; | only at the top level, no groups or {n,m}) or empty; " +
+			"conditions combine with {\"and\": [...]}, {\"or\": [...]} and {\"not\": {...}}. Fields: " + strings.Join(fields.names(), ", "),
+		"properties": map[string]interface{}{
+			"field":  map[string]interface{}{"type": "string", "enum": fields.names()},
+			"op":     map[string]interface{}{"type": "string", "enum": filterOps, "description": "Defaults to eq"},
+			"value":  map[string]interface{}{"type": scalar},
+			"values": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": scalar}},
+			"and":    map[string]interface{}{"type": "array", "items": node},
+			"or":     map[string]interface{}{"type": "array", "items": node},
+			"not":    node,
+		},
+		"additionalProperties": false,
+	}
+	return props
+}
+
+// FilterParams compiles the filter argument, if any, and returns params
+// extended with the resulting predicate
+func FilterParams(params map[string]string, args map[string]interface{}, fields FilterFields) (map[string]string, error) {
+	raw, ok := args["filter"]
+	if !ok || raw == nil {
+		return params, nil
+	}
+	c := filterCompiler{fields: fields}
+	p, err := c.compile(raw, 1)
+	if err != nil {
+		return nil, invalidArgument("invalid filter: %w", err)
+	}
+	out, err := client.WithPredicate(params, p)
+	if err != nil {
+		return nil, invalidArgument("invalid filter: %w", err)
+	}
+	return out, nil
+}
+
+type filterCompiler struct {
+	fields FilterFields
+	terms  int
+}
+
+func (c *filterCompiler) compile(raw interface{}, depth int) (client.Predicate, error) {
+	if depth > MaxFilterDepth {
+		return client.Predicate{}, fmt.Errorf("nested deeper than %d levels", MaxFilterDepth)
+	}
+	node, ok := raw.(map[string]interface{})
+	if !ok {
+		return client.Predicate{}, fmt.Errorf("expected an object, got %T", raw)
+	}
+	if len(node) == 1 {
+		for key, value := range node {
+			switch key {
+			case "and", "or":
+				list, ok := value.([]interface{})
+				if !ok || len(list) == 0 {
+					return client.Predicate{}, fmt.Errorf("%q needs a non-empty array", key)
+				}
+				children := make([]client.Predicate, len(list))
+				for i, item := range list {
+					child, err := c.compile(item, depth+1)
+					if err != nil {
+						return client.Predicate{}, err
+					}
+					children[i] = child
+				}
+				if key == "and" {
+					return client.And(children...), nil
+				}
+				return client.Or(children...), nil
+			case "not":
+				child, err := c.compile(value, depth+1)
+				if err != nil {
+					return client.Predicate{}, err
+				}
+				return client.Not(child), nil
+			}
+		}
+	}
+	return c.condition(node)
+}
+
+func (c *filterCompiler) condition(node map[string]interface{}) (client.Predicate, error) {
+	for key := range node {
+		switch key {
+		case "field", "op", "value", "values":
+		default:
+			return client.Predicate{}, fmt.Errorf("unknown key %q", key)
+		}
+	}
+	if c.terms++; c.terms > MaxFilterTerms {
+		return client.Predicate{}, fmt.Errorf("more than %d conditions", MaxFilterTerms)
+	}
+	name, _ := node["field"].(string)
+	field, ok := c.fields[name]
+	if !ok {
+		return client.Predicate{}, fmt.Errorf("unknown field %q (allowed: %s)", name, strings.Join(c.fields.names(), ", "))
+	}
+	op, _ := node["op"].(string)
+	if op == "" {
+		op = "eq"
+	}
+
+	switch op {
+	case "empty":
+		return client.IsEmpty(field), nil
+	case "in":
+		list, ok := node["values"].([]interface{})
+		if !ok || len(list) == 0 {
+			return client.Predicate{}, fmt.Errorf("%s: op in needs a non-empty \"values\" array", name)
+		}
+		values := make([]string, len(list))
+		for i, v := range list {
+			s, err := scalar(name, v)
+			if err != nil {
+				return client.Predicate{}, err
+			}
+			values[i] = s
+		}
+		return client.In(field, values...), nil
+	}
+
+	value, err := scalar(name, node["value"])
+	if err != nil {
+		return client.Predicate{}, err
+	}
+	switch op {
+	case "eq":
+		return client.Eq(field, value), nil
+	case "ne":
+		return client.NotEq(field, value), nil
+	case "lt":
+		return client.Lt(field, value), nil
+	case "le":
+		return client.Le(field, value), nil
+	case "gt":
+		return client.Gt(field, value), nil
+	case "ge":
+		return client.Ge(field, value), nil
+	case "matches":
+		return client.Matches(field, value), nil
+	}
+	return client.Predicate{}, fmt.Errorf("%s: unknown op %q", name, op)
+}
+
+func scalar(field string, v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case nil:
+		return "", fmt.Errorf("%s: missing \"value\"", field)
+	}
+	return "", fmt.Errorf("%s: value must be a string, number or boolean", field)
+}
@@ -0,0 +1,217 @@
+package operations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"mcp-ambari/internal/model"
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// Output describes the typed result of a read-only operation. Structure
+// converts what Execute returned into the value published as the tool's
+// structuredContent, which matches Schema, and a text rendering of it for
+// the tool's text content.
+type Output interface {
+	Schema() (*jsonschema.Schema, error)
+	Structure(result interface{}) (value interface{}, text string, err error)
+}
+
+// StructuredOperation is implemented by operations with a typed result
+type StructuredOperation interface {
+	Output() Output
+}
+
+type typedOutput[T any] struct {
+	decode func(result interface{}) (T, error)
+	render func(T) string
+}
+
+// TypedOutput declares T as the output of an operation. decode converts the
+// result of Execute, render produces its text rendering. The output schema
+// is inferred from T's JSON encoding.
+func TypedOutput[T any](decode func(result interface{}) (T, error), render func(T) string) Output {
+	return typedOutput[T]{decode: decode, render: render}
+}
+
+func (o typedOutput[T]) Schema() (*jsonschema.Schema, error) {
+	return jsonschema.For[T](&jsonschema.ForOptions{})
+}
+
+func (o typedOutput[T]) Structure(result interface{}) (interface{}, string, error) {
+	v, err := o.decode(result)
+	if err != nil {
+		return nil, "", err
+	}
+	return v, o.render(v), nil
+}
+
+// RawProperty is the property of a typed result that carries Ambari's
+// response when the call asked for other than the default fields, which the
+// typed model may not cover
+const RawProperty = "raw"
+
+// rawRequested reports whether a call of op selects fields other than the
+// default of its fields argument
+func rawRequested(op Operation, args map[string]interface{}) bool {
+	prop, ok := op.Definition().InputSchema.Properties["fields"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	fields, _ := args["fields"].(string)
+	def, _ := prop["default"].(string)
+	return fields != "" && fields != def
+}
+
+// withRaw adds the Ambari response raw to a typed value and its text
+func withRaw(value interface{}, text string, raw interface{}) (interface{}, string, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, "", err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, "", err
+	}
+	m[RawProperty] = raw
+	rawJSON, err := json.Marshal(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	return m, text + "\n\nAmbari response with the requested fields:\n" + string(rawJSON), nil
+}
+
+// Result is the decode function of operations whose Execute already returns a T
+func Result[T any](result interface{}) (T, error) {
+	v, ok := result.(T)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("unexpected result type %T, want %T", result, zero)
+	}
+	return v, nil
+}
+
+// List is the output of a list tool. Page is set for tools that page.
+type List[T any] struct {
+	Items []T       `json:"items"`
+	Page  *PageInfo `json:"page,omitempty"`
+}
+
+// DecodeList returns the decode function for collection responses, paged by
+// GetPage or not, whose items decode with item
+func DecodeList[T any](item func(map[string]interface{}) T) func(interface{}) (List[T], error) {
+	return func(result interface{}) (List[T], error) {
+		resp, ok := result.(map[string]interface{})
+		if !ok {
+			return List[T]{}, fmt.Errorf("unexpected result type %T", result)
+		}
+		items := model.Items(resp)
+		list := List[T]{Items: make([]T, 0, len(items))}
+		for _, it := range items {
+			list.Items = append(list.Items, item(it))
+		}
+		if page, ok := resp["page"].(PageInfo); ok {
+			list.Page = &page
+		}
+		return list, nil
+	}
+}
+
+// DecodeItem returns the decode function for single-resource responses
+func DecodeItem[T any](item func(map[string]interface{}) T) func(interface{}) (T, error) {
+	return func(result interface{}) (T, error) {
+		resp, ok := result.(map[string]interface{})
+		if !ok {
+			var zero T
+			return zero, fmt.Errorf("unexpected result type %T", result)
+		}
+		return item(resp), nil
+	}
+}
+
+// Summary is the first line of a list rendering, e.g. "7 hosts" or
+// "100 hosts (1-100 of 2000, more with cursor)"
+func (l List[T]) Summary(noun string) string {
+	line := fmt.Sprintf("%d %s", len(l.Items), noun)
+	if len(l.Items) != 1 {
+		line += "s"
+	}
+	if p := l.Page; p != nil && (p.From > 0 || p.NextCursor != "") {
+		of := ""
+		if p.Total != nil {
+			of = fmt.Sprintf(" of %d", *p.Total)
+		}
+		more := ""
+		if p.NextCursor != "" {
+			more = ", more with cursor"
+		}
+		line += fmt.Sprintf(" (%d-%d%s%s)", p.From+1, p.From+p.Count, of, more)
+	}
+	return line
+}
+
+// Text renders the list as its summary line followed by a table with a row
+// per item
+func (l List[T]) Text(noun string, header []string, row func(T) []string) string {
+	if len(l.Items) == 0 {
+		return l.Summary(noun)
+	}
+	rows := make([][]string, len(l.Items))
+	for i, item := range l.Items {
+		rows[i] = row(item)
+	}
+	return l.Summary(noun) + "\n\n" + Table(header, rows)
+}
+
+// Table renders rows as columns aligned under header
+func Table(header []string, rows [][]string) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+	rendered := strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+	for i, line := range rendered {
+		rendered[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(rendered, "\n")
+}
+
+// OutputSchemas infers and checks the output schema of every registered
+// operation with a typed output
+func (r *Registry) OutputSchemas() (map[string]*jsonschema.Schema, error) {
+	schemas := make(map[string]*jsonschema.Schema)
+	var errs []error
+	for _, op := range r.All() {
+		s, ok := op.(StructuredOperation)
+		if !ok {
+			continue
+		}
+		schema, err := s.Output().Schema()
+		if err == nil && schema.Type != "object" {
+			err = fmt.Errorf("output schema type is %q, not \"object\"", schema.Type)
+		}
+		if _, ok := op.Definition().InputSchema.Properties["fields"]; ok && err == nil {
+			schema.Properties[RawProperty] = &jsonschema.Schema{
+				Type:        "object",
+				Description: "Ambari's response, present when fields other than the default were requested",
+			}
+		}
+		if err == nil {
+			_, err = schema.Resolve(nil)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", op.Name(), err))
+			continue
+		}
+		schemas[op.Name()] = schema
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return schemas, errors.Join(errs...)
+}
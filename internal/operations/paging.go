@@ -0,0 +1,139 @@
+package operations
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"mcp-ambari/internal/client"
+)
+
+// Page sizes of list tools
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// WithPaging adds the paging arguments to a list tool's schema properties
+func WithPaging(props map[string]interface{}, defaultSort string) map[string]interface{} {
+	props["page_size"] = map[string]interface{}{"type": "integer", "description": fmt.Sprintf("Items per page (max %d)", MaxPageSize), "default": DefaultPageSize, "minimum": 1, "maximum": MaxPageSize}
+	props["from"] = map[string]interface{}{"type": "integer", "description": "Offset of the first item", "default": 0, "minimum": 0}
+	props["sortBy"] = map[string]interface{}{"type": "string", "description": "Ambari sort order, e.g. Hosts/host_name.desc", "default": defaultSort}
+	props["cursor"] = map[string]interface{}{"type": "string", "description": "next_cursor of the previous page; overrides page_size, from and sortBy. The other arguments must be the same as in that call"}
+	return props
+}
+
+// PageInfo describes the page a list tool returned
+type PageInfo struct {
+	From     int `json:"from"`
+	PageSize int `json:"page_size"`
+	Count    int `json:"count"`
+	// Total is Ambari's item count, when it reports one
+	Total      *int   `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// pagingArgs are the arguments a cursor replaces
+var pagingArgs = map[string]bool{"page_size": true, "from": true, "sortBy": true, "cursor": true}
+
+// cursor is the state encoded in next_cursor. It is bound to the tool and the
+// other arguments of the call that issued it so it cannot be replayed against
+// a different collection, cluster or filter.
+type cursor struct {
+	Tool     string `json:"t"`
+	Args     string `json:"a"`
+	From     int    `json:"f"`
+	PageSize int    `json:"n"`
+	SortBy   string `json:"s"`
+}
+
+// argsHash hashes the non-paging arguments of a call
+func argsHash(args map[string]interface{}) string {
+	rest := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		if !pagingArgs[k] {
+			rest[k] = v
+		}
+	}
+	// Map keys are marshalled in sorted order, so equal arguments hash equally
+	b, _ := json.Marshal(rest)
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+func (c cursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(tool, value string, args map[string]interface{}) (cursor, error) {
+	var c cursor
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(b, &c)
+	}
+	if err != nil || c.Tool != tool || c.PageSize < 1 || c.From < 0 {
+		return cursor{}, invalidArgument("invalid cursor for %s", tool)
+	}
+	if c.Args != argsHash(args) {
+		return cursor{}, invalidArgument("cursor for %s was issued for different arguments; repeat them unchanged or drop the cursor", tool)
+	}
+	return c, nil
+}
+
+// PageRequestFromArgs reads page_size, from, sortBy or cursor from the arguments
+func PageRequestFromArgs(tool string, args map[string]interface{}, defaultSort string) (client.PageRequest, error) {
+	if value, ok := args["cursor"].(string); ok && value != "" {
+		c, err := decodeCursor(tool, value, args)
+		if err != nil {
+			return client.PageRequest{}, err
+		}
+		return client.PageRequest{PageSize: c.PageSize, From: c.From, SortBy: c.SortBy}, nil
+	}
+	req := client.PageRequest{PageSize: DefaultPageSize, SortBy: defaultSort}
+	if n, ok := args["page_size"].(int); ok {
+		if n < 1 || n > MaxPageSize {
+			return req, invalidArgument("page_size must be between 1 and %d", MaxPageSize)
+		}
+		req.PageSize = n
+	}
+	if n, ok := args["from"].(int); ok {
+		if n < 0 {
+			return req, invalidArgument("from must not be negative")
+		}
+		req.From = n
+	}
+	if s, ok := args["sortBy"].(string); ok && s != "" {
+		req.SortBy = s
+	}
+	return req, nil
+}
+
+// GetPage fetches the page of a collection selected by the call's paging
+// arguments and adds a "page" object with the offsets, total and next_cursor
+func GetPage(ctx context.Context, c client.AmbariClient, tool, path string, params map[string]string, args map[string]interface{}, defaultSort string) (map[string]interface{}, error) {
+	req, err := PageRequestFromArgs(tool, args, defaultSort)
+	if err != nil {
+		return nil, err
+	}
+	page, err := client.GetPage(ctx, c, path, params, req)
+	if err != nil {
+		return nil, err
+	}
+	info := PageInfo{From: page.From, PageSize: page.PageSize, Count: len(page.Items)}
+	if page.Total >= 0 {
+		total := page.Total
+		info.Total = &total
+	}
+	if page.HasNext() {
+		info.NextCursor = cursor{Tool: tool, Args: argsHash(args), From: page.NextFrom, PageSize: req.PageSize, SortBy: req.SortBy}.encode()
+	}
+	resp := page.Response
+	if resp == nil {
+		resp = map[string]interface{}{}
+	}
+	resp["page"] = info
+	return resp, nil
+}
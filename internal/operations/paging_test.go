@@ -0,0 +1,45 @@
+package operations
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPageRequestCursor(t *testing.T) {
+	first := map[string]interface{}{"clusterName": "c1", "filter": map[string]interface{}{"field": "state", "value": "HEALTHY"}, "page_size": 10, "from": 0, "sortBy": "Hosts/host_name"}
+	next := cursor{Tool: "ambari_hosts_gethosts", Args: argsHash(first), From: 10, PageSize: 10, SortBy: "Hosts/host_name"}.encode()
+
+	tests := []struct {
+		name    string
+		tool    string
+		args    map[string]interface{}
+		wantErr bool
+	}{
+		{name: "same arguments", tool: "ambari_hosts_gethosts", args: map[string]interface{}{"clusterName": "c1", "filter": map[string]interface{}{"value": "HEALTHY", "field": "state"}}},
+		{name: "paging arguments ignored", tool: "ambari_hosts_gethosts", args: map[string]interface{}{"clusterName": "c1", "filter": map[string]interface{}{"field": "state", "value": "HEALTHY"}, "page_size": 500, "from": 0}},
+		{name: "other tool", tool: "ambari_users_getusers", args: map[string]interface{}{"clusterName": "c1", "filter": map[string]interface{}{"field": "state", "value": "HEALTHY"}}, wantErr: true},
+		{name: "other cluster", tool: "ambari_hosts_gethosts", args: map[string]interface{}{"clusterName": "c2", "filter": map[string]interface{}{"field": "state", "value": "HEALTHY"}}, wantErr: true},
+		{name: "other filter", tool: "ambari_hosts_gethosts", args: map[string]interface{}{"clusterName": "c1", "filter": map[string]interface{}{"field": "state", "value": "UNHEALTHY"}}, wantErr: true},
+		{name: "filter dropped", tool: "ambari_hosts_gethosts", args: map[string]interface{}{"clusterName": "c1"}, wantErr: true},
+		{name: "other instance", tool: "ambari_hosts_gethosts", args: map[string]interface{}{"clusterName": "c1", "filter": map[string]interface{}{"field": "state", "value": "HEALTHY"}, InstanceArg: "prod"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["cursor"] = next
+			req, err := PageRequestFromArgs(tt.tool, tt.args, "Hosts/host_name")
+			if tt.wantErr {
+				var te *ToolError
+				if !errors.As(err, &te) || te.Code != CodeInvalidArgument {
+					t.Fatalf("PageRequestFromArgs() error = %v, want %s", err, CodeInvalidArgument)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PageRequestFromArgs() error = %v", err)
+			}
+			if req.From != 10 || req.PageSize != 10 {
+				t.Errorf("PageRequestFromArgs() = %+v, want the cursor's page", req)
+			}
+		})
+	}
+}
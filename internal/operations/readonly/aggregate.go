@@ -0,0 +1,404 @@
+package readonly
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"mcp-ambari/internal/auth"
+	"mcp-ambari/internal/client"
+	"mcp-ambari/internal/model"
+	ops "mcp-ambari/internal/operations"
+	"github.com/sirupsen/logrus"
+)
+
+// AggregateResult merges the items of every queried cluster. Each item is
+// labelled with the instance and cluster it came from; failures are reported
+// per source instead of failing the whole call.
+type AggregateResult struct {
+	Items   []map[string]interface{} `json:"items"`
+	Sources []SourceStatus           `json:"sources"`
+	Failed  int                      `json:"failed"`
+	Partial bool                     `json:"partial"`
+}
+
+// SourceStatus is the outcome of one instance (cluster listing) or cluster query
+type SourceStatus struct {
+	Instance string `json:"instance"`
+	Cluster  string `json:"cluster,omitempty"`
+	OK       bool   `json:"ok"`
+	Count    int    `json:"count"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AggregateList is the typed output of an aggregate tool
+type AggregateList[T any] struct {
+	Items   []T            `json:"items"`
+	Sources []SourceStatus `json:"sources"`
+	Failed  int            `json:"failed"`
+	Partial bool           `json:"partial"`
+}
+
+// InstanceAlert is an alert of an aggregate query
+type InstanceAlert struct {
+	Instance string `json:"instance"`
+	model.Alert
+}
+
+// InstanceService is a service of an aggregate query
+type InstanceService struct {
+	Instance string `json:"instance"`
+	model.Service
+}
+
+// decodeAggregate returns the decode function of an aggregate tool whose
+// items decode with item
+func decodeAggregate[T any](item func(instance, cluster string, m map[string]interface{}) T) func(interface{}) (AggregateList[T], error) {
+	return func(result interface{}) (AggregateList[T], error) {
+		r, err := ops.Result[*AggregateResult](result)
+		if err != nil {
+			return AggregateList[T]{}, err
+		}
+		out := AggregateList[T]{Items: make([]T, 0, len(r.Items)), Sources: r.Sources, Failed: r.Failed, Partial: r.Partial}
+		for _, m := range r.Items {
+			instance, _ := m["instance"].(string)
+			cluster, _ := m["cluster"].(string)
+			out.Items = append(out.Items, item(instance, cluster, m))
+		}
+		return out, nil
+	}
+}
+
+// clusterQuery fetches the items of one cluster
+type clusterQuery func(ctx context.Context, c client.AmbariClient, cluster string) ([]interface{}, error)
+
+// aggregator fans a query out to every cluster of the selected instances with
+// at most parallelism requests in flight
+type aggregator struct {
+	instances   *client.Registry
+	parallelism int
+}
+
+func newAggregator(r *client.Registry, parallelism int) aggregator {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	return aggregator{instances: r, parallelism: parallelism}
+}
+
+// AuthorizesTargets tells the Executor that clusters are authorized one by
+// one, see allowed
+func (a aggregator) AuthorizesTargets() bool { return true }
+
+// allowed reports whether authCtx holds perms on the cluster of an instance
+func allowed(authCtx *auth.AuthContext, perms []auth.Permission, instance, cluster string) bool {
+	if !authCtx.AllowsCluster(cluster) {
+		return false
+	}
+	for _, perm := range perms {
+		if !authCtx.HasPermissionOn(perm, auth.Scope{Instance: instance, Cluster: cluster}) {
+			return false
+		}
+	}
+	return true
+}
+
+// instanceAllowed reports whether authCtx holds perms on some cluster of an
+// instance, so that listing its clusters can find any to query
+func instanceAllowed(authCtx *auth.AuthContext, perms []auth.Permission, instance string) bool {
+	for _, perm := range perms {
+		if !authCtx.HasPermissionOnInstance(perm, instance) {
+			return false
+		}
+	}
+	return true
+}
+
+var instancesProperty = map[string]interface{}{
+	"type": "array", "items": map[string]interface{}{"type": "string"},
+	"description": "Ambari instances to query (default: all configured instances)",
+}
+
+// selected returns the instances named by the call: the instances argument,
+// else ambariInstance, else all
+func (a aggregator) selected(args map[string]interface{}) ([]string, error) {
+	var names []string
+	if list, ok := args["instances"].([]interface{}); ok {
+		for _, v := range list {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("instances must be a list of instance names")
+			}
+			names = append(names, name)
+		}
+	} else if name, ok := args[ops.InstanceArg].(string); ok && name != "" {
+		names = []string{name}
+	}
+	if len(names) == 0 {
+		for _, inst := range a.instances.Instances() {
+			names = append(names, inst.Name)
+		}
+	}
+	for _, name := range names {
+		if !a.instances.Has(name) {
+			return nil, fmt.Errorf("unknown Ambari instance %q", name)
+		}
+	}
+	return names, nil
+}
+
+// run queries every cluster of the instances on which the caller holds perms.
+// Without a caller on ctx nothing is queried.
+func (a aggregator) run(ctx context.Context, instanceNames []string, perms []auth.Permission, query clusterQuery) (*AggregateResult, error) {
+	authCtx, ok := auth.GetAuthContext(ctx)
+	if !ok || authCtx == nil {
+		return nil, ops.NewToolError("", ops.CodePermissionDenied, fmt.Errorf("no caller identity to authorize the clusters of an aggregate query"))
+	}
+	type target struct {
+		instance string
+		cluster  string
+		client   client.AmbariClient
+	}
+	sem := make(chan struct{}, a.parallelism)
+	result := &AggregateResult{Items: []map[string]interface{}{}, Sources: []SourceStatus{}}
+	var mu sync.Mutex
+	record := func(status SourceStatus, items []map[string]interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		result.Sources = append(result.Sources, status)
+		result.Items = append(result.Items, items...)
+		if !status.OK {
+			result.Failed++
+		}
+	}
+
+	// Phase 1: list the clusters of every instance the caller holds grants on
+	var targets []target
+	var wg sync.WaitGroup
+	for _, name := range instanceNames {
+		if !instanceAllowed(authCtx, perms, name) {
+			continue
+		}
+		c, _ := a.instances.Client(name)
+		wg.Add(1)
+		go func(name string, c client.AmbariClient) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			clusters, err := listClusters(ctx, c)
+			if err != nil {
+				record(SourceStatus{Instance: name, Error: err.Error()}, nil)
+				return
+			}
+			mu.Lock()
+			for _, cluster := range clusters {
+				targets = append(targets, target{instance: name, cluster: cluster, client: c})
+			}
+			mu.Unlock()
+		}(name, c)
+	}
+	wg.Wait()
+
+	// Phase 2: query every cluster the caller may see
+	for _, t := range targets {
+		if !allowed(authCtx, perms, t.instance, t.cluster) {
+			continue
+		}
+		wg.Add(1)
+		go func(t target) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			raw, err := query(ctx, t.client, t.cluster)
+			status := SourceStatus{Instance: t.instance, Cluster: t.cluster}
+			if err != nil {
+				status.Error = err.Error()
+				record(status, nil)
+				return
+			}
+			items := make([]map[string]interface{}, 0, len(raw))
+			for _, item := range raw {
+				m, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				labelled := map[string]interface{}{"instance": t.instance, "cluster": t.cluster}
+				for k, v := range m {
+					if k != "href" {
+						labelled[k] = v
+					}
+				}
+				items = append(items, labelled)
+			}
+			status.OK, status.Count = true, len(items)
+			record(status, items)
+		}(t)
+	}
+	wg.Wait()
+
+	sort.Slice(result.Sources, func(i, j int) bool {
+		if result.Sources[i].Instance != result.Sources[j].Instance {
+			return result.Sources[i].Instance < result.Sources[j].Instance
+		}
+		return result.Sources[i].Cluster < result.Sources[j].Cluster
+	})
+	sort.SliceStable(result.Items, func(i, j int) bool {
+		a, b := result.Items[i], result.Items[j]
+		if a["instance"] != b["instance"] {
+			return a["instance"].(string) < b["instance"].(string)
+		}
+		return a["cluster"].(string) < b["cluster"].(string)
+	})
+	result.Partial = result.Failed > 0
+	return result, nil
+}
+
+func listClusters(ctx context.Context, c client.AmbariClient) ([]string, error) {
+	resp, err := c.Get(ctx, "/clusters", map[string]string{"fields": "Clusters/cluster_name"})
+	if err != nil {
+		return nil, err
+	}
+	var clusters []string
+	items, _ := resp["items"].([]interface{})
+	for _, item := range items {
+		m, _ := item.(map[string]interface{})
+		info, _ := m["Clusters"].(map[string]interface{})
+		if name, ok := info["cluster_name"].(string); ok {
+			clusters = append(clusters, name)
+		}
+	}
+	return clusters, nil
+}
+
+// stateFilter builds the .in predicate for a comma-separated state list
+func stateFilter(field string, args map[string]interface{}, fallback string) client.Predicate {
+	state, ok := args["state"].(string)
+	if !ok || strings.TrimSpace(state) == "" {
+		return client.In(field, fallback)
+	}
+	var states []string
+	for _, s := range strings.Split(state, ",") {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			states = append(states, s)
+		}
+	}
+	return client.In(field, states...)
+}
+
+// ---------- AggregateAlerts ----------
+
+type AggregateAlerts struct {
+	ops.ReadOnlyBase
+	aggregator
+}
+
+func NewAggregateAlerts(r *client.Registry, parallelism int, l *logrus.Logger) *AggregateAlerts {
+	return &AggregateAlerts{ops.ReadOnlyBase{
+		OpName: "ambari_aggregate_getalerts", OpDescription: "Returns alerts in the given states from every cluster of every Ambari instance",
+		OpCategory: "aggregate", Permissions: []auth.Permission{auth.AlertView}, Client: r, Logger: l,
+	}, newAggregator(r, parallelism)}
+}
+
+func (o *AggregateAlerts) Definition() ops.ToolDefinition {
+	return ops.ToolDefinition{
+		Name: o.OpName, Description: o.OpDescription,
+		InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{
+			"state":     map[string]interface{}{"type": "string", "description": "Comma-separated alert states (" + strings.Join(alertStates, ", ") + ")", "default": "CRITICAL"},
+			"instances": instancesProperty,
+		}, Required: []string{}},
+	}
+}
+
+func (o *AggregateAlerts) Output() ops.Output {
+	return ops.TypedOutput(decodeAggregate(func(instance, cluster string, m map[string]interface{}) InstanceAlert {
+		a := InstanceAlert{Instance: instance, Alert: model.DecodeAlert(m)}
+		a.Cluster = cluster
+		return a
+	}), renderAggregateAlerts)
+}
+
+func (o *AggregateAlerts) Validate(args map[string]interface{}) error {
+	_, err := o.selected(args)
+	return err
+}
+
+func (o *AggregateAlerts) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	names, err := o.selected(args)
+	if err != nil {
+		return nil, err
+	}
+	params, err := client.WithPredicate(map[string]string{
+		"fields": "Alert/definition_name,Alert/label,Alert/service_name,Alert/host_name,Alert/state,Alert/text,Alert/maintenance_state",
+	}, stateFilter("Alert/state", args, "CRITICAL"))
+	if err != nil {
+		return nil, err
+	}
+	return o.run(ctx, names, o.Permissions, func(ctx context.Context, c client.AmbariClient, cluster string) ([]interface{}, error) {
+		resp, err := c.Get(ctx, fmt.Sprintf("/clusters/%s/alerts", cluster), params)
+		if err != nil {
+			return nil, err
+		}
+		items, _ := resp["items"].([]interface{})
+		return items, nil
+	})
+}
+
+// ---------- AggregateServices ----------
+
+type AggregateServices struct {
+	ops.ReadOnlyBase
+	aggregator
+}
+
+func NewAggregateServices(r *client.Registry, parallelism int, l *logrus.Logger) *AggregateServices {
+	return &AggregateServices{ops.ReadOnlyBase{
+		OpName: "ambari_aggregate_getservices", OpDescription: "Returns services in the given states from every cluster of every Ambari instance (INSTALLED means stopped)",
+		OpCategory: "aggregate", Permissions: []auth.Permission{auth.ServiceView}, Client: r, Logger: l,
+	}, newAggregator(r, parallelism)}
+}
+
+func (o *AggregateServices) Definition() ops.ToolDefinition {
+	return ops.ToolDefinition{
+		Name: o.OpName, Description: o.OpDescription,
+		InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{
+			"state":     map[string]interface{}{"type": "string", "description": "Comma-separated service states (e.g. INSTALLED, STARTED, UNKNOWN)", "default": "INSTALLED"},
+			"instances": instancesProperty,
+		}, Required: []string{}},
+	}
+}
+
+func (o *AggregateServices) Output() ops.Output {
+	return ops.TypedOutput(decodeAggregate(func(instance, cluster string, m map[string]interface{}) InstanceService {
+		s := InstanceService{Instance: instance, Service: model.DecodeService(m)}
+		s.Cluster = cluster
+		return s
+	}), renderAggregateServices)
+}
+
+func (o *AggregateServices) Validate(args map[string]interface{}) error {
+	_, err := o.selected(args)
+	return err
+}
+
+func (o *AggregateServices) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	names, err := o.selected(args)
+	if err != nil {
+		return nil, err
+	}
+	params, err := client.WithPredicate(map[string]string{
+		"fields":       "ServiceInfo/service_name,ServiceInfo/state,ServiceInfo/maintenance_state",
+	}, stateFilter("ServiceInfo/state", args, "INSTALLED"))
+	if err != nil {
+		return nil, err
+	}
+	return o.run(ctx, names, o.Permissions, func(ctx context.Context, c client.AmbariClient, cluster string) ([]interface{}, error) {
+		resp, err := c.Get(ctx, fmt.Sprintf("/clusters/%s/services", cluster), params)
+		if err != nil {
+			return nil, err
+		}
+		items, _ := resp["items"].([]interface{})
+		return items, nil
+	})
+}
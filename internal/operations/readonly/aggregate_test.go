@@ -0,0 +1,136 @@
+package readonly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"mcp-ambari/internal/auth"
+	"mcp-ambari/internal/client"
+	ops "mcp-ambari/internal/operations"
+	"github.com/sirupsen/logrus"
+)
+
+// listings records the instances whose clusters were listed
+type listings struct {
+	mu        sync.Mutex
+	instances []string
+}
+
+func (l *listings) add(instance string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.instances = append(l.instances, instance)
+}
+
+func (l *listings) sorted() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := append([]string(nil), l.instances...)
+	sort.Strings(out)
+	return out
+}
+
+// aggregateFixture serves two instances, east and west, each with clusters
+// c1 and c2 that have one critical alert
+func aggregateFixture(t *testing.T, logger *logrus.Logger) (*client.Registry, *listings) {
+	t.Helper()
+	var cfg client.InstancesConfig
+	listed := &listings{}
+	for _, name := range []string{"east", "west"} {
+		name := name
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/clusters":
+				listed.add(name)
+				fmt.Fprint(w, `{"items":[{"Clusters":{"cluster_name":"c1"}},{"Clusters":{"cluster_name":"c2"}}]}`)
+			case strings.HasSuffix(r.URL.Path, "/alerts"):
+				fmt.Fprint(w, `{"items":[{"Alert":{"definition_name":"datanode_process","state":"CRITICAL"}}]}`)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		t.Cleanup(srv.Close)
+		retries := 0
+		cfg.Instances = append(cfg.Instances, client.InstanceConfig{Name: name, BaseURL: srv.URL, Username: "svc", Password: "pw", Retries: &retries})
+	}
+	r, err := client.NewRegistry(cfg, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, listed
+}
+
+func TestAggregateAlertsAuthorizesEachCluster(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name       string
+		grants     []auth.Permission
+		clusters   []string
+		want       []string
+		wantListed []string
+		wantErr    bool
+	}{
+		{name: "global grant", grants: []auth.Permission{auth.AlertView}, want: []string{"east/c1", "east/c2", "west/c1", "west/c2"}, wantListed: []string{"east", "west"}},
+		{name: "cluster grant", grants: []auth.Permission{"alert:view@c2"}, want: []string{"east/c2", "west/c2"}, wantListed: []string{"east", "west"}},
+		{name: "instance grants", grants: []auth.Permission{"alert:view@east:c1", "alert:view@west:"}, want: []string{"east/c1", "west/c1", "west/c2"}, wantListed: []string{"east", "west"}},
+		{name: "one instance", grants: []auth.Permission{"alert:view@west:c1"}, want: []string{"west/c1"}, wantListed: []string{"west"}},
+		{name: "cluster restricted key", grants: []auth.Permission{auth.AlertView}, clusters: []string{"c1"}, want: []string{"east/c1", "west/c1"}, wantListed: []string{"east", "west"}},
+		{name: "other permission only", grants: []auth.Permission{"service:view@c1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances, listed := aggregateFixture(t, logger)
+			op := NewAggregateAlerts(instances, 2, logger)
+			e := ops.NewExecutor(instances, nil, nil, nil, logger)
+			authCtx := &auth.AuthContext{Username: "alice", Permissions: tt.grants, Clusters: tt.clusters}
+			res, err := e.Run(context.Background(), op, map[string]interface{}{}, authCtx)
+			if tt.wantErr {
+				var te *ops.ToolError
+				if !errors.As(err, &te) || te.Code != ops.CodePermissionDenied {
+					t.Fatalf("Run() error = %v, want %s", err, ops.CodePermissionDenied)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			var got []string
+			for _, s := range res.Result.(*AggregateResult).Sources {
+				got = append(got, s.Instance+"/"+s.Cluster)
+			}
+			sort.Strings(got)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("queried %v, want %v", got, tt.want)
+			}
+			if got := listed.sorted(); fmt.Sprint(got) != fmt.Sprint(tt.wantListed) {
+				t.Errorf("listed clusters of %v, want %v", got, tt.wantListed)
+			}
+		})
+	}
+}
+
+func TestAggregateWithoutCaller(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	instances, listed := aggregateFixture(t, logger)
+	op := NewAggregateAlerts(instances, 2, logger)
+
+	_, err := op.Execute(context.Background(), map[string]interface{}{})
+	var te *ops.ToolError
+	if !errors.As(err, &te) || te.Code != ops.CodePermissionDenied {
+		t.Fatalf("Execute() error = %v, want %s", err, ops.CodePermissionDenied)
+	}
+	if got := listed.sorted(); len(got) != 0 {
+		t.Errorf("listed clusters of %v without a caller", got)
+	}
+}
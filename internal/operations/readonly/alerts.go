@@ -6,6 +6,7 @@ import (
 
 	"mcp-ambari/internal/auth"
 	"mcp-ambari/internal/client"
+	"mcp-ambari/internal/model"
 	ops "mcp-ambari/internal/operations"
 	"github.com/sirupsen/logrus"
 )
@@ -19,6 +20,9 @@ func NewGetHost(c client.AmbariClient, l *logrus.Logger) *GetHost {
 func (o *GetHost) Definition() ops.ToolDefinition {
 	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{"hostName": map[string]interface{}{"type": "string", "description": "The name of the host"}, "fields": map[string]interface{}{"type": "string", "description": "Filter fields", "default": "Hosts/*"}}, Required: []string{"hostName"}}}
 }
+func (o *GetHost) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeItem(model.DecodeHost), renderHost)
+}
 func (o *GetHost) Validate(args map[string]interface{}) error {
 	if _, ok := args["hostName"].(string); !ok {
 		return fmt.Errorf("hostName is required")
@@ -42,6 +46,9 @@ func NewGetAlertTargets(c client.AmbariClient, l *logrus.Logger) *GetAlertTarget
 func (o *GetAlertTargets) Definition() ops.ToolDefinition {
 	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{"fields": map[string]interface{}{"type": "string", "description": "Filter fields", "default": "AlertTarget/*"}}, Required: []string{}}}
 }
+func (o *GetAlertTargets) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeList(model.DecodeAlertTarget), renderAlertTargets)
+}
 func (o *GetAlertTargets) Validate(args map[string]interface{}) error { return nil }
 func (o *GetAlertTargets) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	p := map[string]string{"fields": "AlertTarget/*"}
@@ -60,6 +67,9 @@ func NewGetAlertSummary(c client.AmbariClient, l *logrus.Logger) *GetAlertSummar
 func (o *GetAlertSummary) Definition() ops.ToolDefinition {
 	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"}, "maintenanceFilter": map[string]interface{}{"type": "boolean", "description": "Filter out maintenance alerts"}}, Required: []string{"clusterName"}}}
 }
+func (o *GetAlertSummary) Output() ops.Output {
+	return ops.TypedOutput(decodeAlertSummaries, renderAlertSummaries)
+}
 func (o *GetAlertSummary) Validate(args map[string]interface{}) error {
 	if _, ok := args["clusterName"].(string); !ok {
 		return fmt.Errorf("clusterName is required")
@@ -69,7 +79,7 @@ func (o *GetAlertSummary) Validate(args map[string]interface{}) error {
 func (o *GetAlertSummary) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	p := map[string]string{"format": "groupedSummary"}
 	if mf, ok := args["maintenanceFilter"].(bool); ok && mf {
-		p["Alert/maintenance_state.in"] = "OFF"
+		p, _ = client.WithPredicate(p, client.In("Alert/maintenance_state", "OFF"))
 	}
 	return o.Client.Get(ctx, fmt.Sprintf("/clusters/%s/alerts", args["clusterName"].(string)), p)
 }
@@ -83,6 +93,9 @@ func NewGetAlertDetails(c client.AmbariClient, l *logrus.Logger) *GetAlertDetail
 func (o *GetAlertDetails) Definition() ops.ToolDefinition {
 	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"}, "alertId": map[string]interface{}{"type": "string", "description": "Alert definition ID"}}, Required: []string{"clusterName", "alertId"}}}
 }
+func (o *GetAlertDetails) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeList(model.DecodeAlert), renderAlerts)
+}
 func (o *GetAlertDetails) Validate(args map[string]interface{}) error {
 	if _, ok := args["clusterName"].(string); !ok {
 		return fmt.Errorf("clusterName required")
@@ -104,7 +117,10 @@ func NewGetAlertDefinitions(c client.AmbariClient, l *logrus.Logger) *GetAlertDe
 	return &GetAlertDefinitions{ops.ReadOnlyBase{OpName: "ambari_alerts_getalertdefinitions", OpDescription: "Get all alert definitions for a cluster", OpCategory: "alerts", Permissions: []auth.Permission{auth.AlertView}, Client: c, Logger: l}}
 }
 func (o *GetAlertDefinitions) Definition() ops.ToolDefinition {
-	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"}, "fields": map[string]interface{}{"type": "string", "description": "Filter fields", "default": "*"}}, Required: []string{"clusterName"}}}
+	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: ops.WithPaging(map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"}, "fields": map[string]interface{}{"type": "string", "description": "Filter fields", "default": "*"}}, "AlertDefinition/id.asc"), Required: []string{"clusterName"}}}
+}
+func (o *GetAlertDefinitions) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeList(model.DecodeAlertDefinition), renderAlertDefinitions)
 }
 func (o *GetAlertDefinitions) Validate(args map[string]interface{}) error {
 	if _, ok := args["clusterName"].(string); !ok {
@@ -117,7 +133,7 @@ func (o *GetAlertDefinitions) Execute(ctx context.Context, args map[string]inter
 	if f, ok := args["fields"].(string); ok {
 		p["fields"] = f
 	}
-	return o.Client.Get(ctx, fmt.Sprintf("/clusters/%s/alert_definitions", args["clusterName"].(string)), p)
+	return ops.GetPage(ctx, o.Client, o.OpName, fmt.Sprintf("/clusters/%s/alert_definitions", args["clusterName"].(string)), p, args, "AlertDefinition/id.asc")
 }
 
 // ---- GetAlertGroups ----
@@ -129,6 +145,9 @@ func NewGetAlertGroups(c client.AmbariClient, l *logrus.Logger) *GetAlertGroups
 func (o *GetAlertGroups) Definition() ops.ToolDefinition {
 	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"}}, Required: []string{"clusterName"}}}
 }
+func (o *GetAlertGroups) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeList(model.DecodeAlertGroup), renderAlertGroups)
+}
 func (o *GetAlertGroups) Validate(args map[string]interface{}) error {
 	if _, ok := args["clusterName"].(string); !ok {
 		return fmt.Errorf("clusterName required")
@@ -148,6 +167,9 @@ func NewGetNotifications(c client.AmbariClient, l *logrus.Logger) *GetNotificati
 func (o *GetNotifications) Definition() ops.ToolDefinition {
 	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"}}, Required: []string{"clusterName"}}}
 }
+func (o *GetNotifications) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeList(model.DecodeAlertTarget), renderAlertTargets)
+}
 func (o *GetNotifications) Validate(args map[string]interface{}) error {
 	if _, ok := args["clusterName"].(string); !ok {
 		return fmt.Errorf("clusterName required")
@@ -167,6 +189,9 @@ func NewGetServicesWithStaleConfigs(c client.AmbariClient, l *logrus.Logger) *Ge
 func (o *GetServicesWithStaleConfigs) Definition() ops.ToolDefinition {
 	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"}, "serviceName": map[string]interface{}{"type": "string", "description": "Filter by service (optional)"}}, Required: []string{"clusterName"}}}
 }
+func (o *GetServicesWithStaleConfigs) Output() ops.Output {
+	return ops.TypedOutput(decodeStaleServices, renderStaleServices)
+}
 func (o *GetServicesWithStaleConfigs) Validate(args map[string]interface{}) error {
 	if _, ok := args["clusterName"].(string); !ok {
 		return fmt.Errorf("clusterName required")
@@ -185,11 +210,22 @@ func (o *GetServicesWithStaleConfigs) Execute(ctx context.Context, args map[stri
 // ---- GetHostComponentsWithStaleConfigs ----
 type GetHostComponentsWithStaleConfigs struct{ ops.ReadOnlyBase }
 
+const hostComponentSort = "HostRoles/host_name.asc,HostRoles/component_name.asc"
+
+var hostComponentFilterFields = ops.PrefixedFields("HostRoles",
+	"host_name", "component_name", "service_name", "state", "desired_state", "maintenance_state")
+
+var requestFilterFields = ops.PrefixedFields("Requests",
+	"id", "request_context", "request_status", "progress_percent", "create_time", "start_time", "end_time")
+
 func NewGetHostComponentsWithStaleConfigs(c client.AmbariClient, l *logrus.Logger) *GetHostComponentsWithStaleConfigs {
 	return &GetHostComponentsWithStaleConfigs{ops.ReadOnlyBase{OpName: "ambari_services_gethostcomponentswithstaleconfigs", OpDescription: "Get host components needing restart due to stale configurations", OpCategory: "services", Permissions: []auth.Permission{auth.ServiceView}, Client: c, Logger: l}}
 }
 func (o *GetHostComponentsWithStaleConfigs) Definition() ops.ToolDefinition {
-	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"}, "hostName": map[string]interface{}{"type": "string", "description": "Filter by host"}, "serviceName": map[string]interface{}{"type": "string", "description": "Filter by service"}, "componentName": map[string]interface{}{"type": "string", "description": "Filter by component"}}, Required: []string{"clusterName"}}}
+	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: ops.WithFilter(ops.WithPaging(map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"}, "hostName": map[string]interface{}{"type": "string", "description": "Filter by host"}, "serviceName": map[string]interface{}{"type": "string", "description": "Filter by service"}, "componentName": map[string]interface{}{"type": "string", "description": "Filter by component"}}, hostComponentSort), hostComponentFilterFields), Required: []string{"clusterName"}}}
+}
+func (o *GetHostComponentsWithStaleConfigs) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeList(model.DecodeHostComponent), renderHostComponents)
 }
 func (o *GetHostComponentsWithStaleConfigs) Validate(args map[string]interface{}) error {
 	if _, ok := args["clusterName"].(string); !ok {
@@ -208,7 +244,11 @@ func (o *GetHostComponentsWithStaleConfigs) Execute(ctx context.Context, args ma
 	if c, ok := args["componentName"].(string); ok {
 		p["HostRoles/component_name"] = c
 	}
-	return o.Client.Get(ctx, fmt.Sprintf("/clusters/%s/host_components", args["clusterName"].(string)), p)
+	p, err := ops.FilterParams(p, args, hostComponentFilterFields)
+	if err != nil {
+		return nil, err
+	}
+	return ops.GetPage(ctx, o.Client, o.OpName, fmt.Sprintf("/clusters/%s/host_components", args["clusterName"].(string)), p, args, hostComponentSort)
 }
 
 // ---- GetRollingRestartStatus ----
@@ -218,7 +258,10 @@ func NewGetRollingRestartStatus(c client.AmbariClient, l *logrus.Logger) *GetRol
 	return &GetRollingRestartStatus{ops.ReadOnlyBase{OpName: "ambari_services_getrollingrestartstatus", OpDescription: "Get status of rolling restart operations", OpCategory: "services", Permissions: []auth.Permission{auth.ServiceView}, Client: c, Logger: l}}
 }
 func (o *GetRollingRestartStatus) Definition() ops.ToolDefinition {
-	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"}, "serviceName": map[string]interface{}{"type": "string", "description": "Filter by service"}, "requestId": map[string]interface{}{"type": "string", "description": "Filter by request ID"}}, Required: []string{"clusterName"}}}
+	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: ops.WithFilter(ops.WithPaging(map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"}, "serviceName": map[string]interface{}{"type": "string", "description": "Filter by service"}, "requestId": map[string]interface{}{"type": "string", "description": "Filter by request ID"}}, "Requests/id.desc"), requestFilterFields), Required: []string{"clusterName"}}}
+}
+func (o *GetRollingRestartStatus) Output() ops.Output {
+	return ops.TypedOutput(decodeRequests, renderRequests)
 }
 func (o *GetRollingRestartStatus) Validate(args map[string]interface{}) error {
 	if _, ok := args["clusterName"].(string); !ok {
@@ -229,24 +272,52 @@ func (o *GetRollingRestartStatus) Validate(args map[string]interface{}) error {
 func (o *GetRollingRestartStatus) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	cluster := args["clusterName"].(string)
 	p := map[string]string{"fields": "Requests/id,Requests/request_context,Requests/request_status,Requests/progress_percent,Requests/start_time,Requests/end_time,tasks/Tasks/command_name,tasks/Tasks/status,tasks/Tasks/host_name,tasks/Tasks/role"}
-	if s, ok := args["serviceName"].(string); ok {
-		p["tasks/Tasks/role.in"] = s
+	if s, ok := args["serviceName"].(string); ok && s != "" {
+		var err error
+		if p, err = client.WithPredicate(p, client.In("tasks/Tasks/role", s)); err != nil {
+			return nil, err
+		}
 	}
 	if rid, ok := args["requestId"].(string); ok && rid != "" {
 		return o.Client.Get(ctx, fmt.Sprintf("/clusters/%s/requests/%s", cluster, rid), p)
 	}
-	return o.Client.Get(ctx, fmt.Sprintf("/clusters/%s/requests", cluster), p)
+	p, err := ops.FilterParams(p, args, requestFilterFields)
+	if err != nil {
+		return nil, err
+	}
+	return ops.GetPage(ctx, o.Client, o.OpName, fmt.Sprintf("/clusters/%s/requests", cluster), p, args, "Requests/id.desc")
 }
 
 // ---- IsServiceCheckSupported ----
 type IsServiceCheckSupported struct{ ops.ReadOnlyBase }
 
+// ServiceCheckSupport is the output of IsServiceCheckSupported
+type ServiceCheckSupport struct {
+	Stack        string `json:"stack"`
+	StackVersion string `json:"stack_version"`
+	Service      string `json:"service"`
+	Supported    bool   `json:"supported"`
+}
+
+func decodeServiceCheckSupport(item map[string]interface{}) ServiceCheckSupport {
+	info, _ := item["StackServices"].(map[string]interface{})
+	s := ServiceCheckSupport{}
+	s.Stack, _ = info["stack_name"].(string)
+	s.StackVersion, _ = info["stack_version"].(string)
+	s.Service, _ = info["service_name"].(string)
+	s.Supported, _ = info["service_check_supported"].(bool)
+	return s
+}
+
 func NewIsServiceCheckSupported(c client.AmbariClient, l *logrus.Logger) *IsServiceCheckSupported {
 	return &IsServiceCheckSupported{ops.ReadOnlyBase{OpName: "ambari_services_isservicechecksupported", OpDescription: "Check if service check is supported for a service in the stack", OpCategory: "services", Permissions: []auth.Permission{auth.ServiceView}, Client: c, Logger: l}}
 }
 func (o *IsServiceCheckSupported) Definition() ops.ToolDefinition {
 	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"}, "serviceName": map[string]interface{}{"type": "string", "description": "Service name"}, "stackName": map[string]interface{}{"type": "string", "description": "Stack name (e.g., HDP, VDP)"}, "stackVersion": map[string]interface{}{"type": "string", "description": "Stack version (e.g., 3.1)"}}, Required: []string{"clusterName", "serviceName", "stackName", "stackVersion"}}}
 }
+func (o *IsServiceCheckSupported) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeItem(decodeServiceCheckSupport), renderServiceCheckSupport)
+}
 func (o *IsServiceCheckSupported) Validate(args map[string]interface{}) error {
 	for _, k := range []string{"clusterName", "serviceName", "stackName", "stackVersion"} {
 		if _, ok := args[k].(string); !ok {
@@ -266,7 +337,10 @@ func NewGetServiceCheckStatus(c client.AmbariClient, l *logrus.Logger) *GetServi
 	return &GetServiceCheckStatus{ops.ReadOnlyBase{OpName: "ambari_services_getservicecheckstatus", OpDescription: "Get status of recent service check operations", OpCategory: "services", Permissions: []auth.Permission{auth.ServiceView}, Client: c, Logger: l}}
 }
 func (o *GetServiceCheckStatus) Definition() ops.ToolDefinition {
-	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"}, "serviceName": map[string]interface{}{"type": "string", "description": "Filter by service"}, "requestId": map[string]interface{}{"type": "string", "description": "Filter by request ID"}}, Required: []string{"clusterName"}}}
+	return ops.ToolDefinition{Name: o.OpName, Description: o.OpDescription, InputSchema: ops.ToolSchema{Type: "object", Properties: ops.WithFilter(ops.WithPaging(map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"}, "serviceName": map[string]interface{}{"type": "string", "description": "Filter by service"}, "requestId": map[string]interface{}{"type": "string", "description": "Filter by request ID"}}, "Requests/id.desc"), requestFilterFields), Required: []string{"clusterName"}}}
+}
+func (o *GetServiceCheckStatus) Output() ops.Output {
+	return ops.TypedOutput(decodeRequests, renderRequests)
 }
 func (o *GetServiceCheckStatus) Validate(args map[string]interface{}) error {
 	if _, ok := args["clusterName"].(string); !ok {
@@ -276,12 +350,20 @@ func (o *GetServiceCheckStatus) Validate(args map[string]interface{}) error {
 }
 func (o *GetServiceCheckStatus) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	cluster := args["clusterName"].(string)
-	p := map[string]string{"fields": "Requests/id,Requests/request_context,Requests/request_status,Requests/progress_percent,tasks/Tasks/command_name,tasks/Tasks/status,tasks/Tasks/host_name,tasks/Tasks/role", "Requests/request_context.matches": ".*Service Check.*"}
-	if s, ok := args["serviceName"].(string); ok {
-		p["tasks/Tasks/role.in"] = s
+	p := map[string]string{"fields": "Requests/id,Requests/request_context,Requests/request_status,Requests/progress_percent,tasks/Tasks/command_name,tasks/Tasks/status,tasks/Tasks/host_name,tasks/Tasks/role"}
+	p, _ = client.WithPredicate(p, client.Matches("Requests/request_context", ".*Service Check.*"))
+	if s, ok := args["serviceName"].(string); ok && s != "" {
+		var err error
+		if p, err = client.WithPredicate(p, client.In("tasks/Tasks/role", s)); err != nil {
+			return nil, err
+		}
 	}
 	if rid, ok := args["requestId"].(string); ok && rid != "" {
 		return o.Client.Get(ctx, fmt.Sprintf("/clusters/%s/requests/%s", cluster, rid), p)
 	}
-	return o.Client.Get(ctx, fmt.Sprintf("/clusters/%s/requests", cluster), p)
+	p, err := ops.FilterParams(p, args, requestFilterFields)
+	if err != nil {
+		return nil, err
+	}
+	return ops.GetPage(ctx, o.Client, o.OpName, fmt.Sprintf("/clusters/%s/requests", cluster), p, args, "Requests/id.desc")
 }
@@ -7,6 +7,7 @@ import (
 
 	"mcp-ambari/internal/auth"
 	"mcp-ambari/internal/client"
+	"mcp-ambari/internal/model"
 	ops "mcp-ambari/internal/operations"
 	"github.com/sirupsen/logrus"
 )
@@ -27,13 +28,16 @@ func NewGetClusters(c client.AmbariClient, l *logrus.Logger) *GetClusters {
 func (o *GetClusters) Definition() ops.ToolDefinition {
 	return ops.ToolDefinition{
 		Name: o.OpName, Description: o.OpDescription,
-		InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{
-			"fields":    map[string]interface{}{"type": "string", "description": "Filter fields", "default": "Clusters/*"},
-			"page_size": map[string]interface{}{"type": "integer", "description": "Page size", "default": 10},
-		}, Required: []string{}},
+		InputSchema: ops.ToolSchema{Type: "object", Properties: ops.WithPaging(map[string]interface{}{
+			"fields": map[string]interface{}{"type": "string", "description": "Filter fields", "default": "Clusters/*"},
+		}, "Clusters/cluster_name.asc"), Required: []string{}},
 	}
 }
 
+func (o *GetClusters) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeList(model.DecodeCluster), renderClusters)
+}
+
 func (o *GetClusters) Validate(args map[string]interface{}) error { return nil }
 
 func (o *GetClusters) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
@@ -43,10 +47,7 @@ func (o *GetClusters) Execute(ctx context.Context, args map[string]interface{})
 	} else {
 		params["fields"] = "Clusters/*"
 	}
-	if ps, ok := args["page_size"].(float64); ok {
-		params["page_size"] = fmt.Sprintf("%d", int(ps))
-	}
-	return o.Client.Get(ctx, "/clusters", params)
+	return ops.GetPage(ctx, o.Client, o.OpName, "/clusters", params, args, "Clusters/cluster_name.asc")
 }
 
 // ---------- GetCluster ----------
@@ -72,6 +73,10 @@ func (o *GetCluster) Definition() ops.ToolDefinition {
 	}
 }
 
+func (o *GetCluster) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeItem(model.DecodeCluster), renderCluster)
+}
+
 func (o *GetCluster) Validate(args map[string]interface{}) error {
 	if _, ok := args["clusterName"].(string); !ok || args["clusterName"] == "" {
 		return fmt.Errorf("clusterName is required")
@@ -111,6 +116,10 @@ func (o *GetServices) Definition() ops.ToolDefinition {
 	}
 }
 
+func (o *GetServices) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeList(model.DecodeService), renderServices)
+}
+
 func (o *GetServices) Validate(args map[string]interface{}) error {
 	if _, ok := args["clusterName"].(string); !ok || args["clusterName"] == "" {
 		return fmt.Errorf("clusterName is required")
@@ -120,7 +129,7 @@ func (o *GetServices) Validate(args map[string]interface{}) error {
 
 func (o *GetServices) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	cluster := args["clusterName"].(string)
-	params := map[string]string{"fields": "ServiceInfo/service_name,ServiceInfo/cluster_name"}
+	params := map[string]string{"fields": "ServiceInfo/service_name,ServiceInfo/cluster_name,ServiceInfo/state,ServiceInfo/maintenance_state"}
 	if f, ok := args["fields"].(string); ok {
 		params["fields"] = f
 	}
@@ -151,6 +160,10 @@ func (o *GetService) Definition() ops.ToolDefinition {
 	}
 }
 
+func (o *GetService) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeItem(model.DecodeService), renderService)
+}
+
 func (o *GetService) Validate(args map[string]interface{}) error {
 	if _, ok := args["clusterName"].(string); !ok {
 		return fmt.Errorf("clusterName is required")
@@ -176,6 +189,9 @@ type GetHosts struct {
 	ops.ReadOnlyBase
 }
 
+var hostFilterFields = ops.PrefixedFields("Hosts",
+	"host_name", "host_status", "host_state", "maintenance_state", "os_type", "rack_info", "ip", "cpu_count", "total_mem", "last_heartbeat_time")
+
 func NewGetHosts(c client.AmbariClient, l *logrus.Logger) *GetHosts {
 	return &GetHosts{ops.ReadOnlyBase{
 		OpName: "ambari_hosts_gethosts", OpDescription: "Returns all hosts",
@@ -186,12 +202,16 @@ func NewGetHosts(c client.AmbariClient, l *logrus.Logger) *GetHosts {
 func (o *GetHosts) Definition() ops.ToolDefinition {
 	return ops.ToolDefinition{
 		Name: o.OpName, Description: o.OpDescription,
-		InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{
+		InputSchema: ops.ToolSchema{Type: "object", Properties: ops.WithFilter(ops.WithPaging(map[string]interface{}{
 			"fields": map[string]interface{}{"type": "string", "description": "Filter fields", "default": "Hosts/*"},
-		}, Required: []string{}},
+		}, "Hosts/host_name.asc"), hostFilterFields), Required: []string{}},
 	}
 }
 
+func (o *GetHosts) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeList(model.DecodeHost), renderHosts)
+}
+
 func (o *GetHosts) Validate(args map[string]interface{}) error { return nil }
 
 func (o *GetHosts) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
@@ -199,7 +219,11 @@ func (o *GetHosts) Execute(ctx context.Context, args map[string]interface{}) (in
 	if f, ok := args["fields"].(string); ok {
 		params["fields"] = f
 	}
-	return o.Client.Get(ctx, "/hosts", params)
+	params, err := ops.FilterParams(params, args, hostFilterFields)
+	if err != nil {
+		return nil, err
+	}
+	return ops.GetPage(ctx, o.Client, o.OpName, "/hosts", params, args, "Hosts/host_name.asc")
 }
 
 // ---------- GetAlerts ----------
@@ -208,6 +232,12 @@ type GetAlerts struct {
 	ops.ReadOnlyBase
 }
 
+// alertStates are the states (severities) of an Ambari alert
+var alertStates = []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+var alertFilterFields = ops.PrefixedFields("Alert",
+	"definition_name", "label", "service_name", "component_name", "host_name", "state", "maintenance_state", "scope", "latest_timestamp", "original_timestamp")
+
 func NewGetAlerts(c client.AmbariClient, l *logrus.Logger) *GetAlerts {
 	return &GetAlerts{ops.ReadOnlyBase{
 		OpName: "ambari_alerts_getalerts", OpDescription: "Get all alerts for a cluster",
@@ -218,13 +248,17 @@ func NewGetAlerts(c client.AmbariClient, l *logrus.Logger) *GetAlerts {
 func (o *GetAlerts) Definition() ops.ToolDefinition {
 	return ops.ToolDefinition{
 		Name: o.OpName, Description: o.OpDescription,
-		InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{
+		InputSchema: ops.ToolSchema{Type: "object", Properties: ops.WithFilter(ops.WithPaging(map[string]interface{}{
 			"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"},
-			"state":       map[string]interface{}{"type": "string", "description": "Filter by state (CRITICAL,WARNING,OK,UNKNOWN)"},
-		}, Required: []string{"clusterName"}},
+			"state":       map[string]interface{}{"type": "string", "description": "Filter by state", "enum": alertStates},
+		}, "Alert/id.asc"), alertFilterFields), Required: []string{"clusterName"}},
 	}
 }
 
+func (o *GetAlerts) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeList(model.DecodeAlert), renderAlerts)
+}
+
 func (o *GetAlerts) Validate(args map[string]interface{}) error {
 	if _, ok := args["clusterName"].(string); !ok {
 		return fmt.Errorf("clusterName is required")
@@ -238,7 +272,11 @@ func (o *GetAlerts) Execute(ctx context.Context, args map[string]interface{}) (i
 	if s, ok := args["state"].(string); ok {
 		params["Alert/state"] = s
 	}
-	return o.Client.Get(ctx, fmt.Sprintf("/clusters/%s/alerts", cluster), params)
+	params, err := ops.FilterParams(params, args, alertFilterFields)
+	if err != nil {
+		return nil, err
+	}
+	return ops.GetPage(ctx, o.Client, o.OpName, fmt.Sprintf("/clusters/%s/alerts", cluster), params, args, "Alert/id.asc")
 }
 
 // ---------- GetServiceState ----------
@@ -264,6 +302,10 @@ func (o *GetServiceState) Definition() ops.ToolDefinition {
 	}
 }
 
+func (o *GetServiceState) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeItem(model.DecodeService), renderService)
+}
+
 func (o *GetServiceState) Validate(args map[string]interface{}) error {
 	if _, ok := args["clusterName"].(string); !ok {
 		return fmt.Errorf("clusterName is required")
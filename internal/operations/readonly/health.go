@@ -0,0 +1,155 @@
+package readonly
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"mcp-ambari/internal/auth"
+	"mcp-ambari/internal/client"
+	"mcp-ambari/internal/model"
+	ops "mcp-ambari/internal/operations"
+	"github.com/sirupsen/logrus"
+)
+
+// Cluster health levels, from best to worst
+const (
+	HealthHealthy  = "HEALTHY"
+	HealthDegraded = "DEGRADED"
+	HealthCritical = "CRITICAL"
+)
+
+// ClusterHealth summarizes the state of a cluster's services, hosts and alerts
+type ClusterHealth struct {
+	Cluster         string         `json:"cluster"`
+	Status          string         `json:"status"`
+	Reasons         []string       `json:"reasons"`
+	ServicesByState map[string]int `json:"services_by_state"`
+	StoppedServices []string       `json:"stopped_services"`
+	HostsByStatus   map[string]int `json:"hosts_by_status"`
+	UnhealthyHosts  []string       `json:"unhealthy_hosts"`
+	AlertsByState   map[string]int `json:"alerts_by_state"`
+	CriticalAlerts  []model.Alert  `json:"critical_alerts"`
+}
+
+// ---------- GetClusterHealth ----------
+
+type GetClusterHealth struct {
+	ops.ReadOnlyBase
+	model *model.Client
+}
+
+func NewGetClusterHealth(c client.AmbariClient, l *logrus.Logger) *GetClusterHealth {
+	return &GetClusterHealth{ops.ReadOnlyBase{
+		OpName: "ambari_clusters_gethealth", OpDescription: "Summarizes the health of a cluster from its services, hosts and alerts",
+		OpCategory: "clusters", Permissions: []auth.Permission{auth.ClusterView, auth.ServiceView, auth.HostView, auth.AlertView}, Client: c, Logger: l,
+	}, model.NewClient(c)}
+}
+
+func (o *GetClusterHealth) Definition() ops.ToolDefinition {
+	return ops.ToolDefinition{
+		Name: o.OpName, Description: o.OpDescription,
+		InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{
+			"clusterName": map[string]interface{}{"type": "string", "description": "Cluster name"},
+		}, Required: []string{"clusterName"}},
+	}
+}
+
+func (o *GetClusterHealth) Output() ops.Output {
+	return ops.TypedOutput(func(result interface{}) (ClusterHealth, error) {
+		h, err := ops.Result[*ClusterHealth](result)
+		if err != nil {
+			return ClusterHealth{}, err
+		}
+		return *h, nil
+	}, renderHealth)
+}
+
+func (o *GetClusterHealth) Validate(args map[string]interface{}) error {
+	if v, ok := args["clusterName"].(string); !ok || v == "" {
+		return fmt.Errorf("clusterName is required")
+	}
+	return nil
+}
+
+func (o *GetClusterHealth) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	cluster := args["clusterName"].(string)
+	services, err := o.model.Services(ctx, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("services: %w", err)
+	}
+	hosts, err := o.model.Hosts(ctx, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("hosts: %w", err)
+	}
+	alerts, err := o.model.Alerts(ctx, cluster, nil)
+	if err != nil {
+		return nil, fmt.Errorf("alerts: %w", err)
+	}
+	return assessHealth(cluster, services, hosts, alerts), nil
+}
+
+// assessHealth scores a cluster. Services and alerts in maintenance mode are
+// ignored, as are client-only services, which never reach STARTED.
+func assessHealth(cluster string, services []model.Service, hosts []model.Host, alerts []model.Alert) *ClusterHealth {
+	h := &ClusterHealth{
+		Cluster: cluster, Status: HealthHealthy, Reasons: []string{},
+		ServicesByState: map[string]int{}, StoppedServices: []string{},
+		HostsByStatus: map[string]int{}, UnhealthyHosts: []string{},
+		AlertsByState: map[string]int{}, CriticalAlerts: []model.Alert{},
+	}
+	worsen := func(status, reason string) {
+		if status == HealthCritical || h.Status == HealthHealthy {
+			h.Status = status
+		}
+		h.Reasons = append(h.Reasons, reason)
+	}
+
+	for _, s := range services {
+		h.ServicesByState[s.State]++
+		if s.State != "STARTED" && s.MaintenanceState != "ON" && !clientOnly(s) {
+			h.StoppedServices = append(h.StoppedServices, s.Name)
+		}
+	}
+	for _, host := range hosts {
+		h.HostsByStatus[host.Status]++
+		if host.Status != "HEALTHY" && host.MaintenanceState != "ON" {
+			h.UnhealthyHosts = append(h.UnhealthyHosts, host.Name)
+		}
+	}
+	for _, a := range alerts {
+		h.AlertsByState[a.State]++
+		if a.State == "CRITICAL" && a.MaintenanceState != "ON" {
+			h.CriticalAlerts = append(h.CriticalAlerts, a)
+		}
+	}
+	sort.Strings(h.StoppedServices)
+	sort.Strings(h.UnhealthyHosts)
+
+	if n := len(h.CriticalAlerts); n > 0 {
+		worsen(HealthCritical, fmt.Sprintf("%d critical alert(s)", n))
+	}
+	if n := len(h.StoppedServices); n > 0 {
+		worsen(HealthCritical, fmt.Sprintf("%d service(s) not started", n))
+	}
+	if n := len(h.UnhealthyHosts); n > 0 {
+		worsen(HealthDegraded, fmt.Sprintf("%d host(s) not healthy", n))
+	}
+	if n := h.AlertsByState["WARNING"]; n > 0 {
+		worsen(HealthDegraded, fmt.Sprintf("%d warning alert(s)", n))
+	}
+	return h
+}
+
+// clientOnly reports whether every component of a service is a client
+func clientOnly(s model.Service) bool {
+	if len(s.Components) == 0 {
+		return false
+	}
+	for _, c := range s.Components {
+		if c.Category != "CLIENT" {
+			return false
+		}
+	}
+	return true
+}
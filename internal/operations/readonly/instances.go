@@ -0,0 +1,90 @@
+package readonly
+
+import (
+	"context"
+	"time"
+
+	"mcp-ambari/internal/auth"
+	"mcp-ambari/internal/client"
+	ops "mcp-ambari/internal/operations"
+	"github.com/sirupsen/logrus"
+)
+
+// InstanceList is the output of GetInstances
+type InstanceList struct {
+	Default   string                  `json:"default"`
+	Instances []client.InstanceStatus `json:"instances"`
+}
+
+// ConnectionStatus is the output of GetConnectionStatus
+type ConnectionStatus struct {
+	Instances []client.InstanceProtection `json:"instances"`
+}
+
+// ---------- GetInstances ----------
+
+type GetInstances struct {
+	ops.ReadOnlyBase
+	instances *client.Registry
+}
+
+func NewGetInstances(r *client.Registry, l *logrus.Logger) *GetInstances {
+	return &GetInstances{ReadOnlyBase: ops.ReadOnlyBase{
+		OpName: "ambari_instances_getinstances", OpDescription: "Lists the configured Ambari instances and whether each is reachable",
+		OpCategory: "instances", Permissions: []auth.Permission{auth.ClusterView}, Client: r, Logger: l,
+	}, instances: r}
+}
+
+func (o *GetInstances) Definition() ops.ToolDefinition {
+	return ops.ToolDefinition{
+		Name: o.OpName, Description: o.OpDescription,
+		InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{
+			"timeoutSeconds": map[string]interface{}{"type": "integer", "description": "Per-instance probe timeout", "default": 5, "minimum": 1},
+		}, Required: []string{}},
+	}
+}
+
+func (o *GetInstances) Output() ops.Output {
+	return ops.TypedOutput(ops.Result[InstanceList], renderInstances)
+}
+
+func (o *GetInstances) Validate(args map[string]interface{}) error { return nil }
+
+func (o *GetInstances) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	timeout := 5 * time.Second
+	if t, ok := args["timeoutSeconds"].(int); ok && t > 0 {
+		timeout = time.Duration(t) * time.Second
+	}
+	return InstanceList{Default: o.instances.Default(), Instances: o.instances.Probe(ctx, timeout)}, nil
+}
+
+// ---------- GetConnectionStatus ----------
+
+type GetConnectionStatus struct {
+	ops.ReadOnlyBase
+	instances *client.Registry
+}
+
+func NewGetConnectionStatus(r *client.Registry, l *logrus.Logger) *GetConnectionStatus {
+	return &GetConnectionStatus{ReadOnlyBase: ops.ReadOnlyBase{
+		OpName: "ambari_instances_getconnectionstatus", OpDescription: "Shows the circuit breaker and request limiter of each Ambari instance; an open breaker means the server is failing and requests to it are refused until it recovers",
+		OpCategory: "instances", Permissions: []auth.Permission{auth.ClusterView}, Client: r, Logger: l,
+	}, instances: r}
+}
+
+func (o *GetConnectionStatus) Definition() ops.ToolDefinition {
+	return ops.ToolDefinition{
+		Name: o.OpName, Description: o.OpDescription,
+		InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{}, Required: []string{}},
+	}
+}
+
+func (o *GetConnectionStatus) Output() ops.Output {
+	return ops.TypedOutput(ops.Result[ConnectionStatus], renderConnectionStatus)
+}
+
+func (o *GetConnectionStatus) Validate(args map[string]interface{}) error { return nil }
+
+func (o *GetConnectionStatus) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return ConnectionStatus{Instances: o.instances.Protection()}, nil
+}
@@ -0,0 +1,410 @@
+package readonly
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-ambari/internal/model"
+	ops "mcp-ambari/internal/operations"
+	"mcp-ambari/internal/policy"
+)
+
+// Decoders and text renderings of the typed tool outputs. Lists render as a
+// summary line and a table, single resources as "name: value" lines.
+
+// decodeRequests decodes a request list, or the one request fetched by requestId
+func decodeRequests(result interface{}) (ops.List[model.Request], error) {
+	if resp, ok := result.(map[string]interface{}); ok && resp["Requests"] != nil {
+		return ops.List[model.Request]{Items: []model.Request{model.DecodeRequest(resp)}}, nil
+	}
+	return ops.DecodeList(model.DecodeRequest)(result)
+}
+
+// decodeStaleServices keeps the services, components and host components
+// whose configuration is stale
+func decodeStaleServices(result interface{}) (ops.List[model.Service], error) {
+	resp, ok := result.(map[string]interface{})
+	if !ok {
+		return ops.List[model.Service]{}, fmt.Errorf("unexpected result type %T", result)
+	}
+	items := model.Items(resp)
+	if resp["ServiceInfo"] != nil {
+		items = []map[string]interface{}{resp}
+	}
+	list := ops.List[model.Service]{Items: []model.Service{}}
+	for _, item := range items {
+		s := model.DecodeService(item)
+		var stale []model.ServiceComponent
+		for _, c := range s.Components {
+			var hcs []model.HostComponent
+			for _, hc := range c.HostComponents {
+				if hc.StaleConfigs {
+					hcs = append(hcs, hc)
+				}
+			}
+			if len(hcs) > 0 {
+				c.HostComponents = hcs
+				stale = append(stale, c)
+			}
+		}
+		if len(stale) > 0 {
+			s.Components = stale
+			list.Items = append(list.Items, s)
+		}
+	}
+	return list, nil
+}
+
+func decodeAlertSummaries(result interface{}) (ops.List[model.AlertSummary], error) {
+	resp, ok := result.(map[string]interface{})
+	if !ok {
+		return ops.List[model.AlertSummary]{}, fmt.Errorf("unexpected result type %T", result)
+	}
+	summaries := model.DecodeAlertSummaries(resp)
+	if summaries == nil {
+		summaries = []model.AlertSummary{}
+	}
+	return ops.List[model.AlertSummary]{Items: summaries}, nil
+}
+
+// ---------- text helpers ----------
+
+// lines renders name/value pairs one per line, skipping empty values
+func lines(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(pairs[i] + ": " + pairs[i+1])
+	}
+	return b.String()
+}
+
+func itoa(n int64) string { return strconv.FormatInt(n, 10) }
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func timestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// oneLine flattens text to a single line of at most max runes
+func oneLine(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if r := []rune(text); len(r) > max {
+		return string(r[:max-1]) + "…"
+	}
+	return text
+}
+
+// counts renders a state→count map as "STARTED 3, INSTALLED 1", largest first
+func counts[N int | int64](m map[string]N) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s %d", k, m[k])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ---------- clusters and services ----------
+
+func renderClusters(l ops.List[model.Cluster]) string {
+	return l.Text("cluster", []string{"CLUSTER", "VERSION", "SECURITY", "HOSTS"}, func(c model.Cluster) []string {
+		return []string{c.Name, c.Version, c.SecurityType, itoa(c.TotalHosts)}
+	})
+}
+
+func renderCluster(c model.Cluster) string {
+	return lines(
+		"Cluster", c.Name, "Version", c.Version, "Security", c.SecurityType,
+		"State", c.State, "Hosts", itoa(c.TotalHosts), "Host health", counts(c.HealthReport),
+	)
+}
+
+func renderServices(l ops.List[model.Service]) string {
+	return l.Text("service", []string{"SERVICE", "STATE", "MAINTENANCE"}, func(s model.Service) []string {
+		return []string{s.Name, s.State, s.MaintenanceState}
+	})
+}
+
+func renderService(s model.Service) string {
+	text := lines("Service", s.Name, "Cluster", s.Cluster, "State", s.State, "Maintenance", s.MaintenanceState)
+	if len(s.Components) == 0 {
+		return text
+	}
+	var stale []string
+	rows := make([][]string, len(s.Components))
+	for i, c := range s.Components {
+		rows[i] = []string{c.Name, c.Category, c.State, itoa(c.StartedCount), itoa(c.InstalledCount), itoa(c.TotalCount)}
+		for _, hc := range c.HostComponents {
+			if hc.StaleConfigs {
+				stale = append(stale, c.Name+" on "+hc.Host)
+			}
+		}
+	}
+	text += "\n\n" + ops.Table([]string{"COMPONENT", "CATEGORY", "STATE", "STARTED", "INSTALLED", "TOTAL"}, rows)
+	if len(stale) > 0 {
+		text += "\n\nStale configs: " + strings.Join(stale, ", ")
+	}
+	return text
+}
+
+func renderStaleServices(l ops.List[model.Service]) string {
+	var rows [][]string
+	for _, s := range l.Items {
+		for _, c := range s.Components {
+			for _, hc := range c.HostComponents {
+				rows = append(rows, []string{s.Name, c.Name, hc.Host, hc.State})
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return "No services with stale configs"
+	}
+	return l.Summary("service") + " with stale configs\n\n" + ops.Table([]string{"SERVICE", "COMPONENT", "HOST", "STATE"}, rows)
+}
+
+func renderHostComponents(l ops.List[model.HostComponent]) string {
+	return l.Text("host component", []string{"HOST", "SERVICE", "COMPONENT", "STATE", "MAINTENANCE"}, func(hc model.HostComponent) []string {
+		return []string{hc.Host, hc.Service, hc.Component, hc.State, hc.MaintenanceState}
+	})
+}
+
+func renderRequests(l ops.List[model.Request]) string {
+	text := l.Text("request", []string{"ID", "STATUS", "PROGRESS", "CONTEXT"}, func(r model.Request) []string {
+		return []string{itoa(r.ID), r.Status, fmt.Sprintf("%.0f%%", r.ProgressPercent), r.Context}
+	})
+	if len(l.Items) == 1 && len(l.Items[0].Tasks) > 0 {
+		rows := make([][]string, len(l.Items[0].Tasks))
+		for i, t := range l.Items[0].Tasks {
+			rows[i] = []string{t.Host, t.Role, t.Command, t.Status}
+		}
+		text += "\n\n" + ops.Table([]string{"HOST", "ROLE", "COMMAND", "STATUS"}, rows)
+	}
+	return text
+}
+
+func renderServiceCheckSupport(s ServiceCheckSupport) string {
+	support := "supports"
+	if !s.Supported {
+		support = "does not support"
+	}
+	return fmt.Sprintf("%s in %s %s %s service checks", s.Service, s.Stack, s.StackVersion, support)
+}
+
+// ---------- hosts ----------
+
+func renderHosts(l ops.List[model.Host]) string {
+	return l.Text("host", []string{"HOST", "IP", "STATUS", "STATE", "MAINTENANCE", "RACK"}, func(h model.Host) []string {
+		return []string{h.Name, h.IP, h.Status, h.State, h.MaintenanceState, h.Rack}
+	})
+}
+
+func renderHost(h model.Host) string {
+	mem := ""
+	if h.TotalMemKB > 0 {
+		mem = fmt.Sprintf("%.1f GiB", float64(h.TotalMemKB)/(1<<20))
+	}
+	cpus := ""
+	if h.CPUCount > 0 {
+		cpus = itoa(h.CPUCount)
+	}
+	return lines(
+		"Host", h.Name, "Cluster", h.Cluster, "IP", h.IP, "Status", h.Status, "State", h.State,
+		"Maintenance", h.MaintenanceState, "OS", h.OSType, "Rack", h.Rack, "CPUs", cpus, "Memory", mem,
+		"Last heartbeat", timestamp(h.LastHeartbeat),
+	)
+}
+
+// ---------- alerts ----------
+
+// alertName is the label of an alert's definition, or its name when the
+// label was not fetched
+func alertName(a model.Alert) string {
+	if a.Label != "" {
+		return a.Label
+	}
+	return a.DefinitionName
+}
+
+var alertHeader = []string{"STATE", "ALERT", "SERVICE", "HOST", "TEXT"}
+
+func alertRow(a model.Alert) []string {
+	return []string{a.State, alertName(a), a.Service, a.Host, oneLine(a.Text, 80)}
+}
+
+func renderAlerts(l ops.List[model.Alert]) string {
+	return l.Text("alert", alertHeader, alertRow)
+}
+
+func renderAlertDefinitions(l ops.List[model.AlertDefinition]) string {
+	return l.Text("alert definition", []string{"ID", "NAME", "SERVICE", "COMPONENT", "ENABLED", "INTERVAL", "SCOPE"}, func(d model.AlertDefinition) []string {
+		return []string{itoa(d.ID), d.Name, d.Service, d.Component, yesNo(d.Enabled), itoa(d.Interval) + "m", d.Scope}
+	})
+}
+
+func renderAlertSummaries(l ops.List[model.AlertSummary]) string {
+	header := append([]string{"DEFINITION"}, alertStates...)
+	return l.Text("alert definition", header, func(s model.AlertSummary) []string {
+		row := []string{s.DefinitionName}
+		for _, state := range alertStates {
+			row = append(row, itoa(s.Counts[state]))
+		}
+		return row
+	})
+}
+
+func renderAlertGroups(l ops.List[model.AlertGroup]) string {
+	return l.Text("alert group", []string{"ID", "NAME", "DEFAULT", "DEFINITIONS", "TARGETS"}, func(g model.AlertGroup) []string {
+		return []string{itoa(g.ID), g.Name, yesNo(g.Default), strconv.Itoa(len(g.Definitions)), strings.Join(g.Targets, ", ")}
+	})
+}
+
+func renderAlertTargets(l ops.List[model.AlertTarget]) string {
+	return l.Text("alert target", []string{"ID", "NAME", "TYPE", "GLOBAL", "STATES"}, func(t model.AlertTarget) []string {
+		return []string{itoa(t.ID), t.Name, t.NotificationType, yesNo(t.Global), strings.Join(t.AlertStates, ",")}
+	})
+}
+
+// ---------- users and groups ----------
+
+func renderUsers(l ops.List[model.User]) string {
+	return l.Text("user", []string{"USER", "TYPE", "ACTIVE", "ADMIN"}, func(u model.User) []string {
+		return []string{u.Name, u.Type, yesNo(u.Active), yesNo(u.Admin)}
+	})
+}
+
+func renderUser(u model.User) string {
+	return lines(
+		"User", u.Name, "Display name", u.DisplayName, "Type", u.Type,
+		"Active", yesNo(u.Active), "Admin", yesNo(u.Admin), "Groups", strings.Join(u.Groups, ", "),
+	)
+}
+
+func renderGroups(l ops.List[model.Group]) string {
+	return l.Text("group", []string{"GROUP", "TYPE"}, func(g model.Group) []string {
+		return []string{g.Name, g.Type}
+	})
+}
+
+func renderGroup(g model.Group) string {
+	return lines("Group", g.Name, "Type", g.Type, "Members", strings.Join(g.Members, ", "))
+}
+
+func renderPrivileges(l ops.List[model.Privilege]) string {
+	return l.Text("privilege", []string{"PERMISSION", "RESOURCE", "CLUSTER", "PRINCIPAL"}, func(p model.Privilege) []string {
+		return []string{p.Permission, p.ResourceType, p.Cluster, strings.TrimSpace(p.PrincipalType + " " + p.PrincipalName)}
+	})
+}
+
+// ---------- health, instances and aggregates ----------
+
+func renderHealth(h ClusterHealth) string {
+	text := fmt.Sprintf("Cluster %s is %s", h.Cluster, h.Status)
+	if len(h.Reasons) > 0 {
+		text += ": " + strings.Join(h.Reasons, ", ")
+	}
+	text += "\n\n" + lines(
+		"Services", counts(h.ServicesByState), "Stopped services", strings.Join(h.StoppedServices, ", "),
+		"Hosts", counts(h.HostsByStatus), "Unhealthy hosts", strings.Join(h.UnhealthyHosts, ", "),
+		"Alerts", counts(h.AlertsByState),
+	)
+	if len(h.CriticalAlerts) > 0 {
+		rows := make([][]string, len(h.CriticalAlerts))
+		for i, a := range h.CriticalAlerts {
+			rows[i] = alertRow(a)
+		}
+		text += "\n\nCritical alerts:\n" + ops.Table(alertHeader, rows)
+	}
+	return text
+}
+
+func renderInstances(l InstanceList) string {
+	rows := make([][]string, len(l.Instances))
+	for i, s := range l.Instances {
+		reachable := yesNo(s.Reachable)
+		if s.Reachable {
+			reachable += fmt.Sprintf(" (%d ms)", s.LatencyMs)
+		}
+		rows[i] = []string{s.Name, s.BaseURL, yesNo(s.Default), reachable, oneLine(s.Error, 80)}
+	}
+	return ops.Table([]string{"INSTANCE", "URL", "DEFAULT", "REACHABLE", "ERROR"}, rows)
+}
+
+func renderConnectionStatus(s ConnectionStatus) string {
+	rows := make([][]string, len(s.Instances))
+	for i, p := range s.Instances {
+		rows[i] = []string{
+			p.Name, p.Breaker, strconv.Itoa(p.ConsecutiveFailures), timestamp(p.RetryAt),
+			fmt.Sprintf("%d/%d", p.InFlight, p.MaxConcurrent), strconv.Itoa(p.Waiting),
+		}
+	}
+	return ops.Table([]string{"INSTANCE", "BREAKER", "FAILURES", "RETRY AT", "IN FLIGHT", "WAITING"}, rows)
+}
+
+func renderApprovals(l ops.List[policy.Approval]) string {
+	return l.Text("approval", []string{"ID", "TOOL", "REQUESTER", "RULE", "STATUS", "EXPIRES"}, func(a policy.Approval) []string {
+		status := "pending"
+		if a.Granted() {
+			status = "approved by " + a.ApprovedBy
+		}
+		return []string{a.ID, a.Tool, a.Requester, a.Rule, status, a.Expires.UTC().Format(time.RFC3339)}
+	})
+}
+
+// renderSources lists the instances and clusters an aggregate query failed on
+func renderSources(sources []SourceStatus) string {
+	var failed []string
+	for _, s := range sources {
+		if !s.OK {
+			where := s.Instance
+			if s.Cluster != "" {
+				where += "/" + s.Cluster
+			}
+			failed = append(failed, where+": "+oneLine(s.Error, 120))
+		}
+	}
+	if len(failed) == 0 {
+		return ""
+	}
+	return "\n\nFailed:\n" + strings.Join(failed, "\n")
+}
+
+func renderAggregateAlerts(l AggregateList[InstanceAlert]) string {
+	list := ops.List[InstanceAlert]{Items: l.Items}
+	return list.Text("alert", []string{"INSTANCE", "CLUSTER", "STATE", "ALERT", "HOST", "TEXT"}, func(a InstanceAlert) []string {
+		return []string{a.Instance, a.Cluster, a.State, alertName(a.Alert), a.Host, oneLine(a.Text, 80)}
+	}) + renderSources(l.Sources)
+}
+
+func renderAggregateServices(l AggregateList[InstanceService]) string {
+	list := ops.List[InstanceService]{Items: l.Items}
+	return list.Text("service", []string{"INSTANCE", "CLUSTER", "SERVICE", "STATE", "MAINTENANCE"}, func(s InstanceService) []string {
+		return []string{s.Instance, s.Cluster, s.Name, s.State, s.MaintenanceState}
+	}) + renderSources(l.Sources)
+}
@@ -0,0 +1,48 @@
+package readonly
+
+import (
+	"context"
+	"fmt"
+
+	"mcp-ambari/internal/auth"
+	ops "mcp-ambari/internal/operations"
+	"mcp-ambari/internal/policy"
+	"github.com/sirupsen/logrus"
+)
+
+// ---------- GetApprovals ----------
+
+type GetApprovals struct {
+	ops.ReadOnlyBase
+	policies *policy.Engine
+}
+
+// NewGetApprovals lists held calls. It needs no permission: callers only see
+// the calls they requested and those their groups may approve.
+func NewGetApprovals(p *policy.Engine, l *logrus.Logger) *GetApprovals {
+	return &GetApprovals{ReadOnlyBase: ops.ReadOnlyBase{
+		OpName: "ambari_policy_getapprovals", OpDescription: "Lists calls held by require-approval policy rules that the caller requested or may approve with " + ops.ApproveTool,
+		OpCategory: "policy", Permissions: []auth.Permission{}, Logger: l,
+	}, policies: p}
+}
+
+func (o *GetApprovals) Definition() ops.ToolDefinition {
+	return ops.ToolDefinition{
+		Name: o.OpName, Description: o.OpDescription,
+		InputSchema: ops.ToolSchema{Type: "object", Properties: map[string]interface{}{}, Required: []string{}},
+	}
+}
+
+func (o *GetApprovals) Output() ops.Output {
+	return ops.TypedOutput(ops.Result[ops.List[policy.Approval]], renderApprovals)
+}
+
+func (o *GetApprovals) Validate(args map[string]interface{}) error { return nil }
+
+func (o *GetApprovals) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	authCtx, ok := auth.GetAuthContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("no caller identity")
+	}
+	return ops.List[policy.Approval]{Items: o.policies.Approvals(authCtx.Username, authCtx.Groups)}, nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"mcp-ambari/internal/auth"
 	"mcp-ambari/internal/client"
+	"mcp-ambari/internal/model"
 	ops "mcp-ambari/internal/operations"
 	"github.com/sirupsen/logrus"
 )
@@ -32,18 +33,22 @@ func (o *GetUsers) Definition() ops.ToolDefinition {
 		Description: o.OpDescription,
 		InputSchema: ops.ToolSchema{
 			Type: "object",
-			Properties: map[string]interface{}{
+			Properties: ops.WithPaging(map[string]interface{}{
 				"fields": map[string]interface{}{
 					"type":        "string",
 					"description": "Comma-separated fields to return (optional)",
 					"default":     "Users/*",
 				},
-			},
+			}, "Users/user_name.asc"),
 			Required: []string{},
 		},
 	}
 }
 
+func (o *GetUsers) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeList(model.DecodeUser), renderUsers)
+}
+
 func (o *GetUsers) Validate(args map[string]interface{}) error {
 	return nil // No required parameters
 }
@@ -57,7 +62,7 @@ func (o *GetUsers) Execute(ctx context.Context, args map[string]interface{}) (in
 		params["fields"] = fields
 	}
 
-	return o.Client.Get(ctx, "/users", params)
+	return ops.GetPage(ctx, o.Client, o.OpName, "/users", params, args, "Users/user_name.asc")
 }
 
 // ---- GetUser ----
@@ -91,6 +96,10 @@ func (o *GetUser) Definition() ops.ToolDefinition {
 	}
 }
 
+func (o *GetUser) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeItem(model.DecodeUser), renderUser)
+}
+
 func (o *GetUser) Validate(args map[string]interface{}) error {
 	if _, ok := args["username"].(string); !ok {
 		return fmt.Errorf("username required")
@@ -125,18 +134,22 @@ func (o *GetGroups) Definition() ops.ToolDefinition {
 		Description: o.OpDescription,
 		InputSchema: ops.ToolSchema{
 			Type: "object",
-			Properties: map[string]interface{}{
+			Properties: ops.WithPaging(map[string]interface{}{
 				"fields": map[string]interface{}{
 					"type":        "string",
 					"description": "Comma-separated fields to return (optional)",
 					"default":     "Groups/*",
 				},
-			},
+			}, "Groups/group_name.asc"),
 			Required: []string{},
 		},
 	}
 }
 
+func (o *GetGroups) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeList(model.DecodeGroup), renderGroups)
+}
+
 func (o *GetGroups) Validate(args map[string]interface{}) error {
 	return nil // No required parameters
 }
@@ -150,7 +163,7 @@ func (o *GetGroups) Execute(ctx context.Context, args map[string]interface{}) (i
 		params["fields"] = fields
 	}
 
-	return o.Client.Get(ctx, "/groups", params)
+	return ops.GetPage(ctx, o.Client, o.OpName, "/groups", params, args, "Groups/group_name.asc")
 }
 
 // ---- GetGroup ----
@@ -184,6 +197,10 @@ func (o *GetGroup) Definition() ops.ToolDefinition {
 	}
 }
 
+func (o *GetGroup) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeItem(model.DecodeGroup), renderGroup)
+}
+
 func (o *GetGroup) Validate(args map[string]interface{}) error {
 	if _, ok := args["groupName"].(string); !ok {
 		return fmt.Errorf("groupName required")
@@ -229,6 +246,10 @@ func (o *GetUserPrivileges) Definition() ops.ToolDefinition {
 	}
 }
 
+func (o *GetUserPrivileges) Output() ops.Output {
+	return ops.TypedOutput(ops.DecodeList(model.DecodePrivilege), renderPrivileges)
+}
+
 func (o *GetUserPrivileges) Validate(args map[string]interface{}) error {
 	if _, ok := args["username"].(string); !ok {
 		return fmt.Errorf("username required")
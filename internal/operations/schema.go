@@ -0,0 +1,157 @@
+package operations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// namePatterns constrain the arguments that name or identify Ambari
+// resources. Names and IDs are interpolated into API paths, so none of them
+// may contain a path separator or be "." or "..".
+var namePatterns = map[string]string{
+	"clusterName":   `^[A-Za-z0-9_-]{1,100}$`,
+	"serviceName":   `^[A-Za-z0-9_]+$`,
+	"componentName": `^[A-Za-z0-9_]+$`,
+	"hostName":      `^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`,
+	"username":      `^[A-Za-z0-9_@+-][A-Za-z0-9_@.+-]*$`,
+	"groupName":     `^[A-Za-z0-9_@+-][A-Za-z0-9_@. +-]*$`,
+	"newGroupName":  `^[A-Za-z0-9_@+-][A-Za-z0-9_@. +-]*$`,
+	"alertId":       `^[0-9]+$`,
+	"definitionId":  `^[0-9]+$`,
+	"requestId":     `^[0-9]+$`,
+}
+
+// JSONSchema converts the input schema of a tool into the JSON Schema
+// published to MCP clients and used by the Executor to check arguments. It
+// adds the ambariInstance argument, limited to instances when given, and the
+// patterns of name arguments, and closes every object schema so undeclared
+// arguments are rejected. It fails if the schema is not valid JSON
+// Schema, uses keywords the SDK does not know (usually a typo) or requires
+// an argument it does not declare.
+func (d ToolDefinition) JSONSchema(instances ...string) (*jsonschema.Resolved, error) {
+	if d.InputSchema.Type != "object" {
+		return nil, fmt.Errorf("%s: input schema type is %q, not \"object\"", d.Name, d.InputSchema.Type)
+	}
+	props := make(map[string]interface{}, len(d.InputSchema.Properties)+1)
+	for name, prop := range d.InputSchema.Properties {
+		if pattern, ok := namePatterns[name]; ok {
+			if m, ok := prop.(map[string]interface{}); ok && m["type"] == "string" && m["pattern"] == nil {
+				named := make(map[string]interface{}, len(m)+1)
+				for k, v := range m {
+					named[k] = v
+				}
+				named["pattern"] = pattern
+				prop = named
+			}
+		}
+		props[name] = closeObjects(prop)
+	}
+	if _, ok := props[InstanceArg]; !ok {
+		prop := map[string]interface{}{
+			"type":        "string",
+			"description": "Ambari instance to run against (see ambari_instances_getinstances); the default instance if omitted",
+		}
+		if len(instances) > 0 {
+			prop["enum"] = instances
+		}
+		props[InstanceArg] = prop
+	}
+
+	raw, err := json.Marshal(map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"required":             d.InputSchema.Required,
+		"additionalProperties": false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", d.Name, err)
+	}
+	var s jsonschema.Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", d.Name, err)
+	}
+	if len(s.Required) == 0 {
+		s.Required = nil
+	}
+
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; !ok {
+			return nil, fmt.Errorf("%s: required argument %q is not a property", d.Name, name)
+		}
+	}
+	if unknown := unknownKeywords("", &s); len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%s: unknown schema keywords: %s", d.Name, strings.Join(unknown, ", "))
+	}
+	resolved, err := s.Resolve(&jsonschema.ResolveOptions{ValidateDefaults: true})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", d.Name, err)
+	}
+	return resolved, nil
+}
+
+// closeObjects returns prop with additionalProperties set to false on it and
+// on every object schema it contains that does not set additionalProperties
+func closeObjects(prop interface{}) interface{} {
+	m, ok := prop.(map[string]interface{})
+	if !ok {
+		return prop
+	}
+	closed := make(map[string]interface{}, len(m)+1)
+	for k, v := range m {
+		switch k {
+		case "properties":
+			if props, ok := v.(map[string]interface{}); ok {
+				nested := make(map[string]interface{}, len(props))
+				for name, p := range props {
+					nested[name] = closeObjects(p)
+				}
+				v = nested
+			}
+		case "items":
+			v = closeObjects(v)
+		}
+		closed[k] = v
+	}
+	if closed["type"] == "object" && closed["additionalProperties"] == nil {
+		closed["additionalProperties"] = false
+	}
+	return closed
+}
+
+// unknownKeywords lists the keywords of s and its subschemas that the SDK
+// keeps in Extra because they are not JSON Schema
+func unknownKeywords(path string, s *jsonschema.Schema) []string {
+	var out []string
+	for k := range s.Extra {
+		out = append(out, path+k)
+	}
+	for name, prop := range s.Properties {
+		out = append(out, unknownKeywords(path+name+".", prop)...)
+	}
+	if s.Items != nil {
+		out = append(out, unknownKeywords(path+"items.", s.Items)...)
+	}
+	return out
+}
+
+// CheckSchemas converts the input schema of every registered tool, see
+// ToolDefinition.JSONSchema, and reports all invalid ones at once
+func (r *Registry) CheckSchemas(instances ...string) (map[string]*jsonschema.Resolved, error) {
+	schemas := make(map[string]*jsonschema.Resolved)
+	var errs []error
+	for _, def := range r.Definitions() {
+		s, err := def.JSONSchema(instances...)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		schemas[def.Name] = s
+	}
+	return schemas, errors.Join(errs...)
+}
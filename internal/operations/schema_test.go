@@ -0,0 +1,172 @@
+package operations
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	str := map[string]interface{}{"type": "string"}
+	tests := []struct {
+		name      string
+		props     map[string]interface{}
+		required  []string
+		schemaTyp string
+		instances []string
+		wantErr   string
+		valid     []map[string]interface{}
+		invalid   []map[string]interface{}
+	}{
+		{
+			name:     "name patterns",
+			props:    map[string]interface{}{"clusterName": str, "hostName": str, "alertId": str, "note": str},
+			required: []string{"clusterName"},
+			valid: []map[string]interface{}{
+				{"clusterName": "c1", "hostName": "node1.example.com", "alertId": "42", "note": "../anything"},
+			},
+			invalid: []map[string]interface{}{
+				{"clusterName": "../users"},
+				{"clusterName": "c1", "hostName": "node1/../admin"},
+				{"clusterName": "c1", "hostName": ".."},
+				{"clusterName": "c1", "alertId": "4/2"},
+				{"clusterName": strings.Repeat("c", 101)},
+			},
+		},
+		{
+			name:  "explicit pattern kept",
+			props: map[string]interface{}{"serviceName": map[string]interface{}{"type": "string", "pattern": "^HDFS$"}},
+			valid: []map[string]interface{}{{"serviceName": "HDFS"}},
+			invalid: []map[string]interface{}{
+				{"serviceName": "YARN"},
+			},
+		},
+		{
+			name:  "additionalProperties false",
+			props: map[string]interface{}{"options": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"force": map[string]interface{}{"type": "boolean"}}}},
+			valid: []map[string]interface{}{{"options": map[string]interface{}{"force": true}}},
+			invalid: []map[string]interface{}{
+				{"undeclared": "x"},
+				{"options": map[string]interface{}{"force": true, "all": true}},
+			},
+		},
+		{
+			name: "open object kept open",
+			props: map[string]interface{}{"properties": map[string]interface{}{
+				"type": "object", "additionalProperties": map[string]interface{}{"type": "string"},
+			}},
+			valid: []map[string]interface{}{{"properties": map[string]interface{}{"dfs.replication": "3"}}},
+		},
+		{
+			name:      "instance enum",
+			props:     map[string]interface{}{"clusterName": str},
+			instances: []string{"east", "west"},
+			valid:     []map[string]interface{}{{InstanceArg: "west"}, {}},
+			invalid:   []map[string]interface{}{{InstanceArg: "north"}},
+		},
+		{
+			name:  "instance without enum",
+			props: map[string]interface{}{"clusterName": str},
+			valid: []map[string]interface{}{{InstanceArg: "north"}},
+		},
+		{
+			name:    "unknown keyword",
+			props:   map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "descripton": "typo"}},
+			wantErr: "unknown schema keywords: clusterName.descripton",
+		},
+		{
+			name: "unknown nested keyword",
+			props: map[string]interface{}{"hosts": map[string]interface{}{
+				"type": "array", "items": map[string]interface{}{"type": "string", "minLen": 1},
+			}},
+			wantErr: "unknown schema keywords: hosts.items.minLen",
+		},
+		{
+			name:     "required but not declared",
+			props:    map[string]interface{}{"clusterName": str},
+			required: []string{"clusterName", "serviceName"},
+			wantErr:  `required argument "serviceName" is not a property`,
+		},
+		{
+			name:      "not an object",
+			schemaTyp: "array",
+			wantErr:   `input schema type is "array"`,
+		},
+		{
+			name:    "invalid default",
+			props:   map[string]interface{}{"page_size": map[string]interface{}{"type": "integer", "default": "ten"}},
+			wantErr: "ambari_test_tool",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := tt.schemaTyp
+			if typ == "" {
+				typ = "object"
+			}
+			def := ToolDefinition{Name: "ambari_test_tool", InputSchema: ToolSchema{Type: typ, Properties: tt.props, Required: tt.required}}
+			resolved, err := def.JSONSchema(tt.instances...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("JSONSchema() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if !strings.HasPrefix(err.Error(), "ambari_test_tool: ") {
+					t.Errorf("JSONSchema() error = %q does not name the tool", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("JSONSchema() error = %v", err)
+			}
+			if prop, ok := resolved.Schema().Properties[InstanceArg]; !ok || prop.Type != "string" {
+				t.Errorf("schema has no string %s argument", InstanceArg)
+			}
+			for _, args := range tt.valid {
+				if err := resolved.Validate(args); err != nil {
+					t.Errorf("Validate(%v) error = %v", args, err)
+				}
+			}
+			for _, args := range tt.invalid {
+				if err := resolved.Validate(args); err == nil {
+					t.Errorf("Validate(%v) accepted invalid arguments", args)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONSchemaDoesNotModifyDefinition(t *testing.T) {
+	prop := map[string]interface{}{"type": "string"}
+	def := ToolDefinition{Name: "ambari_test_tool", InputSchema: ToolSchema{Type: "object", Properties: map[string]interface{}{"clusterName": prop}}}
+	if _, err := def.JSONSchema("east"); err != nil {
+		t.Fatal(err)
+	}
+	if len(prop) != 1 || len(def.InputSchema.Properties) != 1 {
+		t.Errorf("JSONSchema() modified the definition: %v", def.InputSchema.Properties)
+	}
+}
+
+func TestCheckSchemas(t *testing.T) {
+	registry := NewRegistry(testLogger())
+	ops := []*fakeOperation{
+		{ReadOnlyBase: ReadOnlyBase{OpName: "ambari_good_tool"}, props: map[string]interface{}{"clusterName": map[string]interface{}{"type": "string"}}},
+		{ReadOnlyBase: ReadOnlyBase{OpName: "ambari_default_tool"}, props: map[string]interface{}{"page_size": map[string]interface{}{"type": "integer", "default": "ten"}}},
+		{ReadOnlyBase: ReadOnlyBase{OpName: "ambari_typo_tool"}, props: map[string]interface{}{"clusterName": map[string]interface{}{"type": "string", "patern": "^c"}}},
+	}
+	for _, op := range ops {
+		if err := registry.Register(op); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schemas, err := registry.CheckSchemas("east")
+	if err == nil {
+		t.Fatal("CheckSchemas() accepted invalid schemas")
+	}
+	for _, name := range []string{"ambari_default_tool: ", "ambari_typo_tool: unknown schema keywords: clusterName.patern"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("CheckSchemas() error = %q, want it to report %q", err, name)
+		}
+	}
+	if _, ok := schemas["ambari_good_tool"]; !ok || len(schemas) != 1 {
+		t.Errorf("CheckSchemas() = %d schemas, want only ambari_good_tool", len(schemas))
+	}
+}
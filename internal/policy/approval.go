@@ -0,0 +1,132 @@
+package policy
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ApprovalTTL is how long a held call waits for approval, and how long a
+// granted approval can be used
+const ApprovalTTL = time.Hour
+
+// Approval is a call held by a require-approval rule. A member of one of the
+// rule's approver groups other than the requester grants it; the requester
+// then repeats the call with the same arguments, which runs once.
+type Approval struct {
+	ID         string                 `json:"id"`
+	Tool       string                 `json:"tool"`
+	Args       map[string]interface{} `json:"args"`
+	Requester  string                 `json:"requester"`
+	Rule       string                 `json:"rule"`
+	Reason     string                 `json:"reason,omitempty"`
+	Approvers  []string               `json:"approvers"`
+	ApprovedBy string                 `json:"approved_by,omitempty"`
+	Expires    time.Time              `json:"expires"`
+
+	key string
+}
+
+// Granted reports whether an approver has granted the call
+func (a Approval) Granted() bool { return a.ApprovedBy != "" }
+
+// approvalKey identifies a call: the same tool, caller, rule and arguments
+func approvalKey(req Request, d Decision) string {
+	b, _ := json.Marshal([]interface{}{req.Tool, req.Username, d.Rule, req.Args})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Hold returns the approval of a call that d requires approval for. A
+// granted approval is used up and returned with granted set; otherwise the
+// pending approval is returned, created on the first attempt.
+func (e *Engine) Hold(req Request, d Decision) (approval Approval, granted bool) {
+	key := approvalKey(req, d)
+	now := time.Now()
+
+	e.amu.Lock()
+	defer e.amu.Unlock()
+	e.expire(now)
+	for id, a := range e.approvals {
+		if a.key != key {
+			continue
+		}
+		if a.Granted() {
+			delete(e.approvals, id)
+			return *a, true
+		}
+		return *a, false
+	}
+	a := &Approval{
+		ID: newApprovalID(), Tool: req.Tool, Args: req.Args, Requester: req.Username,
+		Rule: d.Rule, Reason: d.Reason, Approvers: d.Approvers, Expires: now.Add(ApprovalTTL), key: key,
+	}
+	e.approvals[a.ID] = a
+	return *a, false
+}
+
+// Grant approves the held call id for approver, who must belong to one of
+// the rule's approver groups and must not have requested the call
+func (e *Engine) Grant(id, approver string, groups []string) (Approval, error) {
+	if e == nil {
+		return Approval{}, fmt.Errorf("no policy is configured")
+	}
+	e.amu.Lock()
+	defer e.amu.Unlock()
+	e.expire(time.Now())
+	a, ok := e.approvals[id]
+	switch {
+	case !ok:
+		return Approval{}, fmt.Errorf("approval %s does not exist or has expired", id)
+	case a.Granted():
+		return Approval{}, fmt.Errorf("approval %s was already granted by %s", id, a.ApprovedBy)
+	case strings.EqualFold(a.Requester, approver):
+		return Approval{}, fmt.Errorf("%s cannot approve their own call", approver)
+	case !anyGroup(a.Approvers, groups):
+		return Approval{}, fmt.Errorf("%s is not a member of the approvers of rule %s (%s)", approver, a.Rule, strings.Join(a.Approvers, ", "))
+	}
+	a.ApprovedBy = approver
+	a.Expires = time.Now().Add(ApprovalTTL)
+	return *a, nil
+}
+
+// Approvals lists the approvals username requested or may grant, oldest
+// expiry first
+func (e *Engine) Approvals(username string, groups []string) []Approval {
+	if e == nil {
+		return nil
+	}
+	e.amu.Lock()
+	defer e.amu.Unlock()
+	e.expire(time.Now())
+	out := []Approval{}
+	for _, a := range e.approvals {
+		if strings.EqualFold(a.Requester, username) || !a.Granted() && anyGroup(a.Approvers, groups) {
+			out = append(out, *a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Expires.Before(out[j].Expires) })
+	return out
+}
+
+// expire drops approvals past their expiry; e.amu must be held
+func (e *Engine) expire(now time.Time) {
+	for id, a := range e.approvals {
+		if now.After(a.Expires) {
+			delete(e.approvals, id)
+		}
+	}
+}
+
+func newApprovalID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("read random approval id: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
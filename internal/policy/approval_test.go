@@ -0,0 +1,97 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+const approvalRules = `
+rules:
+  - name: dangerous-on-prod
+    decision: require-approval
+    approvers: [change-*]
+    match: {dangerous: true}
+`
+
+func TestApprovalFlow(t *testing.T) {
+	e := testEngine(t, approvalRules)
+	req := Request{Tool: "ambari_services_stopservice", Dangerous: true, Username: "alice", Args: map[string]interface{}{"clusterName": "prod", "serviceName": "HDFS"}}
+	d := e.Evaluate(req)
+
+	held, granted := e.Hold(req, d)
+	if granted || held.ID == "" || held.Requester != "alice" || held.Rule != "dangerous-on-prod" {
+		t.Fatalf("Hold() = %+v, %v, want a pending approval", held, granted)
+	}
+	if again, _ := e.Hold(req, d); again.ID != held.ID {
+		t.Errorf("repeated Hold() = %s, want the pending %s", again.ID, held.ID)
+	}
+	if list := e.Approvals("carol", []string{"change-managers"}); len(list) != 1 || list[0].ID != held.ID {
+		t.Errorf("Approvals() for an approver = %v, want the held call", list)
+	}
+	if list := e.Approvals("dave", []string{"users"}); len(list) != 0 {
+		t.Errorf("Approvals() for another user = %v, want none", list)
+	}
+
+	// A different call is held separately
+	other := req
+	other.Args = map[string]interface{}{"clusterName": "prod", "serviceName": "YARN"}
+	if a, _ := e.Hold(other, d); a.ID == held.ID {
+		t.Error("call with other arguments shares the approval")
+	}
+
+	if _, err := e.Grant(held.ID, "carol", []string{"change-managers"}); err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+	if list := e.Approvals("alice", nil); len(list) != 2 || !list[0].Granted() && !list[1].Granted() {
+		t.Errorf("Approvals() for the requester = %v, want both calls, one granted", list)
+	}
+	a, granted := e.Hold(req, d)
+	if !granted || a.ApprovedBy != "carol" {
+		t.Fatalf("Hold() after Grant = %+v, %v, want granted by carol", a, granted)
+	}
+	if a, granted := e.Hold(req, d); granted || a.ID == held.ID {
+		t.Errorf("Hold() after use = %+v, %v, want a new pending approval", a, granted)
+	}
+}
+
+func TestGrant(t *testing.T) {
+	tests := []struct {
+		name     string
+		approver string
+		groups   []string
+		id       func(id string) string
+		wantErr  string
+	}{
+		{name: "approver", approver: "carol", groups: []string{"change-managers"}},
+		{name: "requester", approver: "Alice", groups: []string{"change-managers"}, wantErr: "own call"},
+		{name: "not an approver", approver: "dave", groups: []string{"users"}, wantErr: "not a member"},
+		{name: "unknown id", approver: "carol", groups: []string{"change-managers"}, id: func(string) string { return "0123456789abcdef0123456789abcdef" }, wantErr: "does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := testEngine(t, approvalRules)
+			req := Request{Tool: "ambari_users_deleteuser", Dangerous: true, Username: "alice"}
+			held, _ := e.Hold(req, e.Evaluate(req))
+			id := held.ID
+			if tt.id != nil {
+				id = tt.id(id)
+			}
+			_, err := e.Grant(id, tt.approver, tt.groups)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Grant() error = %v", err)
+				}
+				if _, err := e.Grant(id, "erin", []string{"change-board"}); err == nil || !strings.Contains(err.Error(), "already granted") {
+					t.Errorf("second Grant() error = %v, want already granted", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Grant() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if _, granted := e.Hold(req, e.Evaluate(req)); granted {
+				t.Error("call runs after a rejected grant")
+			}
+		})
+	}
+}
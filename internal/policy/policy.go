@@ -0,0 +1,380 @@
+// Package policy evaluates declarative rules against tool calls before they
+// are executed. Rules are read from a YAML file and evaluated in order; the
+// first rule whose conditions all match decides the call.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Effect is the outcome of a policy decision
+type Effect string
+
+const (
+	Allow           Effect = "allow"
+	Deny            Effect = "deny"
+	RequireApproval Effect = "require-approval"
+)
+
+// Request describes the tool call being evaluated
+type Request struct {
+	Tool      string
+	Category  string
+	Type      string
+	Dangerous bool
+	Args      map[string]interface{}
+	Username  string
+	Groups    []string
+	Time      time.Time
+	// TargetGroups reads the groups of the user the call acts on; nil when
+	// the call names no user. It is only called for rules with target_groups.
+	TargetGroups func() ([]string, error)
+}
+
+// Decision is the result of evaluating a request. Rule is empty when no rule
+// matched and the default effect applied.
+type Decision struct {
+	Effect    Effect   `json:"effect"`
+	Rule      string   `json:"rule,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+	Approvers []string `json:"approvers,omitempty"`
+}
+
+// Violation is returned for calls that are not allowed. Approval is the held
+// call of a require-approval decision.
+type Violation struct {
+	Tool     string
+	Decision Decision
+	Approval *Approval
+}
+
+func (v *Violation) Error() string {
+	verb := "denied"
+	if v.Decision.Effect == RequireApproval {
+		verb = "requires approval"
+	}
+	msg := fmt.Sprintf("%s %s by policy", v.Tool, verb)
+	if v.Decision.Rule != "" {
+		msg += " rule " + v.Decision.Rule
+	}
+	if v.Decision.Reason != "" {
+		msg += ": " + v.Decision.Reason
+	}
+	if v.Approval != nil {
+		msg += " (approval " + v.Approval.ID + ")"
+	}
+	return msg
+}
+
+// Rule is one entry of the policy file. Approvers are the groups (glob
+// patterns) whose members may grant calls held by a require-approval rule.
+type Rule struct {
+	Name      string   `yaml:"name"`
+	Decision  Effect   `yaml:"decision"`
+	Reason    string   `yaml:"reason"`
+	Approvers []string `yaml:"approvers"`
+	Match     Match    `yaml:"match"`
+}
+
+// Match holds the conditions of a rule; empty conditions match everything.
+// Tool, category and argument values accept glob patterns (path.Match).
+// Groups and Users match the caller, TargetGroups the user the call acts on.
+type Match struct {
+	Tools        []string            `yaml:"tools"`
+	Categories   []string            `yaml:"categories"`
+	Types        []string            `yaml:"types"`
+	Dangerous    *bool               `yaml:"dangerous"`
+	Args         map[string][]string `yaml:"args"`
+	MissingArgs  []string            `yaml:"missing_args"`
+	Groups       []string            `yaml:"groups"`
+	Users        []string            `yaml:"users"`
+	TargetGroups []string            `yaml:"target_groups"`
+	Time         *TimeWindow         `yaml:"time"`
+}
+
+// TimeWindow matches calls on the given weekdays between Start and End
+// ("15:04", local to Timezone). A window whose End is before its Start spans midnight.
+type TimeWindow struct {
+	Days     []string `yaml:"days"`
+	Start    string   `yaml:"start"`
+	End      string   `yaml:"end"`
+	Timezone string   `yaml:"timezone"`
+
+	location   *time.Location
+	days       []time.Weekday
+	start, end int // minutes since midnight
+}
+
+type file struct {
+	Default Effect `yaml:"default"`
+	Rules   []Rule `yaml:"rules"`
+}
+
+// Engine holds the loaded rules and the calls held for approval. A nil
+// *Engine allows every call.
+type Engine struct {
+	path   string
+	mu     sync.RWMutex
+	policy file
+	logger *logrus.Logger
+
+	amu       sync.Mutex
+	approvals map[string]*Approval
+}
+
+// NewEngine loads the policy file at path
+func NewEngine(path string, logger *logrus.Logger) (*Engine, error) {
+	e := &Engine{path: path, logger: logger, approvals: make(map[string]*Approval)}
+	if err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Reload re-reads the policy file. On error the previous rules stay active.
+func (e *Engine) Reload() error {
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return fmt.Errorf("read policy %s: %w", e.path, err)
+	}
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("parse policy %s: %w", e.path, err)
+	}
+	if f.Default == "" {
+		f.Default = Allow
+	}
+	if err := checkEffect(f.Default); err != nil {
+		return fmt.Errorf("policy %s default: %w", e.path, err)
+	}
+	if f.Default == RequireApproval {
+		return fmt.Errorf("policy %s default: must be allow or deny, since it names no approvers", e.path)
+	}
+	for i := range f.Rules {
+		if err := f.Rules[i].compile(); err != nil {
+			return fmt.Errorf("policy %s rule %d (%s): %w", e.path, i+1, f.Rules[i].Name, err)
+		}
+	}
+
+	e.mu.Lock()
+	e.policy = f
+	e.mu.Unlock()
+
+	e.logger.WithFields(logrus.Fields{"file": e.path, "rules": len(f.Rules), "default": f.Default}).Info("Policy loaded")
+	return nil
+}
+
+// Evaluate returns the decision of the first matching rule, or the default.
+// When the target's groups cannot be read, rules that deny or hold the call
+// apply and rules that allow it do not.
+func (e *Engine) Evaluate(req Request) Decision {
+	if e == nil {
+		return Decision{Effect: Allow}
+	}
+	if req.TargetGroups != nil {
+		req.TargetGroups = once(req.TargetGroups)
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, rule := range e.policy.Rules {
+		ok, err := rule.Match.matches(req)
+		if err != nil {
+			e.logger.WithError(err).WithFields(logrus.Fields{"tool": req.Tool, "rule": rule.Name}).Warn("Policy condition could not be evaluated")
+			ok = rule.Decision != Allow
+		}
+		if ok {
+			return Decision{Effect: rule.Decision, Rule: rule.Name, Reason: rule.Reason, Approvers: rule.Approvers}
+		}
+	}
+	return Decision{Effect: e.policy.Default}
+}
+
+// once calls f at most once and returns its first result every time
+func once(f func() ([]string, error)) func() ([]string, error) {
+	var (
+		done   bool
+		groups []string
+		err    error
+	)
+	return func() ([]string, error) {
+		if !done {
+			groups, err = f()
+			done = true
+		}
+		return groups, err
+	}
+}
+
+func checkEffect(effect Effect) error {
+	switch effect {
+	case Allow, Deny, RequireApproval:
+		return nil
+	default:
+		return fmt.Errorf("unknown decision %q (supported: allow, deny, require-approval)", effect)
+	}
+}
+
+func (r *Rule) compile() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	if err := checkEffect(r.Decision); err != nil {
+		return err
+	}
+	if r.Decision == RequireApproval && len(r.Approvers) == 0 {
+		return fmt.Errorf("require-approval needs approvers")
+	}
+	patterns := append(append(append(append([]string{}, r.Match.Tools...), r.Match.Categories...), r.Match.TargetGroups...), r.Approvers...)
+	for _, values := range r.Match.Args {
+		patterns = append(patterns, values...)
+	}
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	if r.Match.Time != nil {
+		return r.Match.Time.compile()
+	}
+	return nil
+}
+
+// matches reports whether all conditions hold. The target's groups are read
+// last, so only for calls that match everything else; an error reading them
+// is returned.
+func (m *Match) matches(req Request) (bool, error) {
+	if len(m.Tools) > 0 && !matchAny(m.Tools, req.Tool) {
+		return false, nil
+	}
+	if len(m.Categories) > 0 && !matchAny(m.Categories, req.Category) {
+		return false, nil
+	}
+	if len(m.Types) > 0 && !matchAny(m.Types, req.Type) {
+		return false, nil
+	}
+	if m.Dangerous != nil && *m.Dangerous != req.Dangerous {
+		return false, nil
+	}
+	for name, patterns := range m.Args {
+		value, ok := req.Args[name]
+		if !ok || !matchAny(patterns, fmt.Sprint(value)) {
+			return false, nil
+		}
+	}
+	for _, name := range m.MissingArgs {
+		if value, ok := req.Args[name]; ok && strings.TrimSpace(fmt.Sprint(value)) != "" {
+			return false, nil
+		}
+	}
+	if len(m.Users) > 0 && !matchAny(m.Users, req.Username) {
+		return false, nil
+	}
+	if len(m.Groups) > 0 && !anyGroup(m.Groups, req.Groups) {
+		return false, nil
+	}
+	if m.Time != nil && !m.Time.contains(req.Time) {
+		return false, nil
+	}
+	if len(m.TargetGroups) > 0 {
+		if req.TargetGroups == nil {
+			return false, nil
+		}
+		groups, err := req.TargetGroups()
+		if err != nil {
+			return false, err
+		}
+		return anyGroup(m.TargetGroups, groups), nil
+	}
+	return true, nil
+}
+
+// matchAny reports whether value matches one of the glob patterns, ignoring case
+func matchAny(patterns []string, value string) bool {
+	value = strings.ToLower(value)
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func anyGroup(want, have []string) bool {
+	for _, g := range have {
+		if matchAny(want, g) {
+			return true
+		}
+	}
+	return false
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func (w *TimeWindow) compile() error {
+	w.location = time.Local
+	if w.Timezone != "" {
+		loc, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", w.Timezone, err)
+		}
+		w.location = loc
+	}
+	for _, d := range w.Days {
+		day, ok := weekdays[strings.ToLower(d)]
+		if !ok {
+			return fmt.Errorf("invalid day %q (expected mon, tue, ...)", d)
+		}
+		w.days = append(w.days, day)
+	}
+	var err error
+	if w.start, err = parseClock(w.Start, 0); err != nil {
+		return err
+	}
+	if w.end, err = parseClock(w.End, 24*60); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parseClock(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (w *TimeWindow) contains(at time.Time) bool {
+	at = at.In(w.location)
+	if len(w.days) > 0 {
+		found := false
+		for _, d := range w.days {
+			if d == at.Weekday() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	minute := at.Hour()*60 + at.Minute()
+	if w.end < w.start {
+		return minute >= w.start || minute < w.end
+	}
+	return minute >= w.start && minute < w.end
+}
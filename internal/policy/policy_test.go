@@ -0,0 +1,169 @@
+package policy
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func testEngine(t *testing.T, rules string) *Engine {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	e, err := NewEngine(path, logger)
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	return e
+}
+
+func groups(g ...string) func() ([]string, error) {
+	return func() ([]string, error) { return g, nil }
+}
+
+func TestEvaluate(t *testing.T) {
+	e := testEngine(t, `
+default: allow
+rules:
+  - name: hdfs-stop-business-hours
+    decision: deny
+    match:
+      tools: [ambari_services_stopservice]
+      args: {serviceName: [HDFS]}
+      time: {days: [mon, tue, wed, thu, fri], start: "08:00", end: "18:00", timezone: UTC}
+  - name: night-window
+    decision: deny
+    match:
+      categories: [hosts]
+      time: {start: "22:00", end: "06:00", timezone: UTC}
+  - name: restart-needs-context
+    decision: deny
+    match:
+      tools: [ambari_services_restart*]
+      missing_args: [context]
+  - name: admins-cannot-be-deleted
+    decision: deny
+    match:
+      tools: [ambari_users_deleteuser]
+      target_groups: [ambari-admins]
+  - name: oncall-may-delete
+    decision: allow
+    match:
+      tools: [ambari_users_deleteuser]
+      groups: [oncall-*]
+  - name: contractors
+    decision: deny
+    match:
+      users: [ext-*]
+      types: [actionable]
+  - name: dangerous-on-prod
+    decision: require-approval
+    approvers: [change-managers]
+    match:
+      dangerous: true
+      args: {clusterName: [prod-*]}
+`)
+	monday10 := time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC)
+	saturday10 := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	monday23 := time.Date(2026, 10, 12, 23, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		req      Request
+		want     Effect
+		wantRule string
+	}{
+		{name: "no rule", req: Request{Tool: "ambari_clusters_getclusters", Type: "readonly", Time: monday10}, want: Allow},
+		{name: "hdfs stop in business hours", req: Request{Tool: "ambari_services_stopservice", Args: map[string]interface{}{"serviceName": "hdfs"}, Time: monday10}, want: Deny, wantRule: "hdfs-stop-business-hours"},
+		{name: "hdfs stop on weekend", req: Request{Tool: "ambari_services_stopservice", Args: map[string]interface{}{"serviceName": "HDFS"}, Time: saturday10}, want: Allow},
+		{name: "yarn stop in business hours", req: Request{Tool: "ambari_services_stopservice", Args: map[string]interface{}{"serviceName": "YARN"}, Time: monday10}, want: Allow},
+		{name: "window across midnight", req: Request{Tool: "ambari_hosts_gethosts", Category: "hosts", Time: monday23}, want: Deny, wantRule: "night-window"},
+		{name: "outside window across midnight", req: Request{Tool: "ambari_hosts_gethosts", Category: "hosts", Time: monday10}, want: Allow},
+		{name: "restart without context", req: Request{Tool: "ambari_services_restartservice", Args: map[string]interface{}{"context": "  "}}, want: Deny, wantRule: "restart-needs-context"},
+		{name: "restart with context", req: Request{Tool: "ambari_services_restartservice", Args: map[string]interface{}{"context": "config change"}}, want: Allow},
+		{name: "delete admin", req: Request{Tool: "ambari_users_deleteuser", Groups: []string{"oncall-hdfs"}, TargetGroups: groups("users", "ambari-admins")}, want: Deny, wantRule: "admins-cannot-be-deleted"},
+		{name: "delete user by oncall", req: Request{Tool: "ambari_users_deleteuser", Groups: []string{"oncall-hdfs"}, TargetGroups: groups("users")}, want: Allow, wantRule: "oncall-may-delete"},
+		{name: "admin caller deletes user", req: Request{Tool: "ambari_users_deleteuser", Groups: []string{"ambari-admins"}, TargetGroups: groups("users")}, want: Allow},
+		{name: "target groups unreadable", req: Request{Tool: "ambari_users_deleteuser", Groups: []string{"oncall-hdfs"}, TargetGroups: func() ([]string, error) { return nil, errors.New("ambari down") }}, want: Deny, wantRule: "admins-cannot-be-deleted"},
+		{name: "contractor actionable", req: Request{Tool: "ambari_alerts_createalertgroup", Type: "actionable", Username: "ext-bob"}, want: Deny, wantRule: "contractors"},
+		{name: "contractor readonly", req: Request{Tool: "ambari_alerts_getalerts", Type: "readonly", Username: "ext-bob"}, want: Allow},
+		{name: "dangerous on prod", req: Request{Tool: "ambari_services_stopservice", Dangerous: true, Args: map[string]interface{}{"clusterName": "prod-core", "serviceName": "YARN"}, Time: saturday10}, want: RequireApproval, wantRule: "dangerous-on-prod"},
+		{name: "dangerous on dev", req: Request{Tool: "ambari_services_stopservice", Dangerous: true, Args: map[string]interface{}{"clusterName": "dev", "serviceName": "YARN"}, Time: saturday10}, want: Allow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := e.Evaluate(tt.req)
+			if got.Effect != tt.want || got.Rule != tt.wantRule {
+				t.Errorf("Evaluate() = %s (rule %q), want %s (rule %q)", got.Effect, got.Rule, tt.want, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestEvaluateReadsTargetGroupsOnce(t *testing.T) {
+	e := testEngine(t, `
+rules:
+  - name: a
+    decision: deny
+    match: {target_groups: [admins]}
+  - name: b
+    decision: deny
+    match: {target_groups: [ops]}
+  - name: c
+    decision: deny
+    match: {tools: [other], target_groups: [ops]}
+`)
+	calls := 0
+	got := e.Evaluate(Request{Tool: "ambari_users_deleteuser", TargetGroups: func() ([]string, error) {
+		calls++
+		return []string{"users"}, nil
+	}})
+	if got.Effect != Allow || calls != 1 {
+		t.Errorf("Evaluate() = %s after %d lookups, want allow after 1", got.Effect, calls)
+	}
+}
+
+func TestNilEngineAllows(t *testing.T) {
+	var e *Engine
+	if got := e.Evaluate(Request{Tool: "ambari_users_deleteuser"}); got.Effect != Allow {
+		t.Errorf("Evaluate() = %s, want allow", got.Effect)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{"unknown decision", "rules: [{name: a, decision: maybe}]"},
+		{"unnamed rule", "rules: [{decision: deny}]"},
+		{"bad pattern", "rules: [{name: a, decision: deny, match: {tools: ['[']}}]"},
+		{"bad day", "rules: [{name: a, decision: deny, match: {time: {days: [someday]}}}]"},
+		{"bad time", "rules: [{name: a, decision: deny, match: {time: {start: '8am'}}}]"},
+		{"bad timezone", "rules: [{name: a, decision: deny, match: {time: {timezone: Mars/Base}}}]"},
+		{"approval without approvers", "rules: [{name: a, decision: require-approval}]"},
+		{"approval default", "default: require-approval"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			if err := os.WriteFile(path, []byte(tt.rules), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			if _, err := NewEngine(path, logger); err == nil {
+				t.Error("NewEngine() succeeded, want an error")
+			}
+		})
+	}
+}
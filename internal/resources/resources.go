@@ -7,9 +7,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
+	"mcp-ambari/internal/auth"
 	"mcp-ambari/internal/client"
 	"github.com/sirupsen/logrus"
 )
@@ -33,20 +35,36 @@ type ResourceResult struct {
 // Handler is a function that resolves a resource URI to data
 type Handler func(ctx context.Context, params map[string]string) (*ResourceResult, error)
 
+// Gate admits resource reads with the checks tool calls get: the caller must
+// hold perms on the target cluster and service and be allowed the cluster.
+// It returns the context to read with, which carries the caller's Ambari
+// credentials and the instance to read from.
+type Gate interface {
+	Admit(ctx context.Context, name string, perms []auth.Permission, target auth.Scope, instance string, authCtx *auth.AuthContext) (context.Context, error)
+}
+
+type resource struct {
+	perms   []auth.Permission
+	handler Handler
+}
+
 // Registry holds all resource definitions and their handlers
 type Registry struct {
 	definitions []ResourceDefinition
-	handlers    map[string]Handler
+	resources   map[string]resource
 	client      client.AmbariClient
+	gate        Gate
 	logger      *logrus.Logger
 }
 
-// NewRegistry creates a resource registry with all Ambari resources
-func NewRegistry(c client.AmbariClient, logger *logrus.Logger) *Registry {
+// NewRegistry creates a resource registry with all Ambari resources. Reads
+// are admitted by gate, usually the operations Executor.
+func NewRegistry(c client.AmbariClient, gate Gate, logger *logrus.Logger) *Registry {
 	r := &Registry{
-		handlers: make(map[string]Handler),
-		client:   c,
-		logger:   logger,
+		resources: make(map[string]resource),
+		client:    c,
+		gate:      gate,
+		logger:    logger,
 	}
 	r.registerAll()
 	return r
@@ -57,17 +75,38 @@ func (r *Registry) Definitions() []ResourceDefinition {
 	return r.definitions
 }
 
-// Read resolves a resource URI and returns the data
-func (r *Registry) Read(ctx context.Context, uri string) (*ResourceResult, error) {
+// Read resolves a resource URI and returns the data as authCtx sees it. URIs
+// may name an Ambari instance before the resource path, e.g.
+// ambari://prod-east/cluster/x. Resources of a cluster target that cluster,
+// and service resources its service; the others target no cluster and need
+// global grants.
+func (r *Registry) Read(ctx context.Context, uri string, authCtx *auth.AuthContext) (*ResourceResult, error) {
 	resType, params, err := r.parseURI(uri)
 	if err != nil {
 		return nil, err
 	}
-	handler, ok := r.handlers[resType]
+	if err := checkNames(uri, params); err != nil {
+		return nil, err
+	}
+	res, ok := r.resources[resType]
 	if !ok {
 		return nil, fmt.Errorf("unsupported resource type: %s", resType)
 	}
-	return handler(ctx, params)
+	target := auth.Scope{Cluster: params["clusterName"], Service: params["serviceName"]}
+	ctx, err = r.gate.Admit(ctx, uri, res.perms, target, params["ambariInstance"], authCtx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := res.handler(ctx, params)
+	if result != nil {
+		result.URI = uri
+	}
+	return result, err
+}
+
+// InstanceURI returns the form of a resource URI that names an Ambari instance
+func InstanceURI(uri string) string {
+	return "ambari://{ambariInstance}/" + strings.TrimPrefix(uri, "ambari://")
 }
 
 // Count returns number of registered resources
@@ -80,7 +119,7 @@ func (r *Registry) registerAll() {
 	r.add(ResourceDefinition{
 		URI: "ambari://clusters", Name: "Ambari Clusters",
 		Description: "List of all Ambari clusters with basic information", MimeType: "application/json",
-	}, "clusters", func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
+	}, "clusters", []auth.Permission{auth.ClusterView}, func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
 		data, err := r.client.Get(ctx, "/clusters", map[string]string{
 			"fields": "Clusters/cluster_name,Clusters/version,Clusters/state",
 		})
@@ -91,8 +130,8 @@ func (r *Registry) registerAll() {
 	r.add(ResourceDefinition{
 		URI: "ambari://cluster/{clusterName}", Name: "Cluster Details",
 		Description: "Detailed information about a specific cluster", MimeType: "application/json",
-	}, "cluster", func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
-		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s", params["clusterName"]), map[string]string{
+	}, "cluster", []auth.Permission{auth.ClusterView}, func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
+		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s", url.PathEscape(params["clusterName"])), map[string]string{
 			"fields": "Clusters/*,services/ServiceInfo/service_name,services/ServiceInfo/state,hosts/Hosts/host_name,hosts/Hosts/host_status",
 		})
 		return r.wrap("ambari://cluster/"+params["clusterName"], "cluster-details", data), err
@@ -102,8 +141,8 @@ func (r *Registry) registerAll() {
 	r.add(ResourceDefinition{
 		URI: "ambari://cluster/{clusterName}/services", Name: "Cluster Services",
 		Description: "All services running in a cluster with their status", MimeType: "application/json",
-	}, "services", func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
-		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/services", params["clusterName"]), map[string]string{
+	}, "services", []auth.Permission{auth.ServiceView}, func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
+		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/services", url.PathEscape(params["clusterName"])), map[string]string{
 			"fields": "ServiceInfo/service_name,ServiceInfo/state,ServiceInfo/maintenance_state",
 		})
 		return r.wrap("ambari://cluster/"+params["clusterName"]+"/services", "cluster-services", data), err
@@ -113,8 +152,8 @@ func (r *Registry) registerAll() {
 	r.add(ResourceDefinition{
 		URI: "ambari://cluster/{clusterName}/hosts", Name: "Cluster Hosts",
 		Description: "All hosts in a cluster with status and components", MimeType: "application/json",
-	}, "hosts", func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
-		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/hosts", params["clusterName"]), map[string]string{
+	}, "hosts", []auth.Permission{auth.HostView}, func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
+		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/hosts", url.PathEscape(params["clusterName"])), map[string]string{
 			"fields": "Hosts/host_name,Hosts/host_status,Hosts/maintenance_state",
 		})
 		return r.wrap("ambari://cluster/"+params["clusterName"]+"/hosts", "cluster-hosts", data), err
@@ -124,8 +163,8 @@ func (r *Registry) registerAll() {
 	r.add(ResourceDefinition{
 		URI: "ambari://cluster/{clusterName}/alerts", Name: "Cluster Alerts",
 		Description: "Current alerts for a cluster grouped by severity", MimeType: "application/json",
-	}, "alerts", func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
-		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/alerts", params["clusterName"]), map[string]string{
+	}, "alerts", []auth.Permission{auth.AlertView}, func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
+		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/alerts", url.PathEscape(params["clusterName"])), map[string]string{
 			"fields": "Alert/definition_name,Alert/service_name,Alert/host_name,Alert/state,Alert/text",
 		})
 		return r.wrap("ambari://cluster/"+params["clusterName"]+"/alerts", "cluster-alerts", data), err
@@ -135,8 +174,8 @@ func (r *Registry) registerAll() {
 	r.add(ResourceDefinition{
 		URI: "ambari://cluster/{clusterName}/alerts/summary", Name: "Alert Summary",
 		Description: "Summarized alert information for quick health overview", MimeType: "application/json",
-	}, "alerts-summary", func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
-		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/alerts", params["clusterName"]), map[string]string{
+	}, "alerts-summary", []auth.Permission{auth.AlertView}, func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
+		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/alerts", url.PathEscape(params["clusterName"])), map[string]string{
 			"format": "groupedSummary",
 		})
 		return r.wrap("ambari://cluster/"+params["clusterName"]+"/alerts/summary", "alerts-summary", data), err
@@ -146,8 +185,8 @@ func (r *Registry) registerAll() {
 	r.add(ResourceDefinition{
 		URI: "ambari://cluster/{clusterName}/services/stale-configs", Name: "Stale Configurations",
 		Description: "Services needing restart due to configuration changes", MimeType: "application/json",
-	}, "stale-configs", func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
-		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/host_components", params["clusterName"]), map[string]string{
+	}, "stale-configs", []auth.Permission{auth.ServiceView}, func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
+		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/host_components", url.PathEscape(params["clusterName"])), map[string]string{
 			"fields":                  "HostRoles/component_name,HostRoles/host_name,HostRoles/service_name,HostRoles/state,HostRoles/stale_configs",
 			"HostRoles/stale_configs": "true",
 		})
@@ -158,8 +197,8 @@ func (r *Registry) registerAll() {
 	r.add(ResourceDefinition{
 		URI: "ambari://cluster/{clusterName}/service/{serviceName}", Name: "Service Details",
 		Description: "Detailed information about a specific service", MimeType: "application/json",
-	}, "service", func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
-		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/services/%s", params["clusterName"], params["serviceName"]), map[string]string{
+	}, "service", []auth.Permission{auth.ServiceView}, func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
+		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/services/%s", url.PathEscape(params["clusterName"]), url.PathEscape(params["serviceName"])), map[string]string{
 			"fields": "ServiceInfo/*,components/ServiceComponentInfo/*,components/host_components/HostRoles/state",
 		})
 		return r.wrap("ambari://cluster/"+params["clusterName"]+"/service/"+params["serviceName"], "service-details", data), err
@@ -169,8 +208,8 @@ func (r *Registry) registerAll() {
 	r.add(ResourceDefinition{
 		URI: "ambari://cluster/{clusterName}/service/{serviceName}/components", Name: "Service Components",
 		Description: "All components of a service with host assignments", MimeType: "application/json",
-	}, "service-components", func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
-		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/services/%s", params["clusterName"], params["serviceName"]), map[string]string{
+	}, "service-components", []auth.Permission{auth.ServiceView}, func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
+		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/services/%s", url.PathEscape(params["clusterName"]), url.PathEscape(params["serviceName"])), map[string]string{
 			"fields": "components/ServiceComponentInfo/component_name,components/ServiceComponentInfo/category,components/host_components/HostRoles/host_name,components/host_components/HostRoles/state",
 		})
 		return r.wrap("ambari://cluster/"+params["clusterName"]+"/service/"+params["serviceName"]+"/components", "service-components", data), err
@@ -180,8 +219,8 @@ func (r *Registry) registerAll() {
 	r.add(ResourceDefinition{
 		URI: "ambari://host/{hostName}", Name: "Host Details",
 		Description: "Detailed information about a specific host", MimeType: "application/json",
-	}, "host", func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
-		data, err := r.client.Get(ctx, fmt.Sprintf("/hosts/%s", params["hostName"]), map[string]string{
+	}, "host", []auth.Permission{auth.HostView}, func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
+		data, err := r.client.Get(ctx, fmt.Sprintf("/hosts/%s", url.PathEscape(params["hostName"])), map[string]string{
 			"fields": "Hosts/*,host_components/HostRoles/component_name,host_components/HostRoles/state",
 		})
 		return r.wrap("ambari://host/"+params["hostName"], "host-details", data), err
@@ -191,8 +230,8 @@ func (r *Registry) registerAll() {
 	r.add(ResourceDefinition{
 		URI: "ambari://cluster/{clusterName}/requests/recent", Name: "Recent Operations",
 		Description: "Recent operations and their status", MimeType: "application/json",
-	}, "recent-requests", func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
-		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/requests", params["clusterName"]), map[string]string{
+	}, "recent-requests", []auth.Permission{auth.ServiceView}, func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
+		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/requests", url.PathEscape(params["clusterName"])), map[string]string{
 			"fields": "Requests/id,Requests/request_context,Requests/request_status,Requests/progress_percent",
 			"sortBy": "Requests/id.desc", "page_size": "20",
 		})
@@ -203,8 +242,8 @@ func (r *Registry) registerAll() {
 	r.add(ResourceDefinition{
 		URI: "ambari://cluster/{clusterName}/configurations", Name: "Cluster Configurations",
 		Description: "Current configuration types for all services", MimeType: "application/json",
-	}, "configurations", func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
-		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/configurations", params["clusterName"]), map[string]string{
+	}, "configurations", []auth.Permission{auth.ConfigView}, func(ctx context.Context, params map[string]string) (*ResourceResult, error) {
+		data, err := r.client.Get(ctx, fmt.Sprintf("/clusters/%s/configurations", url.PathEscape(params["clusterName"])), map[string]string{
 			"fields": "Config/type,Config/tag,Config/version",
 		})
 		return r.wrap("ambari://cluster/"+params["clusterName"]+"/configurations", "configurations", data), err
@@ -213,9 +252,9 @@ func (r *Registry) registerAll() {
 	r.logger.WithField("count", len(r.definitions)).Info("MCP resources registered")
 }
 
-func (r *Registry) add(def ResourceDefinition, resType string, handler Handler) {
+func (r *Registry) add(def ResourceDefinition, resType string, perms []auth.Permission, handler Handler) {
 	r.definitions = append(r.definitions, def)
-	r.handlers[resType] = handler
+	r.resources[resType] = resource{perms: perms, handler: handler}
 }
 
 func (r *Registry) wrap(uri, resType string, data interface{}) *ResourceResult {
@@ -234,6 +273,12 @@ func (r *Registry) parseURI(uri string) (string, map[string]string, error) {
 	path := strings.TrimPrefix(uri, "ambari://")
 	params := map[string]string{}
 
+	// A leading segment that is not a resource path names the Ambari instance
+	if first, rest, ok := strings.Cut(path, "/"); ok && first != "cluster" && first != "host" {
+		params["ambariInstance"] = first
+		path = rest
+	}
+
 	if path == "clusters" {
 		return "clusters", params, nil
 	}
@@ -280,6 +325,17 @@ func (r *Registry) parseURI(uri string) (string, map[string]string, error) {
 	return "", nil, fmt.Errorf("unsupported resource URI: %s", uri)
 }
 
+// checkNames rejects URI segments that cannot name an Ambari resource. Names
+// are path-escaped when building Ambari paths, which leaves "." and "..".
+func checkNames(uri string, params map[string]string) error {
+	for _, name := range params {
+		if name == "" || name == "." || name == ".." {
+			return fmt.Errorf("invalid resource URI: %s", uri)
+		}
+	}
+	return nil
+}
+
 // ToJSON converts a ResourceResult to JSON string
 func (r *ResourceResult) ToJSON() string {
 	b, _ := json.MarshalIndent(r, "", "  ")
@@ -0,0 +1,111 @@
+package resources
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"mcp-ambari/internal/auth"
+	ops "mcp-ambari/internal/operations"
+	"github.com/sirupsen/logrus"
+)
+
+// recordingClient records the paths it is asked for
+type recordingClient struct {
+	paths []string
+}
+
+func (c *recordingClient) Get(_ context.Context, path string, _ map[string]string) (map[string]interface{}, error) {
+	c.paths = append(c.paths, path)
+	return map[string]interface{}{}, nil
+}
+func (c *recordingClient) Post(context.Context, string, map[string]string, interface{}) (map[string]interface{}, error) {
+	panic("resources are read-only")
+}
+func (c *recordingClient) Put(context.Context, string, map[string]string, interface{}) (map[string]interface{}, error) {
+	panic("resources are read-only")
+}
+func (c *recordingClient) Delete(context.Context, string, map[string]string) (map[string]interface{}, error) {
+	panic("resources are read-only")
+}
+
+func testRegistry(c *recordingClient) *Registry {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewRegistry(c, ops.NewExecutor(c, nil, nil, nil, logger), logger)
+}
+
+var admin = &auth.AuthContext{Username: "admin", Permissions: auth.AllPermissions}
+
+func TestReadPaths(t *testing.T) {
+	tests := []struct {
+		uri      string
+		wantPath string
+		wantErr  bool
+	}{
+		{uri: "ambari://clusters", wantPath: "/clusters"},
+		{uri: "ambari://cluster/c1/services", wantPath: "/clusters/c1/services"},
+		{uri: "ambari://prod/cluster/c1/service/HDFS/components", wantPath: "/clusters/c1/services/HDFS"},
+		{uri: "ambari://host/h1.example.com", wantPath: "/hosts/h1.example.com"},
+		{uri: "ambari://host/../users/admin", wantPath: "/hosts/..%2Fusers%2Fadmin"},
+		{uri: "ambari://cluster/c1?x=1/hosts", wantPath: "/clusters/c1%3Fx=1/hosts"},
+		{uri: "ambari://host/..", wantErr: true},
+		{uri: "ambari://cluster/./alerts", wantErr: true},
+		{uri: "ambari://cluster//alerts", wantErr: true},
+		{uri: "ambari://cluster/c1/unknown", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			c := &recordingClient{}
+			_, err := testRegistry(c).Read(context.Background(), tt.uri, admin)
+			if tt.wantErr {
+				if err == nil || len(c.paths) > 0 {
+					t.Fatalf("Read() error = %v, requested %v, want error without request", err, c.paths)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if len(c.paths) != 1 || c.paths[0] != tt.wantPath {
+				t.Errorf("Read() requested %v, want %s", c.paths, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestReadAuthorization(t *testing.T) {
+	tests := []struct {
+		name     string
+		uri      string
+		grants   []auth.Permission
+		clusters []string
+		wantErr  bool
+	}{
+		{name: "global grant", uri: "ambari://cluster/c1/alerts", grants: []auth.Permission{auth.AlertView}},
+		{name: "missing permission", uri: "ambari://cluster/c1/alerts", grants: []auth.Permission{auth.ServiceView}, wantErr: true},
+		{name: "scoped grant on its cluster", uri: "ambari://cluster/c1/alerts", grants: []auth.Permission{"alert:view@c1"}},
+		{name: "scoped grant on other cluster", uri: "ambari://cluster/c2/alerts", grants: []auth.Permission{"alert:view@c1"}, wantErr: true},
+		{name: "service grant on its service", uri: "ambari://cluster/c1/service/HDFS", grants: []auth.Permission{"service:view@c1/HDFS"}},
+		{name: "service grant on other service", uri: "ambari://cluster/c1/service/YARN", grants: []auth.Permission{"service:view@c1/HDFS"}, wantErr: true},
+		{name: "service grant on whole cluster", uri: "ambari://cluster/c1/services", grants: []auth.Permission{"service:view@c1/HDFS"}, wantErr: true},
+		{name: "scoped grant on untargeted resource", uri: "ambari://host/h1", grants: []auth.Permission{"host:view@c1"}, wantErr: true},
+		{name: "scoped grant on cluster list", uri: "ambari://clusters", grants: []auth.Permission{"cluster:view@c1"}, wantErr: true},
+		{name: "allowed cluster", uri: "ambari://cluster/c1", grants: []auth.Permission{auth.ClusterView}, clusters: []string{"c1"}},
+		{name: "other cluster", uri: "ambari://cluster/c2", grants: []auth.Permission{auth.ClusterView}, clusters: []string{"c1"}, wantErr: true},
+		{name: "cluster restricted on untargeted resource", uri: "ambari://clusters", grants: []auth.Permission{auth.ClusterView}, clusters: []string{"c1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &recordingClient{}
+			authCtx := &auth.AuthContext{Username: "alice", Permissions: tt.grants, Clusters: tt.clusters}
+			_, err := testRegistry(c).Read(context.Background(), tt.uri, authCtx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Read() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr && len(c.paths) > 0 {
+				t.Errorf("denied read requested %v", c.paths)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"mcp-ambari/internal/auth"
@@ -35,6 +36,8 @@ type Config struct {
 	SSLCert    string `json:"ssl_cert"`
 	SSLKey     string `json:"ssl_key"`
 	SSLCACerts string `json:"ssl_ca_certs"`
+	// SessionTimeout closes MCP sessions idle for this long; zero keeps them
+	SessionTimeout time.Duration `json:"session_timeout"`
 }
 
 // MCPServer wraps the actual mcp.Server for transport use
@@ -103,7 +106,7 @@ func (t *HTTPTransport) Start(ctx context.Context, mcpServer *MCPServer) error {
 	// Create streamable HTTP handler for MCP-over-HTTP (using MCP Go SDK)
 	handler := mcp.NewStreamableHTTPHandler(func(req *http.Request) *mcp.Server {
 		return mcpServer.Server
-	}, nil)
+	}, &mcp.StreamableHTTPOptions{SessionTimeout: t.cfg.SessionTimeout})
 	
 	// Apply auth middleware if provided
 	var httpHandler http.Handler = handler
@@ -156,7 +159,7 @@ func (t *SSLTransport) Start(ctx context.Context, mcpServer *MCPServer) error {
 	// Create streamable HTTP handler for MCP-over-HTTPS
 	handler := mcp.NewStreamableHTTPHandler(func(req *http.Request) *mcp.Server {
 		return mcpServer.Server
-	}, nil)
+	}, &mcp.StreamableHTTPOptions{SessionTimeout: t.cfg.SessionTimeout})
 
 	// Apply auth middleware if provided
 	var httpHandler http.Handler = handler
@@ -231,7 +234,7 @@ func (t *MTLSTransport) Start(ctx context.Context, mcpServer *MCPServer) error {
 	// Create streamable HTTP handler for MCP-over-HTTPS-mTLS
 	handler := mcp.NewStreamableHTTPHandler(func(req *http.Request) *mcp.Server {
 		return mcpServer.Server
-	}, nil)
+	}, &mcp.StreamableHTTPOptions{SessionTimeout: t.cfg.SessionTimeout})
 
 	// Apply auth middleware if provided
 	var httpHandler http.Handler = handler